@@ -0,0 +1,394 @@
+// Copyright 2019 Tskken. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package quadgo
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+)
+
+// binaryMagic is the first bytes of every tree written in the binary format.
+var binaryMagic = [4]byte{'Q', 'D', 'G', 'O'}
+
+// binaryVersion is the version of the binary format written by QuadGo.
+const binaryVersion uint16 = 1
+
+// decodeMaxEntities is the largest max entities option read from the binary or JSON format,
+// as every node of the tree read is made with room for that many entities.
+const decodeMaxEntities = 1 << 16
+
+// binaryEntitySize is the fewest bytes an entity takes in the binary format, its ID, Bound
+// and the length of its payload.
+const binaryEntitySize = 8 + 4*8 + 4
+
+// node kinds in the binary format.
+const (
+	binaryLeaf   byte = 0
+	binaryBranch byte = 1
+)
+
+// PayloadCodec encodes and decodes the Data of entities for the binary format of a tree.
+//
+// A PayloadCodec is set on a tree with the SetPayloadCodec Option. Without one the Data
+// of entities is not written and is left nil when read.
+type PayloadCodec interface {
+	// EncodePayload returns the encoded Data of the given entity.
+	EncodePayload(entity *Entity) ([]byte, error)
+	// DecodePayload sets the Data of the given entity from the given encoded data.
+	DecodePayload(entity *Entity, data []byte) error
+}
+
+// MarshalBinary encodes the tree in to its binary format.
+//
+// The binary format holds the options of the tree, its node structure and all of its
+// entities ID and Bound along with the Data of each entity if a PayloadCodec is set.
+// Action functions are not encoded.
+func (q *QuadGo) MarshalBinary() ([]byte, error) {
+	var buf bytes.Buffer
+	if _, err := q.WriteTo(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// UnmarshalBinary decodes a tree from its binary format, replacing all of this trees
+// options, nodes and entities. The PayloadCodec of this tree is used to decode the Data
+// of each entity and is kept, while its Observer and node pool are not. See ReadFrom().
+func (q *QuadGo) UnmarshalBinary(data []byte) error {
+	_, err := q.ReadFrom(bytes.NewReader(data))
+	return err
+}
+
+// WriteTo writes the tree in its binary format to the given writer.
+// See MarshalBinary().
+func (q *QuadGo) WriteTo(w io.Writer) (int64, error) {
+	e := &binaryEncoder{w: w}
+
+	// write header and options
+	e.bytes(binaryMagic[:])
+	e.uint16(binaryVersion)
+	e.uint64(q.maxEntities)
+	e.uint16(q.maxDepth)
+	e.bound(q.bound)
+
+	// write each entity once, saving its index for the node structure
+	indexes := make(map[*Entity]uint32, q.size)
+	entities := make(Entities, 0, q.size)
+	q.forEach(q.bound, func(entity *Entity) bool {
+		if _, ok := indexes[entity]; !ok {
+			indexes[entity] = uint32(len(entities))
+			entities = append(entities, entity)
+		}
+		return true
+	})
+
+	e.uint32(uint32(len(entities)))
+	for _, entity := range entities {
+		e.uint64(entity.ID)
+		e.bound(entity.Bound)
+
+		var payload []byte
+		if q.codec != nil && e.err == nil {
+			payload, e.err = q.codec.EncodePayload(entity)
+		}
+		e.uint32(uint32(len(payload)))
+		e.bytes(payload)
+	}
+
+	// write the node structure
+	e.node(q.node, indexes)
+
+	return e.n, e.err
+}
+
+// ReadFrom reads a tree in its binary format from the given reader.
+// See UnmarshalBinary().
+//
+// ReadFrom only reads the bytes of the tree so more data can follow it in the reader.
+//
+// The lengths read are checked against the data left when the reader has a Len() method, such
+// as a bytes.Reader, and payloads are otherwise only grown as their data is read, so corrupt or
+// truncated data returns an error instead of allocating more memory then it holds. A tree with
+// a max entities option over 65536 can not be read. The tree read is checked with Validate(),
+// returning its error if the node structure is not valid, and entities which are not held by
+// any node are not part of the tree.
+//
+// The Observer and node pool of this tree are not kept, as they are not part of the binary
+// format, so the tree read has neither.
+func (q *QuadGo) ReadFrom(r io.Reader) (int64, error) {
+	d := &binaryDecoder{r: r}
+
+	// read header and options
+	var magic [4]byte
+	d.bytes(magic[:])
+	if d.err == nil && magic != binaryMagic {
+		return d.n, errors.New("data is not a QuadGo binary tree")
+	}
+	if version := d.uint16(); d.err == nil && version != binaryVersion {
+		return d.n, errors.New("unsupported QuadGo binary version")
+	}
+	maxEntities := d.uint64()
+	maxDepth := d.uint16()
+	bound := d.bound()
+	if d.err == nil && maxEntities > decodeMaxEntities {
+		return d.n, errors.New("QuadGo binary tree has too many max entities")
+	}
+
+	// read entities
+	count := d.uint32()
+	if d.err != nil {
+		return d.n, d.err
+	}
+	if left, ok := d.remaining(); ok && int64(count)*binaryEntitySize > left {
+		return d.n, io.ErrUnexpectedEOF
+	}
+	var entities Entities
+	for i := uint32(0); i < count && d.err == nil; i++ {
+		entity := &Entity{
+			ID:    d.uint64(),
+			Bound: d.bound(),
+		}
+
+		payload := d.payload(d.uint32())
+		if len(payload) > 0 && q.codec != nil && d.err == nil {
+			d.err = q.codec.DecodePayload(entity, payload)
+		}
+
+		entities = append(entities, entity)
+	}
+	if d.err != nil {
+		return d.n, d.err
+	}
+
+	// read the node structure
+	root := &node{
+		parent:   nil,
+		bound:    bound,
+		entities: make(Entities, 0, maxEntities),
+		children: make(nodes, 0, 4),
+		depth:    0,
+	}
+	d.node(root, entities, maxDepth)
+	if d.err != nil {
+		return d.n, d.err
+	}
+
+	// check the node structure read, counting the entities its leaves hold as any entity
+	// no leaf holds is not in the tree
+	t := &QuadGo{
+		node:        root,
+		maxEntities: maxEntities,
+		maxDepth:    maxDepth,
+		codec:       q.codec,
+	}
+	t.forEach(t.bound, func(*Entity) bool {
+		t.size++
+		return true
+	})
+	if err := t.Validate(); err != nil {
+		return d.n, err
+	}
+
+	*q = *t
+
+	return d.n, nil
+}
+
+// binaryEncoder writes values in the binary format, keeping the first error and the
+// number of bytes written.
+type binaryEncoder struct {
+	w   io.Writer
+	buf [8]byte
+	n   int64
+	err error
+}
+
+func (e *binaryEncoder) bytes(b []byte) {
+	if e.err != nil {
+		return
+	}
+	n, err := e.w.Write(b)
+	e.n += int64(n)
+	e.err = err
+}
+
+func (e *binaryEncoder) byte(b byte) {
+	e.buf[0] = b
+	e.bytes(e.buf[:1])
+}
+
+func (e *binaryEncoder) uint16(v uint16) {
+	binary.LittleEndian.PutUint16(e.buf[:2], v)
+	e.bytes(e.buf[:2])
+}
+
+func (e *binaryEncoder) uint32(v uint32) {
+	binary.LittleEndian.PutUint32(e.buf[:4], v)
+	e.bytes(e.buf[:4])
+}
+
+func (e *binaryEncoder) uint64(v uint64) {
+	binary.LittleEndian.PutUint64(e.buf[:8], v)
+	e.bytes(e.buf[:8])
+}
+
+func (e *binaryEncoder) float64(v float64) {
+	e.uint64(math.Float64bits(v))
+}
+
+func (e *binaryEncoder) bound(b Bound) {
+	e.float64(b.Min.X)
+	e.float64(b.Min.Y)
+	e.float64(b.Max.X)
+	e.float64(b.Max.Y)
+}
+
+// node writes the given node and its children, writing entities as their index.
+func (e *binaryEncoder) node(n *node, indexes map[*Entity]uint32) {
+	if len(n.children) > 0 {
+		e.byte(binaryBranch)
+		for i := range n.children {
+			e.node(n.children[i], indexes)
+		}
+		return
+	}
+
+	e.byte(binaryLeaf)
+	e.uint32(uint32(len(n.entities)))
+	for _, entity := range n.entities {
+		e.uint32(indexes[entity])
+	}
+}
+
+// binaryDecoder reads values in the binary format, keeping the first error and the
+// number of bytes read.
+type binaryDecoder struct {
+	r   io.Reader
+	buf [8]byte
+	n   int64
+	err error
+}
+
+func (d *binaryDecoder) bytes(b []byte) {
+	if d.err != nil {
+		return
+	}
+	n, err := io.ReadFull(d.r, b)
+	d.n += int64(n)
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	d.err = err
+}
+
+// payload reads an entity payload of the given size.
+//
+// If the size of the data left is not known the payload is read in to a buffer that only
+// grows as the data is read, so a corrupt size can not allocate more then the data holds.
+func (d *binaryDecoder) payload(size uint32) []byte {
+	if d.err != nil || size == 0 {
+		return nil
+	}
+
+	left, ok := d.remaining()
+	if ok {
+		if int64(size) > left {
+			d.setErr(io.ErrUnexpectedEOF)
+			return nil
+		}
+
+		payload := make([]byte, size)
+		d.bytes(payload)
+		return payload
+	}
+
+	var buf bytes.Buffer
+	n, err := io.CopyN(&buf, d.r, int64(size))
+	d.n += n
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	d.setErr(err)
+	return buf.Bytes()
+}
+
+// remaining returns the number of bytes left to read, if the reader can tell.
+func (d *binaryDecoder) remaining() (int64, bool) {
+	if r, ok := d.r.(interface{ Len() int }); ok {
+		return int64(r.Len()), true
+	}
+	return 0, false
+}
+
+func (d *binaryDecoder) byte() byte {
+	d.bytes(d.buf[:1])
+	return d.buf[0]
+}
+
+func (d *binaryDecoder) uint16() uint16 {
+	d.bytes(d.buf[:2])
+	return binary.LittleEndian.Uint16(d.buf[:2])
+}
+
+func (d *binaryDecoder) uint32() uint32 {
+	d.bytes(d.buf[:4])
+	return binary.LittleEndian.Uint32(d.buf[:4])
+}
+
+func (d *binaryDecoder) uint64() uint64 {
+	d.bytes(d.buf[:8])
+	return binary.LittleEndian.Uint64(d.buf[:8])
+}
+
+func (d *binaryDecoder) float64() float64 {
+	return math.Float64frombits(d.uint64())
+}
+
+func (d *binaryDecoder) bound() Bound {
+	minX, minY := d.float64(), d.float64()
+	maxX, maxY := d.float64(), d.float64()
+	return NewBound(minX, minY, maxX, maxY)
+}
+
+// node reads the given node and its children, finding each entity by its index.
+func (d *binaryDecoder) node(n *node, entities Entities, maxDepth uint16) {
+	switch d.byte() {
+	case binaryBranch:
+		if n.depth >= maxDepth {
+			d.setErr(errors.New("QuadGo binary tree is deeper then its max depth"))
+			return
+		}
+
+		n.split(nil)
+		for i := range n.children {
+			d.node(n.children[i], entities, maxDepth)
+		}
+	case binaryLeaf:
+		count := d.uint32()
+		for i := uint32(0); i < count && d.err == nil; i++ {
+			index := d.uint32()
+			if d.err != nil {
+				return
+			}
+			if index >= uint32(len(entities)) {
+				d.setErr(errors.New("QuadGo binary tree has an invalid entity index"))
+				return
+			}
+			n.entities = append(n.entities, entities[index])
+		}
+	default:
+		d.setErr(errors.New("QuadGo binary tree has an invalid node"))
+	}
+}
+
+// setErr sets the error of the decoder if it does not already have one.
+func (d *binaryDecoder) setErr(err error) {
+	if d.err == nil {
+		d.err = err
+	}
+}
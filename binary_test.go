@@ -0,0 +1,400 @@
+// Copyright 2019 Tskken. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package quadgo
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"reflect"
+	"testing"
+)
+
+// stringCodec is a PayloadCodec for entities with string Data.
+type stringCodec struct{}
+
+func (stringCodec) EncodePayload(entity *Entity) ([]byte, error) {
+	s, _ := entity.Data.(string)
+	return []byte(s), nil
+}
+
+func (stringCodec) DecodePayload(entity *Entity, data []byte) error {
+	entity.Data = string(data)
+	return nil
+}
+
+// errorCodec is a PayloadCodec that always fails.
+type errorCodec struct{}
+
+func (errorCodec) EncodePayload(*Entity) ([]byte, error) {
+	return nil, errors.New("encode error")
+}
+
+func (errorCodec) DecodePayload(*Entity, []byte) error {
+	return errors.New("decode error")
+}
+
+func TestQuadGo_MarshalBinary(t *testing.T) {
+	type fields struct {
+		quadgo   *QuadGo
+		entities Entities
+	}
+	tests := []struct {
+		name   string
+		fields fields
+	}{
+		{
+			name: "empty tree",
+			fields: fields{
+				quadgo: New(800, 600),
+			},
+		},
+		{
+			name: "split tree with duplicate references",
+			fields: fields{
+				quadgo: New(800, 600, SetMaxEntities(3), SetMaxDepth(4)),
+				entities: append(randomEntities(200, 800, 600),
+					&Entity{ID: 1000, Bound: NewBound(0, 0, 800, 600)},
+				),
+			},
+		},
+		{
+			name: "tree with payloads",
+			fields: fields{
+				quadgo: New(800, 600, SetMaxEntities(2), SetPayloadCodec(stringCodec{})),
+				entities: Entities{
+					&Entity{ID: 1, Bound: NewBound(0, 0, 50, 50), Data: "player"},
+					&Entity{ID: 2, Bound: NewBound(500, 400, 700, 600), Data: "wall"},
+					&Entity{ID: 3, Bound: NewBound(350, 250, 450, 350)},
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.fields.quadgo.InsertEntities(tt.fields.entities...)
+			if err != nil && len(tt.fields.entities) > 0 {
+				t.Errorf("QuadGo.MarshalBinary() got error on insert %v", err)
+			}
+
+			data, err := tt.fields.quadgo.MarshalBinary()
+			if err != nil {
+				t.Errorf("QuadGo.MarshalBinary() got error %v", err)
+			}
+
+			got := New(0, 0, SetPayloadCodec(tt.fields.quadgo.codec))
+			if err := got.UnmarshalBinary(data); err != nil {
+				t.Errorf("QuadGo.UnmarshalBinary() got error %v", err)
+			}
+
+			if !sameNode(got.node, tt.fields.quadgo.node) || !checkParents(got.node) {
+				t.Errorf("QuadGo.UnmarshalBinary() tree does not match original tree")
+			}
+			if got.maxEntities != tt.fields.quadgo.maxEntities || got.maxDepth != tt.fields.quadgo.maxDepth {
+				t.Errorf("QuadGo.UnmarshalBinary() options = %v, %v, want %v, %v",
+					got.maxEntities, got.maxDepth, tt.fields.quadgo.maxEntities, tt.fields.quadgo.maxDepth)
+			}
+			if got.Len() != tt.fields.quadgo.Len() {
+				t.Errorf("QuadGo.UnmarshalBinary() Len() = %v, want %v", got.Len(), tt.fields.quadgo.Len())
+			}
+
+			all := <-got.All()
+			for _, e := range tt.fields.entities {
+				found := false
+				for _, g := range all {
+					if g.IsEqual(e) {
+						found = true
+						if g.Data != e.Data {
+							t.Errorf("QuadGo.UnmarshalBinary() entity data = %v, want %v", g.Data, e.Data)
+						}
+					}
+				}
+				if !found {
+					t.Errorf("QuadGo.UnmarshalBinary() could not find %v", e)
+				}
+			}
+
+			// entities referenced from more then one leaf should decode to one entity
+			seen := make(map[uint64]*Entity)
+			for _, e := range <-got.Retrieve(got.Bounds()) {
+				if s, ok := seen[e.ID]; ok && s != e {
+					t.Errorf("QuadGo.UnmarshalBinary() entity %v decoded more then once", e)
+				}
+				seen[e.ID] = e
+			}
+		})
+	}
+}
+
+func TestQuadGo_ReadFrom(t *testing.T) {
+	type args struct {
+		data []byte
+	}
+	valid, _ := Build(800, 600, randomEntities(20, 800, 600), SetMaxEntities(2)).MarshalBinary()
+	tests := []struct {
+		name    string
+		args    args
+		wantErr error
+	}{
+		{
+			name: "valid tree",
+			args: args{
+				data: valid,
+			},
+			wantErr: nil,
+		},
+		{
+			name: "bad magic",
+			args: args{
+				data: append([]byte("QDGX"), valid[4:]...),
+			},
+			wantErr: errors.New("data is not a QuadGo binary tree"),
+		},
+		{
+			name: "bad version",
+			args: args{
+				data: append(append([]byte("QDGO"), 2, 0), valid[6:]...),
+			},
+			wantErr: errors.New("unsupported QuadGo binary version"),
+		},
+		{
+			name: "truncated tree",
+			args: args{
+				data: valid[:len(valid)-3],
+			},
+			wantErr: io.ErrUnexpectedEOF,
+		},
+		{
+			name: "empty data",
+			args: args{
+				data: nil,
+			},
+			wantErr: io.ErrUnexpectedEOF,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := New(100, 100)
+			n, err := q.ReadFrom(bytes.NewReader(tt.args.data))
+			if !reflect.DeepEqual(err, tt.wantErr) {
+				t.Errorf("QuadGo.ReadFrom() got an unwanted error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && n != int64(len(tt.args.data)) {
+				t.Errorf("QuadGo.ReadFrom() read %v bytes, want %v", n, len(tt.args.data))
+			}
+			if err != nil && !q.Bounds().IsEqual(NewBound(0, 0, 100, 100)) {
+				t.Errorf("QuadGo.ReadFrom() changed the tree on error")
+			}
+		})
+	}
+}
+
+// payloadTree returns the binary format of a split tree whose entities all have payloads.
+func payloadTree(t *testing.T) []byte {
+	q := New(800, 600, SetMaxEntities(2), SetPayloadCodec(stringCodec{}))
+	for _, e := range randomEntities(10, 800, 600) {
+		e.Data = "payload"
+		q.InsertEntities(e)
+	}
+
+	data, err := q.MarshalBinary()
+	if err != nil {
+		t.Fatalf("QuadGo.MarshalBinary() got error %v", err)
+	}
+	return data
+}
+
+// onlyReader hides any methods of a reader other then Read, so the decoder can not tell how
+// much data is left.
+type onlyReader struct {
+	io.Reader
+}
+
+func TestQuadGo_ReadFrom_truncated(t *testing.T) {
+	data := payloadTree(t)
+
+	// every truncation of the tree is an error, whether the reader can tell how much is left or not
+	for i := 0; i < len(data); i++ {
+		readers := []io.Reader{bytes.NewReader(data[:i]), onlyReader{bytes.NewReader(data[:i])}}
+		for _, r := range readers {
+			q := New(100, 100, SetPayloadCodec(stringCodec{}))
+			if _, err := q.ReadFrom(r); err != io.ErrUnexpectedEOF {
+				t.Errorf("QuadGo.ReadFrom() of %v of %v bytes got error %v, want %v", i, len(data), err, io.ErrUnexpectedEOF)
+			}
+			if q.Len() != 0 || !q.Bounds().IsEqual(NewBound(0, 0, 100, 100)) {
+				t.Errorf("QuadGo.ReadFrom() of %v of %v bytes changed the tree on error", i, len(data))
+			}
+		}
+	}
+}
+
+func TestQuadGo_ReadFrom_corrupted(t *testing.T) {
+	// the header is the magic, version, max entities, max depth and bound, followed by the
+	// number of entities, the ID, bound, payload length and payload of each entity and then
+	// the root node
+	const (
+		maxEntitiesAt = 4 + 2
+		countAt       = maxEntitiesAt + 8 + 2 + 32
+		payloadAt     = countAt + 4 + 8 + 32
+		rootAt        = countAt + 4 + 10*(binaryEntitySize+len("payload"))
+	)
+	corrupt := func(at int, value ...byte) []byte {
+		data := payloadTree(t)
+		copy(data[at:], value)
+		return data
+	}
+	max := []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}
+
+	tests := []struct {
+		name    string
+		data    []byte
+		wantErr error
+	}{
+		{
+			name:    "max entities",
+			data:    corrupt(maxEntitiesAt, max...),
+			wantErr: errors.New("QuadGo binary tree has too many max entities"),
+		},
+		{
+			name:    "entity count",
+			data:    corrupt(countAt, max[:4]...),
+			wantErr: io.ErrUnexpectedEOF,
+		},
+		{
+			name:    "payload length",
+			data:    corrupt(payloadAt, max[:4]...),
+			wantErr: io.ErrUnexpectedEOF,
+		},
+		{
+			name:    "node kind",
+			data:    corrupt(rootAt, 7),
+			wantErr: errors.New("QuadGo binary tree has an invalid node"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, r := range []io.Reader{bytes.NewReader(tt.data), onlyReader{bytes.NewReader(tt.data)}} {
+				q := New(100, 100, SetPayloadCodec(stringCodec{}))
+				if _, err := q.ReadFrom(r); !reflect.DeepEqual(err, tt.wantErr) {
+					t.Errorf("QuadGo.ReadFrom() got an unwanted error = %v, want %v", err, tt.wantErr)
+				}
+				if q.Len() != 0 {
+					t.Errorf("QuadGo.ReadFrom() changed the tree on error")
+				}
+			}
+		})
+	}
+}
+
+// leafTree returns the binary format of a tree 100 wide and high with the given entities whose
+// root is a leaf holding the entities at the given indexes.
+func leafTree(entities Entities, indexes ...uint32) []byte {
+	var buf bytes.Buffer
+	e := &binaryEncoder{w: &buf}
+	e.bytes(binaryMagic[:])
+	e.uint16(binaryVersion)
+	e.uint64(10)
+	e.uint16(4)
+	e.bound(NewBound(0, 0, 100, 100))
+
+	e.uint32(uint32(len(entities)))
+	for _, entity := range entities {
+		e.uint64(entity.ID)
+		e.bound(entity.Bound)
+		e.uint32(0)
+	}
+
+	e.byte(binaryLeaf)
+	e.uint32(uint32(len(indexes)))
+	for _, i := range indexes {
+		e.uint32(i)
+	}
+	return buf.Bytes()
+}
+
+func TestQuadGo_ReadFrom_nodes(t *testing.T) {
+	entities := Entities{
+		&Entity{ID: 1, Bound: NewBound(0, 0, 10, 10)},
+		&Entity{ID: 2, Bound: NewBound(20, 20, 30, 30)},
+		&Entity{ID: 3, Bound: NewBound(200, 200, 300, 300)},
+	}
+
+	tests := []struct {
+		name    string
+		data    []byte
+		wantLen int
+		wantErr bool
+	}{
+		{name: "every entity held", data: leafTree(entities[:2], 0, 1), wantLen: 2},
+		{name: "entity held by no node", data: leafTree(entities[:2], 0), wantLen: 1},
+		{name: "entity held twice by a leaf", data: leafTree(entities[:2], 0, 1, 1), wantErr: true},
+		{name: "entity outside of the node holding it", data: leafTree(entities, 0, 2), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := New(100, 100, SetObserver(&recorder{}), SetNodePool(16))
+			_, err := q.ReadFrom(bytes.NewReader(tt.data))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("QuadGo.ReadFrom() got error %v, want error %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				if _, ok := err.(*ValidationError); !ok {
+					t.Errorf("QuadGo.ReadFrom() error = %T, want *ValidationError", err)
+				}
+				if q.Len() != 0 || q.observer == nil {
+					t.Errorf("QuadGo.ReadFrom() changed the tree on error")
+				}
+				return
+			}
+
+			if q.Len() != tt.wantLen {
+				t.Errorf("QuadGo.Len() = %v, want %v", q.Len(), tt.wantLen)
+			}
+			if got := len(<-q.All()); got != q.Len() {
+				t.Errorf("QuadGo.All() = %v entities, want %v", got, q.Len())
+			}
+			if err := q.Validate(); err != nil {
+				t.Errorf("QuadGo.Validate() got error %v", err)
+			}
+			if q.observer != nil || q.pool != nil {
+				t.Errorf("QuadGo.ReadFrom() kept the observer or node pool of the tree")
+			}
+		})
+	}
+}
+
+func TestQuadGo_WriteTo(t *testing.T) {
+	lhs := Build(800, 600, randomEntities(50, 800, 600), SetMaxEntities(2))
+	rhs := Build(400, 300, randomEntities(10, 400, 300))
+
+	// write two trees to the same stream and read them back in order
+	var buf bytes.Buffer
+	for _, q := range []*QuadGo{lhs, rhs} {
+		n, err := q.WriteTo(&buf)
+		if err != nil {
+			t.Errorf("QuadGo.WriteTo() got error %v", err)
+		}
+		if n == 0 {
+			t.Errorf("QuadGo.WriteTo() wrote no bytes")
+		}
+	}
+
+	for _, want := range []*QuadGo{lhs, rhs} {
+		got := New(0, 0)
+		if _, err := got.ReadFrom(&buf); err != nil {
+			t.Errorf("QuadGo.ReadFrom() got error %v", err)
+		}
+		if !sameNode(got.node, want.node) {
+			t.Errorf("QuadGo.ReadFrom() tree does not match written tree")
+		}
+	}
+
+	// payload codec errors should be returned
+	q := New(800, 600, SetPayloadCodec(errorCodec{}))
+	q.Insert(0, 0, 50, 50)
+	if _, err := q.WriteTo(&buf); !reflect.DeepEqual(err, errors.New("encode error")) {
+		t.Errorf("QuadGo.WriteTo() got an unwanted error = %v, want encode error", err)
+	}
+}
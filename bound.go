@@ -22,8 +22,8 @@ func NewBound(minX, minY, maxX, maxY float64) Bound {
 		Min: Point{X: minX, Y: minY},
 		Max: Point{X: maxX, Y: maxY},
 		Center: Point{
-			X: maxX - ((math.Abs(maxX) - math.Abs(minX)) / 2),
-			Y: maxY - ((math.Abs(maxY) - math.Abs(minY)) / 2),
+			X: (minX + maxX) / 2,
+			Y: (minY + maxY) / 2,
 		},
 	}
 }
@@ -41,6 +41,64 @@ func (b Bound) IsIntersect(bounds Bound) bool {
 	return !(bounds.Max.X < b.Min.X || bounds.Min.X > b.Max.X || bounds.Max.Y < b.Min.Y || bounds.Min.Y > b.Max.Y)
 }
 
+// quadrants returns the four quarters of this bound split at its center.
+//
+// The quadrants are ordered top left, top right, bottom left and then bottom right.
+func (b Bound) quadrants() [4]Bound {
+	return [4]Bound{
+		NewBound(b.Min.X, b.Min.Y, b.Center.X, b.Center.Y), // Top Left
+		NewBound(b.Center.X, b.Min.Y, b.Max.X, b.Center.Y), // Top Right
+		NewBound(b.Min.X, b.Center.Y, b.Center.X, b.Max.Y), // Bottom Left
+		NewBound(b.Center.X, b.Center.Y, b.Max.X, b.Max.Y), // Bottom Right
+	}
+}
+
+// clamp returns the given point moved to be with in this bound.
+func (b Bound) clamp(p Point) Point {
+	return Point{
+		X: math.Min(math.Max(p.X, b.Min.X), b.Max.X),
+		Y: math.Min(math.Max(p.Y, b.Min.Y), b.Max.Y),
+	}
+}
+
+// distance returns the distance from the given point to the closest point with in this bound.
+func (b Bound) distance(p Point) float64 {
+	return length(sub(p, b.clamp(p)))
+}
+
+// union returns the smallest bound covering both this bound and the given bound.
+func (b Bound) union(bound Bound) Bound {
+	return NewBound(
+		math.Min(b.Min.X, bound.Min.X), math.Min(b.Min.Y, bound.Min.Y),
+		math.Max(b.Max.X, bound.Max.X), math.Max(b.Max.Y, bound.Max.Y),
+	)
+}
+
+// contains returns if the given bound is fully with in this bound.
+func (b Bound) contains(bound Bound) bool {
+	return bound.Min.X >= b.Min.X && bound.Min.Y >= b.Min.Y && bound.Max.X <= b.Max.X && bound.Max.Y <= b.Max.Y
+}
+
+// containsPoint returns if the given point is with in this bound.
+func (b Bound) containsPoint(p Point) bool {
+	return p.X >= b.Min.X && p.Y >= b.Min.Y && p.X <= b.Max.X && p.Y <= b.Max.Y
+}
+
+// grow returns this bound grown by the given margin on every side.
+func (b Bound) grow(margin float64) Bound {
+	return NewBound(b.Min.X-margin, b.Min.Y-margin, b.Max.X+margin, b.Max.Y+margin)
+}
+
+// perimeter returns the perimeter of this bound.
+func (b Bound) perimeter() float64 {
+	return 2 * ((b.Max.X - b.Min.X) + (b.Max.Y - b.Min.Y))
+}
+
+// area returns the area of this bound.
+func (b Bound) area() float64 {
+	return (b.Max.X - b.Min.X) * (b.Max.Y - b.Min.Y)
+}
+
 func (b Bound) String() string {
 	return fmt.Sprintf("Min: %v, Max: %v, Center: %v\n", b.Min, b.Max, b.Center)
 }
@@ -0,0 +1,71 @@
+// Copyright 2019 Tskken. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package quadgo
+
+import (
+	"fmt"
+	"math"
+)
+
+// Bound3 is the basic box bounds for nodes and entities in OctGo.
+type Bound3 struct {
+	Min, Max, Center Point3
+}
+
+// NewBound3 creates a new Bound3 struct from the given min and max points.
+func NewBound3(minX, minY, minZ, maxX, maxY, maxZ float64) Bound3 {
+	return Bound3{
+		Min: Point3{X: minX, Y: minY, Z: minZ},
+		Max: Point3{X: maxX, Y: maxY, Z: maxZ},
+		Center: Point3{
+			X: (minX + maxX) / 2,
+			Y: (minY + maxY) / 2,
+			Z: (minZ + maxZ) / 2,
+		},
+	}
+}
+
+// IsEqual checks if the given bound is equal to this bound.
+//
+// Only checks min and max points as center is based off those points
+// and checking it would be redundant.
+func (b Bound3) IsEqual(bound Bound3) bool {
+	return b.Min.IsEqual(bound.Min) && b.Max.IsEqual(bound.Max)
+}
+
+// IsIntersect returns whether or not the given Bound3 intersects with this bound.
+func (b Bound3) IsIntersect(bounds Bound3) bool {
+	return !(bounds.Max.X < b.Min.X || bounds.Min.X > b.Max.X ||
+		bounds.Max.Y < b.Min.Y || bounds.Min.Y > b.Max.Y ||
+		bounds.Max.Z < b.Min.Z || bounds.Min.Z > b.Max.Z)
+}
+
+// octants returns the eight eighths of this bound split at its center.
+//
+// The octants are ordered the same as quadrants(), top left, top right, bottom left and then
+// bottom right, first for the near half of the bound at its min z and then the far half.
+func (b Bound3) octants() [8]Bound3 {
+	var octants [8]Bound3
+	for i, z := range [2][2]float64{{b.Min.Z, b.Center.Z}, {b.Center.Z, b.Max.Z}} {
+		octants[i*4+0] = NewBound3(b.Min.X, b.Min.Y, z[0], b.Center.X, b.Center.Y, z[1]) // Top Left
+		octants[i*4+1] = NewBound3(b.Center.X, b.Min.Y, z[0], b.Max.X, b.Center.Y, z[1]) // Top Right
+		octants[i*4+2] = NewBound3(b.Min.X, b.Center.Y, z[0], b.Center.X, b.Max.Y, z[1]) // Bottom Left
+		octants[i*4+3] = NewBound3(b.Center.X, b.Center.Y, z[0], b.Max.X, b.Max.Y, z[1]) // Bottom Right
+	}
+	return octants
+}
+
+// clamp returns the given point moved to be with in this bound.
+func (b Bound3) clamp(p Point3) Point3 {
+	return Point3{
+		X: math.Min(math.Max(p.X, b.Min.X), b.Max.X),
+		Y: math.Min(math.Max(p.Y, b.Min.Y), b.Max.Y),
+		Z: math.Min(math.Max(p.Z, b.Min.Z), b.Max.Z),
+	}
+}
+
+func (b Bound3) String() string {
+	return fmt.Sprintf("Min: %v, Max: %v, Center: %v\n", b.Min, b.Max, b.Center)
+}
@@ -0,0 +1,71 @@
+// Copyright 2019 Tskken. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package quadgo
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNewBound3(t *testing.T) {
+	got := NewBound3(0, 10, 20, 10, 30, 60)
+	want := Bound3{
+		Min:    Point3{0, 10, 20},
+		Max:    Point3{10, 30, 60},
+		Center: Point3{5, 20, 40},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("quadgo.NewBound3() = %v, want %v", got, want)
+	}
+}
+
+func TestBound3_IsIntersect(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b Bound3
+		want bool
+	}{
+		{name: "overlap", a: NewBound3(0, 0, 0, 10, 10, 10), b: NewBound3(5, 5, 5, 15, 15, 15), want: true},
+		{name: "inside", a: NewBound3(0, 0, 0, 10, 10, 10), b: NewBound3(2, 2, 2, 3, 3, 3), want: true},
+		{name: "touching", a: NewBound3(0, 0, 0, 10, 10, 10), b: NewBound3(10, 0, 0, 20, 10, 10), want: true},
+		{name: "apart on x", a: NewBound3(0, 0, 0, 10, 10, 10), b: NewBound3(11, 0, 0, 20, 10, 10), want: false},
+		{name: "apart on y", a: NewBound3(0, 0, 0, 10, 10, 10), b: NewBound3(0, 11, 0, 10, 20, 10), want: false},
+		{name: "apart on z", a: NewBound3(0, 0, 0, 10, 10, 10), b: NewBound3(0, 0, 11, 10, 10, 20), want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.a.IsIntersect(tt.b); got != tt.want {
+				t.Errorf("Bound3.IsIntersect() = %v, want %v", got, tt.want)
+			}
+			if got := tt.b.IsIntersect(tt.a); got != tt.want {
+				t.Errorf("Bound3.IsIntersect() reversed = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBound3_octants(t *testing.T) {
+	got := NewBound3(0, 0, 0, 8, 8, 8).octants()
+	want := [8]Bound3{
+		NewBound3(0, 0, 0, 4, 4, 4),
+		NewBound3(4, 0, 0, 8, 4, 4),
+		NewBound3(0, 4, 0, 4, 8, 4),
+		NewBound3(4, 4, 0, 8, 8, 4),
+		NewBound3(0, 0, 4, 4, 4, 8),
+		NewBound3(4, 0, 4, 8, 4, 8),
+		NewBound3(0, 4, 4, 4, 8, 8),
+		NewBound3(4, 4, 4, 8, 8, 8),
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Bound3.octants() = %v, want %v", got, want)
+	}
+}
+
+func TestBound3_clamp(t *testing.T) {
+	b := NewBound3(0, 0, 0, 10, 10, 10)
+	if got, want := b.clamp(Point3{-5, 5, 15}), (Point3{0, 5, 10}); !got.IsEqual(want) {
+		t.Errorf("Bound3.clamp() = %v, want %v", got, want)
+	}
+}
@@ -30,6 +30,15 @@ func TestNewBound(t *testing.T) {
 				Center: Point{25, 25},
 			},
 		},
+		{
+			name: "new bounds with negative points",
+			args: args{-180, -90, 20, 10},
+			want: Bound{
+				Min:    Point{-180, -90},
+				Max:    Point{20, 10},
+				Center: Point{-80, -40},
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -215,3 +224,56 @@ func TestBound_IsIntersect(t *testing.T) {
 // 		})
 // 	}
 // }
+
+func TestBound_clamp(t *testing.T) {
+	type fields struct {
+		bound Bound
+	}
+	type args struct {
+		point Point
+	}
+	tests := []struct {
+		name   string
+		fields fields
+		args   args
+		want   Point
+	}{
+		{
+			name: "point with in bound",
+			fields: fields{
+				bound: NewBound(0, 0, 50, 50),
+			},
+			args: args{
+				point: Point{10, 20},
+			},
+			want: Point{10, 20},
+		},
+		{
+			name: "point less then min",
+			fields: fields{
+				bound: NewBound(0, 0, 50, 50),
+			},
+			args: args{
+				point: Point{-10, -20},
+			},
+			want: Point{0, 0},
+		},
+		{
+			name: "point greater then max",
+			fields: fields{
+				bound: NewBound(0, 0, 50, 50),
+			},
+			args: args{
+				point: Point{60, 20},
+			},
+			want: Point{50, 20},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.fields.bound.clamp(tt.args.point); !got.IsEqual(tt.want) {
+				t.Errorf("Bound.clamp() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
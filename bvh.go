@@ -0,0 +1,516 @@
+// Copyright 2019 Tskken. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package quadgo
+
+import (
+	"container/heap"
+	"errors"
+)
+
+// BVH is a dynamic bounding volume hierarchy, the SpatialIndex used by most physics engines
+// for moving entities.
+//
+// A BVH is a balanced binary tree where each leaf holds one entity with a fat bound, the
+// bound of the entity grown by a margin. An entity moved with Move() only has to be moved in
+// the tree once it leaves its fat bound, so entities moving a little each frame are almost
+// never moved in the tree at all. Larger margins mean fewer moves but more entities to test in
+// each query.
+type BVH struct {
+	root   *bvhNode
+	margin float64
+
+	// size is the number of entities inserted in to the tree
+	size int
+}
+
+// bvhNode is a node of a BVH, holding an entity if it is a leaf or two children if it is not.
+type bvhNode struct {
+	// bound is the fat bound of the entity for leaves and covers both children otherwise
+	bound    Bound
+	parent   *bvhNode
+	children [2]*bvhNode
+	entity   *Entity
+
+	// height is the number of nodes from this node to its furthest leaf, which is 0 for leaves
+	height int
+}
+
+// NewBVH creates an empty BVH growing the bound of each entity by the given margin.
+//
+// Example:
+//  // entities move up to around 4 pixels a frame
+//  bvh := quadgo.NewBVH(4)
+func NewBVH(margin float64) *BVH {
+	return &BVH{
+		margin: margin,
+	}
+}
+
+// Insert takes the desired min and max xy points for the inserted entity.
+func (b *BVH) Insert(minX, minY, maxX, maxY float64) {
+	b.insertEntity(NewEntity(minX, minY, maxX, maxY))
+}
+
+// InsertEntities inserts any number of entities in to the tree. Any entities equal to one
+// already in the tree are skipped.
+//
+// Each entity is paired with the node that makes the tree grow the least, and the tree is then
+// rotated to keep it balanced. This will return an error if you do not give it any entities.
+func (b *BVH) InsertEntities(entities ...*Entity) error {
+	if len(entities) == 0 {
+		return errors.New("no entities given to BVH.InsertEntities()")
+	}
+
+	for _, e := range entities {
+		b.insertEntity(e)
+	}
+	return nil
+}
+
+// insertEntity inserts a leaf for the given entity with its fat bound, unless an entity
+// equal to it is already in the tree.
+func (b *BVH) insertEntity(entity *Entity) {
+	if b.find(entity) != nil {
+		return
+	}
+
+	b.insert(&bvhNode{bound: entity.Bound.grow(b.margin), entity: entity})
+	b.size++
+}
+
+// Remove removes the given Entity from the tree.
+//
+// The given entity has to have the same ID and Bounds as the one to remove. This will return
+// an error if the entity given was not found in the tree.
+func (b *BVH) Remove(entity *Entity) error {
+	leaf := b.find(entity)
+	if leaf == nil {
+		return errors.New("could not find entity in tree to remove")
+	}
+
+	b.remove(leaf)
+	b.size--
+	return nil
+}
+
+// Move moves the given entity by d, moving its Shape as well if it has one.
+//
+// The entity is only moved in the tree if it leaves its fat bound. The new fat bound is grown
+// by the margin and stretched by d in the direction the entity moved, expecting it to keep
+// moving the same way. The given entity has to have the same ID and Bounds as the one in the
+// tree, as for Remove(), and this will return an error if it was not found.
+//
+// Example:
+//  // move the player by its velocity each frame
+//  if err := bvh.Move(player, velocity); err != nil {
+//  	panic(err)
+//  }
+func (b *BVH) Move(entity *Entity, d Point) error {
+	leaf := b.find(entity)
+	if leaf == nil {
+		return errors.New("could not find entity in tree to move")
+	}
+
+	entity.translate(d)
+	leaf.entity = entity
+	if leaf.bound.contains(entity.Bound) {
+		return nil
+	}
+
+	fat := entity.Bound.grow(b.margin)
+	if d.X < 0 {
+		fat.Min.X += d.X
+	} else {
+		fat.Max.X += d.X
+	}
+	if d.Y < 0 {
+		fat.Min.Y += d.Y
+	} else {
+		fat.Max.Y += d.Y
+	}
+
+	b.remove(leaf)
+	leaf.bound = NewBound(fat.Min.X, fat.Min.Y, fat.Max.X, fat.Max.Y)
+	b.insert(leaf)
+	return nil
+}
+
+// Clear removes all entities from the tree.
+func (b *BVH) Clear() {
+	b.root = nil
+	b.size = 0
+}
+
+// Len returns the number of entities in the tree.
+func (b *BVH) Len() int {
+	return b.size
+}
+
+// Bounds returns the bound covering the fat bounds of every entity in the tree.
+func (b *BVH) Bounds() Bound {
+	if b.root == nil {
+		return Bound{}
+	}
+	return b.root.bound
+}
+
+// IsIntersect take a bound and returns if that bound intersects any entity within the tree.
+// See QuadGo.IsIntersect().
+func (b *BVH) IsIntersect(bound Bound) <-chan bool {
+	out := make(chan bool)
+
+	go func() {
+		hit := false
+		b.QueryFunc(bound, func(*Entity) bool {
+			hit = true
+			return false
+		})
+		out <- hit
+		close(out)
+	}()
+
+	return out
+}
+
+// Intersects takes a bound and returns all entities that the given bound intersects with.
+// See QuadGo.Intersects().
+func (b *BVH) Intersects(bound Bound) <-chan Entities {
+	out := make(chan Entities)
+
+	go func() {
+		var entities Entities
+		b.QueryFunc(bound, func(e *Entity) bool {
+			entities = append(entities, e)
+			return true
+		})
+		out <- entities
+		close(out)
+	}()
+
+	return out
+}
+
+// QueryFunc calls the given function for every entity that the given bound intersects with.
+// As each entity is held by one leaf, every entity is only given once.
+//
+// Returning false from the given function stops the query. See QuadGo.QueryFunc().
+func (b *BVH) QueryFunc(bound Bound, fn func(*Entity) bool) {
+	if b.root == nil {
+		return
+	}
+
+	stack := []*bvhNode{b.root}
+	for len(stack) > 0 {
+		n := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if !n.bound.IsIntersect(bound) {
+			continue
+		}
+
+		if n.entity == nil {
+			stack = append(stack, n.children[0], n.children[1])
+			continue
+		}
+		if n.entity.IsIntersect(bound) && n.entity.narrow(bound, nil) && !fn(n.entity) {
+			return
+		}
+	}
+}
+
+// RayCast returns the first entity hit by the line from the given point to the given point,
+// along with the point it was hit at. The bool is false if no entity was hit.
+//
+// Entities are hit at their bound, even if they have a Shape. An entity holding the from point
+// is hit at the from point.
+//
+// Example:
+//  // find what the player is looking at
+//  if e, at, ok := bvh.RayCast(player.Center, target); ok {
+//  	fmt.Println("player can see", e, "at", at)
+//  }
+func (b *BVH) RayCast(from, to Point) (*Entity, Point, bool) {
+	if b.root == nil {
+		return nil, Point{}, false
+	}
+
+	d := sub(to, from)
+
+	// best is how far along the line the closest hit so far is, from 0 to 1
+	var hit *Entity
+	best := 1.0
+
+	stack := []*bvhNode{b.root}
+	for len(stack) > 0 {
+		n := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if _, ok := n.bound.ray(from, d, best); !ok {
+			continue
+		}
+
+		if n.entity == nil {
+			stack = append(stack, n.children[0], n.children[1])
+			continue
+		}
+		if t, ok := n.entity.Bound.ray(from, d, best); ok && (hit == nil || t < best) {
+			hit, best = n.entity, t
+		}
+	}
+
+	if hit == nil {
+		return nil, Point{}, false
+	}
+	return hit, add(from, scale(d, best)), true
+}
+
+// Nearest returns up to k entities in the tree closest to the given point, closest first.
+// See QuadGo.Nearest().
+func (b *BVH) Nearest(point Point, k int) Entities {
+	if k <= 0 || b.root == nil {
+		return nil
+	}
+
+	found := make(Entities, 0, k)
+	queue := nearestQueue{{dist: b.root.bound.distance(point), node: b.root}}
+	for len(queue) > 0 && len(found) < k {
+		item := heap.Pop(&queue).(nearestItem)
+		if item.entity != nil {
+			found = append(found, item.entity)
+			continue
+		}
+
+		// the fat bound of a leaf is closer then its entity so the leaf is queued as a node first
+		n := item.node.(*bvhNode)
+		if n.entity != nil {
+			heap.Push(&queue, nearestItem{dist: n.entity.Bound.distance(point), entity: n.entity})
+			continue
+		}
+		for _, child := range n.children {
+			heap.Push(&queue, nearestItem{dist: child.bound.distance(point), node: child})
+		}
+	}
+
+	return found
+}
+
+// ForEach calls the given function for every entity with in the tree, stopping if the
+// function returns false. See QuadGo.ForEach().
+func (b *BVH) ForEach(fn func(*Entity) bool) {
+	if b.root == nil {
+		return
+	}
+
+	stack := []*bvhNode{b.root}
+	for len(stack) > 0 {
+		n := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		if n.entity == nil {
+			stack = append(stack, n.children[0], n.children[1])
+			continue
+		}
+		if !fn(n.entity) {
+			return
+		}
+	}
+}
+
+// find returns the leaf holding the given entity, or nil if it is not in the tree.
+func (b *BVH) find(entity *Entity) *bvhNode {
+	if b.root == nil {
+		return nil
+	}
+
+	// the fat bound of the leaf holds the bound of the entity, as does every node above it
+	stack := []*bvhNode{b.root}
+	for len(stack) > 0 {
+		n := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if !n.bound.contains(entity.Bound) {
+			continue
+		}
+
+		if n.entity == nil {
+			stack = append(stack, n.children[0], n.children[1])
+			continue
+		}
+		if n.entity.IsEqual(entity) {
+			return n
+		}
+	}
+	return nil
+}
+
+// insert adds the leaf to the tree as the sibling of the node which gives the smallest total
+// perimeter of nodes, the surface area heuristic used by Box2D.
+func (b *BVH) insert(leaf *bvhNode) {
+	if b.root == nil {
+		leaf.parent = nil
+		b.root = leaf
+		return
+	}
+
+	sibling := b.root
+	for sibling.entity == nil {
+		combined := sibling.bound.union(leaf.bound).perimeter()
+
+		// cost of pairing the leaf with this node, and the growth every node below it pays
+		cost := 2 * combined
+		inherited := 2 * (combined - sibling.bound.perimeter())
+
+		costs := [2]float64{}
+		for i, child := range sibling.children {
+			costs[i] = child.bound.union(leaf.bound).perimeter() + inherited
+			if child.entity == nil {
+				costs[i] -= child.bound.perimeter()
+			}
+		}
+
+		if cost < costs[0] && cost < costs[1] {
+			break
+		}
+		if costs[0] < costs[1] {
+			sibling = sibling.children[0]
+		} else {
+			sibling = sibling.children[1]
+		}
+	}
+
+	parent := &bvhNode{
+		bound:    sibling.bound.union(leaf.bound),
+		parent:   sibling.parent,
+		children: [2]*bvhNode{sibling, leaf},
+		height:   sibling.height + 1,
+	}
+	b.replace(sibling, parent)
+	sibling.parent, leaf.parent = parent, parent
+
+	b.refit(parent)
+}
+
+// remove takes the leaf out of the tree, replacing its parent by its sibling.
+func (b *BVH) remove(leaf *bvhNode) {
+	if leaf == b.root {
+		b.root = nil
+		return
+	}
+
+	parent := leaf.parent
+	sibling := parent.children[0]
+	if sibling == leaf {
+		sibling = parent.children[1]
+	}
+
+	b.replace(parent, sibling)
+	sibling.parent = parent.parent
+	leaf.parent = nil
+
+	b.refit(sibling.parent)
+}
+
+// replace puts the given node where old was in its parent, or at the root.
+func (b *BVH) replace(old, n *bvhNode) {
+	switch {
+	case old.parent == nil:
+		b.root = n
+	case old.parent.children[0] == old:
+		old.parent.children[0] = n
+	default:
+		old.parent.children[1] = n
+	}
+}
+
+// refit balances and updates the bound and height of the given node and every node above it.
+func (b *BVH) refit(n *bvhNode) {
+	for ; n != nil; n = n.parent {
+		n = b.balance(n)
+		n.update()
+	}
+}
+
+// balance rotates the child of the node with the larger height above it if the heights of its
+// children differ by more then one, returning the node now in its place.
+func (b *BVH) balance(a *bvhNode) *bvhNode {
+	if a.entity != nil {
+		return a
+	}
+
+	diff := a.children[1].height - a.children[0].height
+	switch {
+	case diff > 1:
+		return b.rotate(a, 1)
+	case diff < -1:
+		return b.rotate(a, 0)
+	}
+	return a
+}
+
+// rotate moves the i'th child of a up in to its place, giving a the shorter child of the
+// moved node.
+func (b *BVH) rotate(a *bvhNode, i int) *bvhNode {
+	c := a.children[i]
+	f, g := c.children[0], c.children[1]
+
+	// c takes the place of a, with a as its child
+	c.parent = a.parent
+	b.replace(a, c)
+	a.parent = c
+
+	// c keeps its taller child and gives a the other
+	if f.height < g.height {
+		f, g = g, f
+	}
+	c.children = [2]*bvhNode{a, f}
+	a.children[i] = g
+	g.parent = a
+
+	a.update()
+	c.update()
+	return c
+}
+
+// update sets the bound and height of a node from its children.
+func (n *bvhNode) update() {
+	if n.entity != nil {
+		return
+	}
+
+	n.bound = n.children[0].bound.union(n.children[1].bound)
+	n.height = 1 + maxInt(n.children[0].height, n.children[1].height)
+}
+
+// ray returns how far along the line from the given point by d the line enters this bound,
+// from 0 to maxT, and if it does before maxT at all.
+func (b Bound) ray(from, d Point, maxT float64) (float64, bool) {
+	tMin, tMax := 0.0, maxT
+
+	axes := [2][4]float64{
+		{from.X, d.X, b.Min.X, b.Max.X},
+		{from.Y, d.Y, b.Min.Y, b.Max.Y},
+	}
+	for _, axis := range axes {
+		o, dir, lo, hi := axis[0], axis[1], axis[2], axis[3]
+		if dir == 0 {
+			if o < lo || o > hi {
+				return 0, false
+			}
+			continue
+		}
+
+		t1, t2 := (lo-o)/dir, (hi-o)/dir
+		if t1 > t2 {
+			t1, t2 = t2, t1
+		}
+		if t1 > tMin {
+			tMin = t1
+		}
+		if t2 < tMax {
+			tMax = t2
+		}
+		if tMin > tMax {
+			return 0, false
+		}
+	}
+	return tMin, true
+}
@@ -0,0 +1,274 @@
+// Copyright 2019 Tskken. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package quadgo
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+// checkBVH checks that every node of the tree links to its parent, covers both children
+// and is balanced, and that every leaf holds the bound of its entity, returning the number
+// of entities in the tree.
+func checkBVH(t *testing.T, b *BVH) int {
+	t.Helper()
+
+	if b.root == nil {
+		if b.Len() != 0 {
+			t.Errorf("BVH has no root, want Len() %v entities", b.Len())
+		}
+		return 0
+	}
+	if b.root.parent != nil {
+		t.Errorf("BVH root has a parent")
+	}
+
+	// check returns the number of entities under the node
+	var check func(n *bvhNode) int
+	check = func(n *bvhNode) int {
+		if n.entity != nil {
+			if !n.bound.contains(n.entity.Bound) {
+				t.Errorf("bvhNode leaf bound %v does not hold entity %v", n.bound, n.entity.Bound)
+			}
+			if n.height != 0 {
+				t.Errorf("bvhNode leaf has height %v, want 0", n.height)
+			}
+			return 1
+		}
+
+		count := 0
+		for _, child := range n.children {
+			if child.parent != n {
+				t.Errorf("bvhNode child does not link to its parent")
+			}
+			count += check(child)
+		}
+
+		left, right := n.children[0], n.children[1]
+		if want := left.bound.union(right.bound); !n.bound.IsEqual(want) {
+			t.Errorf("bvhNode has bound %v, want %v", n.bound, want)
+		}
+		if want := 1 + maxInt(left.height, right.height); n.height != want {
+			t.Errorf("bvhNode has height %v, want %v", n.height, want)
+		}
+		if absInt(left.height-right.height) > 1 {
+			t.Errorf("bvhNode children have heights %v and %v, want them balanced", left.height, right.height)
+		}
+		return count
+	}
+
+	count := check(b.root)
+	if count != b.Len() {
+		t.Errorf("BVH holds %v entities, want Len() %v", count, b.Len())
+	}
+	return count
+}
+
+func TestBVH_InsertEntities(t *testing.T) {
+	b := NewBVH(2)
+	entities := randomEntities(500, 1000, 1000)
+
+	for i, e := range entities {
+		b.InsertEntities(e)
+		if i%50 == 0 {
+			checkBVH(t, b)
+		}
+	}
+	checkBVH(t, b)
+
+	// a balanced tree of 500 leaves is at most around 1.44 log2(500) high
+	if b.root.height > 13 {
+		t.Errorf("BVH height = %v, want at most 13", b.root.height)
+	}
+
+	b.Insert(10, 10, 20, 20)
+	if b.Len() != len(entities)+1 {
+		t.Errorf("BVH.Len() = %v, want %v", b.Len(), len(entities)+1)
+	}
+}
+
+func TestBVH_Remove(t *testing.T) {
+	b := NewBVH(2)
+	entities := randomEntities(300, 1000, 1000)
+	b.InsertEntities(entities...)
+
+	// remove in a random order checking the tree stays valid
+	order := rand.New(rand.NewSource(4)).Perm(len(entities))
+	for i, j := range order {
+		if err := b.Remove(entities[j]); err != nil {
+			t.Fatalf("BVH.Remove() got error %v", err)
+		}
+		if i%25 == 0 {
+			checkBVH(t, b)
+		}
+	}
+
+	if b.Len() != 0 || b.root != nil {
+		t.Errorf("BVH after removing every entity has %v entities", b.Len())
+	}
+	if err := b.Remove(entities[0]); err == nil {
+		t.Errorf("BVH.Remove() on empty tree got no error")
+	}
+}
+
+func TestBVH_Move(t *testing.T) {
+	b := NewBVH(5)
+	player := &Entity{ID: 1000, Bound: NewBound(100, 100, 110, 110)}
+	ball := NewEntityWithShape(Circle{Center: Point{300, 300}, Radius: 10})
+	b.InsertEntities(player, ball)
+	b.InsertEntities(randomEntities(100, 1000, 1000)...)
+
+	leaf := b.find(player)
+
+	tests := []struct {
+		name      string
+		d         Point
+		wantBound Bound
+		wantMoved bool
+	}{
+		{name: "with in margin", d: Point{3, -2}, wantBound: NewBound(103, 98, 113, 108), wantMoved: false},
+		{name: "out of margin", d: Point{20, 0}, wantBound: NewBound(123, 98, 133, 108), wantMoved: true},
+		{name: "on with in stretched margin", d: Point{10, 0}, wantBound: NewBound(133, 98, 143, 108), wantMoved: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fat := leaf.bound
+			if err := b.Move(player, tt.d); err != nil {
+				t.Fatalf("BVH.Move() got error %v", err)
+			}
+			if !player.Bound.IsEqual(tt.wantBound) {
+				t.Errorf("BVH.Move() bound = %v, want %v", player.Bound, tt.wantBound)
+			}
+			if moved := !leaf.bound.IsEqual(fat); moved != tt.wantMoved {
+				t.Errorf("BVH.Move() moved leaf = %v, want %v", moved, tt.wantMoved)
+			}
+			if got := <-b.Intersects(player.Bound); !containsRef(got, player) {
+				t.Errorf("BVH.Intersects() at moved bound = %v, want the player", ids(got))
+			}
+			checkBVH(t, b)
+		})
+	}
+
+	// the fat bound after moving out of the margin is stretched the way the player moved
+	if want := NewBound(118, 93, 158, 113); !leaf.bound.IsEqual(want) {
+		t.Errorf("BVH.Move() fat bound = %v, want %v", leaf.bound, want)
+	}
+
+	if err := b.Move(ball, Point{-200, -200}); err != nil {
+		t.Fatalf("BVH.Move() got error %v", err)
+	}
+	if want := (Circle{Center: Point{100, 100}, Radius: 10}); !reflect.DeepEqual(ball.Shape, want) {
+		t.Errorf("BVH.Move() shape = %v, want %v", ball.Shape, want)
+	}
+	checkBVH(t, b)
+
+	if err := b.Move(&Entity{ID: 1000, Bound: NewBound(0, 0, 1, 1)}, Point{1, 1}); err == nil {
+		t.Errorf("BVH.Move() of a missing entity got no error")
+	}
+}
+
+func TestBVH_RayCast(t *testing.T) {
+	b := NewBVH(2)
+	b.InsertEntities(
+		&Entity{ID: 1, Bound: NewBound(100, 0, 110, 100)},
+		&Entity{ID: 2, Bound: NewBound(200, 0, 210, 100)},
+		&Entity{ID: 3, Bound: NewBound(0, 200, 100, 210)},
+		&Entity{ID: 4, Bound: NewBound(40, 40, 60, 60)},
+	)
+
+	tests := []struct {
+		name   string
+		from   Point
+		to     Point
+		wantID uint64
+		wantAt Point
+		wantOk bool
+	}{
+		{name: "closest wall", from: Point{0, 10}, to: Point{300, 10}, wantID: 1, wantAt: Point{100, 10}, wantOk: true},
+		{name: "from the other side", from: Point{300, 10}, to: Point{0, 10}, wantID: 2, wantAt: Point{210, 10}, wantOk: true},
+		{name: "down", from: Point{20, 0}, to: Point{20, 300}, wantID: 3, wantAt: Point{20, 200}, wantOk: true},
+		{name: "diagonal", from: Point{0, 0}, to: Point{100, 100}, wantID: 4, wantAt: Point{40, 40}, wantOk: true},
+		{name: "from inside", from: Point{50, 50}, to: Point{300, 50}, wantID: 4, wantAt: Point{50, 50}, wantOk: true},
+		{name: "short of the wall", from: Point{0, 10}, to: Point{90, 10}, wantOk: false},
+		{name: "miss", from: Point{0, 150}, to: Point{300, 150}, wantOk: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, at, ok := b.RayCast(tt.from, tt.to)
+			if ok != tt.wantOk {
+				t.Fatalf("BVH.RayCast() ok = %v, want %v", ok, tt.wantOk)
+			}
+			if !ok {
+				return
+			}
+			if e.ID != tt.wantID || !at.IsEqual(tt.wantAt) {
+				t.Errorf("BVH.RayCast() = %v at %v, want %v at %v", e.ID, at, tt.wantID, tt.wantAt)
+			}
+		})
+	}
+
+	if _, _, ok := NewBVH(2).RayCast(Point{}, Point{100, 100}); ok {
+		t.Errorf("BVH.RayCast() on empty tree hit")
+	}
+}
+
+func TestBVH_RayCast_random(t *testing.T) {
+	b := NewBVH(2)
+	entities := randomEntities(300, 1000, 1000)
+	b.InsertEntities(entities...)
+
+	r := rand.New(rand.NewSource(6))
+	for i := 0; i < 100; i++ {
+		from := Point{r.Float64() * 1000, r.Float64() * 1000}
+		to := Point{r.Float64() * 1000, r.Float64() * 1000}
+
+		// the closest hit of every entity, tested one by one
+		want, best := (*Entity)(nil), 1.0
+		for _, e := range entities {
+			if hit, ok := e.Bound.ray(from, sub(to, from), 1); ok && (want == nil || hit < best) {
+				want, best = e, hit
+			}
+		}
+
+		// entities hit at the same point can be given in any order
+		got, at, ok := b.RayCast(from, to)
+		if ok != (want != nil) {
+			t.Fatalf("BVH.RayCast(%v, %v) ok = %v, want %v", from, to, ok, want != nil)
+		}
+		if wantAt := add(from, scale(sub(to, from), best)); ok && !at.IsEqual(wantAt) {
+			t.Errorf("BVH.RayCast(%v, %v) = %v at %v, want %v at %v", from, to, got.ID, at, want.ID, wantAt)
+		}
+	}
+}
+
+func TestBVH_reads(t *testing.T) {
+	b := NewBVH(2)
+	entities := randomEntities(50, 1000, 1000)
+	b.InsertEntities(entities...)
+
+	count := 0
+	b.ForEach(func(*Entity) bool {
+		count++
+		return true
+	})
+	if count != len(entities) {
+		t.Errorf("BVH.ForEach() gave %v entities, want %v", count, len(entities))
+	}
+
+	if !<-b.IsIntersect(entities[0].Bound) {
+		t.Errorf("BVH.IsIntersect() = false, want true")
+	}
+	for _, e := range entities {
+		if !b.Bounds().contains(e.Bound) {
+			t.Errorf("BVH.Bounds() = %v, does not hold entity %v", b.Bounds(), e.Bound)
+		}
+	}
+
+	b.Clear()
+	if b.Len() != 0 || <-b.IsIntersect(NewBound(0, 0, 1000, 1000)) || !b.Bounds().IsEqual(Bound{}) {
+		t.Errorf("BVH.Clear() left entities in the tree")
+	}
+}
@@ -0,0 +1,232 @@
+// Copyright 2019 Tskken. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package quadgo
+
+import "math"
+
+// Contact describes how far two overlapping bounds or shapes overlap and which way to move
+// the first of them to separate them.
+type Contact struct {
+	// Normal is the unit direction to move the first bound or shape to separate it from the second.
+	Normal Point
+	// Depth is how far the first bound or shape has to move along Normal to only touch the second.
+	Depth float64
+}
+
+// MTV returns the minimum translation vector of the contact, the smallest move of the first
+// bound or shape which separates it from the second.
+func (c Contact) MTV() Point {
+	return scale(c.Normal, c.Depth)
+}
+
+// Penetration returns the contact for moving this bound out of the given bound.
+//
+// Penetration returns false if the bounds do not overlap or only touch, as there is then
+// nothing to move.
+func (b Bound) Penetration(bound Bound) (Contact, bool) {
+	x := math.Min(b.Max.X, bound.Max.X) - math.Max(b.Min.X, bound.Min.X)
+	y := math.Min(b.Max.Y, bound.Max.Y) - math.Max(b.Min.Y, bound.Min.Y)
+	if x <= shapeEpsilon || y <= shapeEpsilon {
+		return Contact{}, false
+	}
+
+	// push out along the axis that overlaps the least, away from the center of the given bound
+	if x < y {
+		if b.Center.X < bound.Center.X {
+			return Contact{Normal: Point{X: -1}, Depth: x}, true
+		}
+		return Contact{Normal: Point{X: 1}, Depth: x}, true
+	}
+	if b.Center.Y < bound.Center.Y {
+		return Contact{Normal: Point{Y: -1}, Depth: y}, true
+	}
+	return Contact{Normal: Point{Y: 1}, Depth: y}, true
+}
+
+// Collide returns the contact for moving shape a out of shape b.
+//
+// Collide returns false if the shapes do not overlap or only touch, as there is then
+// nothing to move.
+func Collide(a, b Shape) (Contact, bool) {
+	return a.hull().contact(b.hull())
+}
+
+// Contact returns the contact for moving this entity out of the given entity.
+//
+// The Shape of each entity is used if it has one, otherwise its Bound is used. Contact
+// returns false if the entities do not overlap or only touch.
+func (e *Entity) Contact(entity *Entity) (Contact, bool) {
+	if e.Shape == nil && entity.Shape == nil {
+		return e.Bound.Penetration(entity.Bound)
+	}
+	if !e.Bound.IsIntersect(entity.Bound) {
+		return Contact{}, false
+	}
+	return e.hull().contact(entity.hull())
+}
+
+// translate moves the bound and shape of the entity by the given offset.
+func (e *Entity) translate(d Point) {
+	e.Bound = NewBound(e.Min.X+d.X, e.Min.Y+d.Y, e.Max.X+d.X, e.Max.Y+d.Y)
+	if e.Shape != nil {
+		e.Shape = e.Shape.translate(d)
+	}
+}
+
+// Resolve moves the given entity by the given move and then out of the entities of the tree
+// it overlaps, returning how far it was pushed out of them and if it was left overlapping no
+// other entity.
+//
+// Each iteration finds every entity the given entity overlaps and pushes it out of each of
+// them in turn by their Contact. Pushing out of one entity can push it in to another, so
+// Resolve repeats up to the given number of iterations until nothing is overlapped. The
+// Shape of each entity is used if it has one.
+//
+// The given entity does not have to be in the tree. If it is, it is moved with in the tree
+// so it stays where it was resolved to. The entity has to still have the bound it was
+// inserted with, as Resolve finds it in the tree by it, so move it with the given move
+// instead of changing its bound before calling Resolve. An entity in the tree is not moved
+// fully outside of the tree, as it would then be in no node, so Resolve leaves it where it
+// was and returns no push and false instead.
+//
+// Example:
+//  // move the player and then push it out of the walls
+//  if pushed, _ := tree.Resolve(player, velocity, 4); pushed.X != 0 {
+//  	// the player hit a wall to its side
+//  }
+func (q *QuadGo) Resolve(entity *Entity, move Point, iterations int) (Point, bool) {
+	moved := *entity
+	moved.translate(move)
+
+	var total Point
+	resolved := false
+	for i := 0; ; i++ {
+		hits := q.contacts(&moved, entity)
+		if len(hits) == 0 {
+			resolved = true
+			break
+		}
+		if i == iterations {
+			break
+		}
+
+		for _, hit := range hits {
+			if c, ok := moved.Contact(hit); ok {
+				moved.translate(c.MTV())
+				total = add(total, c.MTV())
+			}
+		}
+	}
+
+	if moved.Bound.IsEqual(entity.Bound) {
+		return total, resolved
+	}
+
+	// check the entity stays with in the tree before removing it with its old bound
+	outside := !q.bound.IsIntersect(moved.Bound)
+	if outside && q.isEntity(entity) {
+		return Point{}, false
+	}
+
+	// remove the entity with its old bound before moving it, if it is in the tree
+	inTree := !outside && q.Remove(entity) == nil
+	entity.Bound, entity.Shape = moved.Bound, moved.Shape
+	if inTree {
+		// the moved entity is with in the tree so this can not return an error
+		q.insertEntity(entity)
+		q.check()
+	}
+
+	return total, resolved
+}
+
+// contacts returns the entities of the tree, other then the given skip entity, that the
+// given entity has a contact with.
+func (q *QuadGo) contacts(entity, skip *Entity) (entities Entities) {
+	h := entity.hull()
+	q.observedQuery(entity.Bound, &h, func(e *Entity) bool {
+		if e == skip {
+			return true
+		}
+		if _, ok := entity.Contact(e); ok {
+			entities = append(entities, e)
+		}
+		return true
+	})
+	return
+}
+
+// contact returns the contact for moving this hull out of the given hull.
+func (h hull) contact(other hull) (Contact, bool) {
+	if len(h.points) == 0 || len(other.points) == 0 {
+		return Contact{}, false
+	}
+
+	radius := h.radius + other.radius
+
+	// when the polygons are apart only their radii overlap, so the closest points give the normal
+	if !h.contains(other.points[0]) && !other.contains(h.points[0]) {
+		pa, pb, dist := h.closest(other)
+		if dist > shapeEpsilon {
+			if radius-dist <= shapeEpsilon {
+				return Contact{}, false
+			}
+			return Contact{Normal: scale(sub(pa, pb), 1/dist), Depth: radius - dist}, true
+		}
+	}
+
+	// the polygons overlap so find the axis of least overlap from the edges of both
+	c := Contact{Normal: Point{Y: -1}, Depth: math.Inf(1)}
+	for _, axis := range append(h.axes(), other.axes()...) {
+		minA, maxA := h.project(axis)
+		minB, maxB := other.project(axis)
+
+		if d := maxB - minA; d < c.Depth {
+			c = Contact{Normal: axis, Depth: d}
+		}
+		if d := maxA - minB; d < c.Depth {
+			c = Contact{Normal: scale(axis, -1), Depth: d}
+		}
+	}
+	if math.IsInf(c.Depth, 1) {
+		// both hulls are the same single point, so any direction separates them
+		c.Depth = radius
+	}
+
+	if c.Depth <= shapeEpsilon {
+		return Contact{}, false
+	}
+	return c, true
+}
+
+// axes returns the unit normals of the edges of the hulls polygon. A single edge also gives
+// its own direction, which separates lines along the same line.
+func (h hull) axes() []Point {
+	var axes []Point
+	for i := range h.points {
+		a, b := h.edge(i)
+		d := sub(b, a)
+		l := length(d)
+		if l == 0 {
+			continue
+		}
+		axes = append(axes, Point{X: -d.Y / l, Y: d.X / l})
+		if len(h.points) == 2 {
+			axes = append(axes, scale(d, 1/l))
+			break
+		}
+	}
+	return axes
+}
+
+// project returns the range of the hull along the given unit axis.
+func (h hull) project(axis Point) (float64, float64) {
+	min, max := math.Inf(1), math.Inf(-1)
+	for _, p := range h.points {
+		d := dot(p, axis)
+		min, max = math.Min(min, d), math.Max(max, d)
+	}
+	return min - h.radius, max + h.radius
+}
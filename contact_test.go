@@ -0,0 +1,405 @@
+// Copyright 2019 Tskken. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package quadgo
+
+import (
+	"math"
+	"testing"
+)
+
+// contactNear returns if the two contacts are equal apart from floating point error.
+func contactNear(lhs, rhs Contact) bool {
+	return math.Abs(lhs.Normal.X-rhs.Normal.X) < 1e-9 && math.Abs(lhs.Normal.Y-rhs.Normal.Y) < 1e-9 &&
+		math.Abs(lhs.Depth-rhs.Depth) < 1e-9
+}
+
+func TestBound_Penetration(t *testing.T) {
+	tests := []struct {
+		name   string
+		a, b   Bound
+		want   Contact
+		wantOk bool
+	}{
+		{
+			name:   "apart",
+			a:      NewBound(0, 0, 10, 10),
+			b:      NewBound(20, 0, 30, 10),
+			wantOk: false,
+		},
+		{
+			name:   "touching",
+			a:      NewBound(0, 0, 10, 10),
+			b:      NewBound(10, 0, 20, 10),
+			wantOk: false,
+		},
+		{
+			name:   "overlap on left",
+			a:      NewBound(0, 0, 10, 10),
+			b:      NewBound(8, 2, 18, 12),
+			want:   Contact{Normal: Point{-1, 0}, Depth: 2},
+			wantOk: true,
+		},
+		{
+			name:   "overlap on right",
+			a:      NewBound(12, 0, 22, 10),
+			b:      NewBound(0, 1, 14, 11),
+			want:   Contact{Normal: Point{1, 0}, Depth: 2},
+			wantOk: true,
+		},
+		{
+			name:   "overlap on top",
+			a:      NewBound(0, 0, 10, 10),
+			b:      NewBound(-5, 7, 15, 20),
+			want:   Contact{Normal: Point{0, -1}, Depth: 3},
+			wantOk: true,
+		},
+		{
+			name:   "overlap on bottom",
+			a:      NewBound(2, 17, 8, 30),
+			b:      NewBound(0, 0, 10, 20),
+			want:   Contact{Normal: Point{0, 1}, Depth: 3},
+			wantOk: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := tt.a.Penetration(tt.b)
+			if ok != tt.wantOk {
+				t.Fatalf("Bound.Penetration() ok = %v, want %v", ok, tt.wantOk)
+			}
+			if ok && !contactNear(got, tt.want) {
+				t.Errorf("Bound.Penetration() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCollide(t *testing.T) {
+	s2 := math.Sqrt2 / 2
+
+	tests := []struct {
+		name   string
+		a, b   Shape
+		want   Contact
+		wantOk bool
+	}{
+		{
+			name:   "circles apart",
+			a:      Circle{Center: Point{0, 0}, Radius: 5},
+			b:      Circle{Center: Point{20, 0}, Radius: 5},
+			wantOk: false,
+		},
+		{
+			name:   "circles touching",
+			a:      Circle{Center: Point{0, 0}, Radius: 5},
+			b:      Circle{Center: Point{10, 0}, Radius: 5},
+			wantOk: false,
+		},
+		{
+			name:   "circles overlapping",
+			a:      Circle{Center: Point{0, 0}, Radius: 6},
+			b:      Circle{Center: Point{6, 8}, Radius: 6},
+			want:   Contact{Normal: Point{-0.6, -0.8}, Depth: 2},
+			wantOk: true,
+		},
+		{
+			name:   "circle above box",
+			a:      Circle{Center: Point{5, -1}, Radius: 2},
+			b:      Polygon{Points: []Point{{0, 0}, {10, 0}, {10, 10}, {0, 10}}},
+			want:   Contact{Normal: Point{0, -1}, Depth: 1},
+			wantOk: true,
+		},
+		{
+			name:   "circle center in box",
+			a:      Circle{Center: Point{9, 5}, Radius: 2},
+			b:      Polygon{Points: []Point{{0, 0}, {10, 0}, {10, 10}, {0, 10}}},
+			want:   Contact{Normal: Point{1, 0}, Depth: 3},
+			wantOk: true,
+		},
+		{
+			name:   "box corner in rotated box",
+			a:      Polygon{Points: []Point{{0, 0}, {10, 0}, {10, 10}, {0, 10}}},
+			b:      OrientedBox{Center: Point{10 + 5*math.Sqrt2 - 1, 5}, Width: 10, Height: 10, Angle: math.Pi / 4},
+			want:   Contact{Normal: Point{-1, 0}, Depth: 1},
+			wantOk: true,
+		},
+		{
+			name:   "capsule on circle",
+			a:      Capsule{A: Point{0, 0}, B: Point{10, 10}, Radius: 1},
+			b:      Circle{Center: Point{6, 4}, Radius: 1},
+			want:   Contact{Normal: Point{-s2, s2}, Depth: 2 - math.Sqrt2},
+			wantOk: true,
+		},
+		{
+			name:   "capsules along the same line",
+			a:      Capsule{A: Point{0, 0}, B: Point{10, 0}, Radius: 1},
+			b:      Capsule{A: Point{8, 0}, B: Point{20, 0}, Radius: 1},
+			want:   Contact{Normal: Point{0, 1}, Depth: 2},
+			wantOk: true,
+		},
+		{
+			name:   "same circle",
+			a:      Circle{Center: Point{5, 5}, Radius: 2},
+			b:      Circle{Center: Point{5, 5}, Radius: 3},
+			want:   Contact{Normal: Point{0, -1}, Depth: 5},
+			wantOk: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := Collide(tt.a, tt.b)
+			if ok != tt.wantOk {
+				t.Fatalf("quadgo.Collide() = %v, %v, want %v, %v", got, ok, tt.want, tt.wantOk)
+			}
+			if ok && !contactNear(got, tt.want) {
+				t.Errorf("quadgo.Collide() = %v, want %v", got, tt.want)
+			}
+
+			// moving a by the contact should leave the shapes only touching
+			if ok {
+				moved := tt.a.translate(got.MTV())
+				if _, ok := Collide(moved, tt.b); ok {
+					t.Errorf("quadgo.Collide() after moving by MTV() still overlaps")
+				}
+				if !Overlaps(moved, tt.b) {
+					t.Errorf("quadgo.Overlaps() after moving by MTV() = false, want true")
+				}
+			}
+		})
+	}
+}
+
+func TestEntity_Contact(t *testing.T) {
+	box := &Entity{ID: 1, Bound: NewBound(0, 0, 10, 10)}
+	ball := NewEntityWithShape(Circle{Center: Point{12, 12}, Radius: 4})
+
+	// the bounds overlap by 2 on both axes but the circle only reaches the corner of the box
+	if c, ok := box.Contact(ball); !ok || !contactNear(c, Contact{Normal: Point{-math.Sqrt2 / 2, -math.Sqrt2 / 2}, Depth: 4 - 2*math.Sqrt2}) {
+		t.Errorf("Entity.Contact() = %v, %v", c, ok)
+	}
+
+	other := &Entity{ID: 2, Bound: NewBound(8, 0, 18, 10)}
+	if c, ok := box.Contact(other); !ok || !contactNear(c, Contact{Normal: Point{-1, 0}, Depth: 2}) {
+		t.Errorf("Entity.Contact() = %v, %v", c, ok)
+	}
+
+	far := NewEntityWithShape(Circle{Center: Point{30, 30}, Radius: 4})
+	if c, ok := box.Contact(far); ok {
+		t.Errorf("Entity.Contact() = %v, %v, want false", c, ok)
+	}
+}
+
+func TestQuadGo_Resolve(t *testing.T) {
+	newTree := func() *QuadGo {
+		q := New(100, 100, SetMaxEntities(2))
+		q.InsertEntities(
+			&Entity{ID: 1, Bound: NewBound(0, 90, 100, 100)}, // floor
+			&Entity{ID: 2, Bound: NewBound(0, 0, 10, 100)},   // left wall
+			&Entity{ID: 3, Bound: NewBound(90, 0, 100, 100)}, // right wall
+			NewEntityWithShape(Circle{Center: Point{50, 50}, Radius: 10}),
+		)
+		return q
+	}
+
+	tests := []struct {
+		name         string
+		entity       *Entity
+		move         Point
+		iterations   int
+		want         Bound
+		wantMoved    Point
+		wantResolved bool
+	}{
+		{
+			name:         "free",
+			entity:       &Entity{ID: 10, Bound: NewBound(20, 20, 30, 30)},
+			iterations:   4,
+			want:         NewBound(20, 20, 30, 30),
+			wantResolved: true,
+		},
+		{
+			name:         "into floor",
+			entity:       &Entity{ID: 10, Bound: NewBound(20, 82, 30, 92)},
+			iterations:   4,
+			want:         NewBound(20, 80, 30, 90),
+			wantMoved:    Point{0, -2},
+			wantResolved: true,
+		},
+		{
+			name:         "into floor and wall",
+			entity:       &Entity{ID: 10, Bound: NewBound(7, 82, 17, 93)},
+			iterations:   4,
+			want:         NewBound(10, 79, 20, 90),
+			wantMoved:    Point{3, -3},
+			wantResolved: true,
+		},
+		{
+			name:         "into circle",
+			entity:       &Entity{ID: 10, Bound: NewBound(58, 45, 68, 55)},
+			iterations:   4,
+			want:         NewBound(60, 45, 70, 55),
+			wantMoved:    Point{2, 0},
+			wantResolved: true,
+		},
+		{
+			name:         "moved in to floor",
+			entity:       &Entity{ID: 10, Bound: NewBound(20, 70, 30, 80)},
+			move:         Point{0, 12},
+			iterations:   4,
+			want:         NewBound(20, 80, 30, 90),
+			wantMoved:    Point{0, -2},
+			wantResolved: true,
+		},
+		{
+			name:         "moved freely",
+			entity:       &Entity{ID: 10, Bound: NewBound(20, 20, 30, 30)},
+			move:         Point{5, 15},
+			iterations:   4,
+			want:         NewBound(25, 35, 35, 45),
+			wantResolved: true,
+		},
+		{
+			name:         "no iterations",
+			entity:       &Entity{ID: 10, Bound: NewBound(20, 82, 30, 92)},
+			iterations:   0,
+			want:         NewBound(20, 82, 30, 92),
+			wantResolved: false,
+		},
+	}
+	for _, tt := range tests {
+		for _, inTree := range []bool{false, true} {
+			name := tt.name
+			if inTree {
+				name += " in tree"
+			}
+			t.Run(name, func(t *testing.T) {
+				q := newTree()
+				entity := &Entity{ID: tt.entity.ID, Bound: tt.entity.Bound}
+				if inTree {
+					q.InsertEntities(entity)
+				}
+
+				moved, resolved := q.Resolve(entity, tt.move, tt.iterations)
+				if resolved != tt.wantResolved {
+					t.Errorf("QuadGo.Resolve() resolved = %v, want %v", resolved, tt.wantResolved)
+				}
+				if math.Abs(moved.X-tt.wantMoved.X) > 1e-9 || math.Abs(moved.Y-tt.wantMoved.Y) > 1e-9 {
+					t.Errorf("QuadGo.Resolve() moved = %v, want %v", moved, tt.wantMoved)
+				}
+				if !boundNear(entity.Bound, tt.want) {
+					t.Errorf("QuadGo.Resolve() bound = %v, want %v", entity.Bound, tt.want)
+				}
+
+				if got := <-q.IsEntity(entity); got != inTree {
+					t.Errorf("QuadGo.IsEntity() after Resolve() = %v, want %v", got, inTree)
+				}
+				if err := q.Validate(); err != nil {
+					t.Errorf("QuadGo.Validate() after Resolve() = %v", err)
+				}
+			})
+		}
+	}
+}
+
+func TestQuadGo_Resolve_split(t *testing.T) {
+	// a split tree of a floor and crates with the player in it, as used by a game each frame
+	q := New(100, 100, SetMaxEntities(2))
+	q.InsertEntities(&Entity{ID: 1, Bound: NewBound(0, 90, 100, 100)})
+	for i := 0; i < 5; i++ {
+		x := float64(i * 20)
+		q.InsertEntities(&Entity{ID: uint64(i + 2), Bound: NewBound(x, 0, x+10, 10)})
+	}
+	player := &Entity{ID: 100, Bound: NewBound(12, 60, 22, 70)}
+	q.InsertEntities(player)
+
+	for i := 0; i < 5; i++ {
+		q.Resolve(player, Point{7, 8}, 4)
+
+		if err := q.Validate(); err != nil {
+			t.Fatalf("QuadGo.Validate() after Resolve() %v = %v", i, err)
+		}
+		if !<-q.IsEntity(player) {
+			t.Fatalf("QuadGo.IsEntity() after Resolve() %v = false, want true", i)
+		}
+	}
+
+	if want := NewBound(47, 80, 57, 90); !boundNear(player.Bound, want) {
+		t.Errorf("QuadGo.Resolve() bound = %v, want %v", player.Bound, want)
+	}
+	if q.Len() != 7 {
+		t.Errorf("QuadGo.Len() after Resolve() = %v, want 7", q.Len())
+	}
+}
+
+func TestQuadGo_Resolve_outside(t *testing.T) {
+	q := New(100, 100, SetMaxEntities(2))
+	q.InsertEntities(randomEntities(10, 100, 100)...)
+	player := &Entity{ID: 100, Bound: NewBound(85, 40, 95, 50)}
+	q.InsertEntities(player)
+
+	// moving the player off the edge of the tree leaves it where it was
+	pushed, resolved := q.Resolve(player, Point{X: 50}, 4)
+	if resolved || pushed != (Point{}) {
+		t.Errorf("QuadGo.Resolve() = %v, %v, want %v, false", pushed, resolved, Point{})
+	}
+	if want := NewBound(85, 40, 95, 50); !player.Bound.IsEqual(want) {
+		t.Errorf("QuadGo.Resolve() bound = %v, want %v", player.Bound, want)
+	}
+	if !<-q.IsEntity(player) {
+		t.Errorf("QuadGo.IsEntity() after Resolve() = false, want true")
+	}
+	if q.Len() != 11 {
+		t.Errorf("QuadGo.Len() after Resolve() = %v, want 11", q.Len())
+	}
+	if err := q.Validate(); err != nil {
+		t.Errorf("QuadGo.Validate() after Resolve() = %v", err)
+	}
+
+	// an entity not in the tree can be moved anywhere
+	other := &Entity{ID: 101, Bound: NewBound(85, 40, 95, 50)}
+	q.Resolve(other, Point{X: 50}, 4)
+	if want := NewBound(135, 40, 145, 50); !other.Bound.IsEqual(want) {
+		t.Errorf("QuadGo.Resolve() bound of entity not in the tree = %v, want %v", other.Bound, want)
+	}
+	if q.Len() != 11 {
+		t.Errorf("QuadGo.Len() after Resolve() = %v, want 11", q.Len())
+	}
+}
+
+func TestQuadGo_Resolve_stuck(t *testing.T) {
+	q := New(100, 100)
+	q.InsertEntities(
+		&Entity{ID: 1, Bound: NewBound(0, 0, 10, 100)},
+		&Entity{ID: 2, Bound: NewBound(90, 0, 100, 100)},
+	)
+
+	// the entity is wider then the gap between the walls so can not be resolved
+	entity := &Entity{ID: 3, Bound: NewBound(5, 20, 95, 40)}
+	if _, resolved := q.Resolve(entity, Point{}, 3); resolved {
+		t.Errorf("QuadGo.Resolve() resolved = true, want false")
+	}
+	if !<-q.IsIntersect(entity.Bound) {
+		t.Errorf("QuadGo.IsIntersect() after Resolve() = false, want true")
+	}
+}
+
+func TestQuadGo_Resolve_shape(t *testing.T) {
+	q := New(100, 100)
+	q.InsertEntities(&Entity{ID: 1, Bound: NewBound(0, 50, 100, 100)})
+
+	ball := NewEntityWithShape(Circle{Center: Point{30, 46}, Radius: 5})
+	q.InsertEntities(ball)
+
+	moved, resolved := q.Resolve(ball, Point{}, 1)
+	if !resolved || math.Abs(moved.Y+1) > 1e-9 || moved.X != 0 {
+		t.Fatalf("QuadGo.Resolve() = %v, %v, want %v, true", moved, resolved, Point{0, -1})
+	}
+	if c := ball.Shape.(Circle).Center; math.Abs(c.Y-45) > 1e-9 {
+		t.Errorf("QuadGo.Resolve() circle center = %v, want %v", c, Point{30, 45})
+	}
+	if !boundNear(ball.Bound, NewBound(25, 40, 35, 50)) {
+		t.Errorf("QuadGo.Resolve() bound = %v, want %v", ball.Bound, NewBound(25, 40, 35, 50))
+	}
+}
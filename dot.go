@@ -0,0 +1,65 @@
+// Copyright 2019 Tskken. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package quadgo
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// WriteDOT writes the node hierarchy of the tree as a Graphviz DOT graph to the given writer.
+//
+// Each node of the tree is a graph node labeled with its bound, depth and the IDs of the
+// entities it holds, with an edge from each parent to its children in the order top left,
+// top right, bottom left and bottom right. Leaf nodes are drawn as boxes, empty leaf nodes
+// are dashed and branch nodes whose children are all leaves holding no more then the max
+// entities, which should have been collapsed, are drawn in red.
+//
+//  dot -Tsvg tree.dot -o tree.svg
+func (q *QuadGo) WriteDOT(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+
+	fmt.Fprintln(bw, "digraph quadgo {")
+	fmt.Fprintln(bw, `	node [fontname="monospace" fontsize=10];`)
+
+	id := 0
+	var write func(n *node) int
+	write = func(n *node) int {
+		name := id
+		id++
+
+		label := fmt.Sprintf("depth %v\\n(%g, %g) to (%g, %g)", n.depth, n.bound.Min.X, n.bound.Min.Y, n.bound.Max.X, n.bound.Max.Y)
+
+		var attrs string
+		switch {
+		case len(n.children) > 0:
+			if n.collapsible() {
+				attrs = ` color="red" fontcolor="red"`
+			}
+		case len(n.entities) == 0:
+			attrs = ` shape="box" style="dashed"`
+		default:
+			ids := make([]string, len(n.entities))
+			for i, e := range n.entities {
+				ids[i] = strconv.FormatUint(e.ID, 10)
+			}
+			label += fmt.Sprintf("\\n%v entities: %v", len(n.entities), strings.Join(ids, ", "))
+			attrs = ` shape="box"`
+		}
+		fmt.Fprintf(bw, "\tn%v [label=\"%v\"%v];\n", name, label, attrs)
+
+		for i := range n.children {
+			fmt.Fprintf(bw, "\tn%v -> n%v;\n", name, write(n.children[i]))
+		}
+		return name
+	}
+	write(q.node)
+
+	fmt.Fprintln(bw, "}")
+	return bw.Flush()
+}
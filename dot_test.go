@@ -0,0 +1,89 @@
+// Copyright 2019 Tskken. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package quadgo
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestQuadGo_WriteDOT(t *testing.T) {
+	tests := []struct {
+		name string
+		tree func() *QuadGo
+		want []string
+	}{
+		{
+			name: "empty tree",
+			tree: func() *QuadGo {
+				return New(100, 100)
+			},
+			want: []string{
+				`n0 [label="depth 0\n(0, 0) to (100, 100)" shape="box" style="dashed"];`,
+			},
+		},
+		{
+			name: "split tree",
+			tree: func() *QuadGo {
+				return Build(100, 100, Entities{
+					{ID: 1, Bound: NewBound(0, 0, 10, 10)},
+					{ID: 2, Bound: NewBound(60, 0, 70, 10)},
+					{ID: 3, Bound: NewBound(40, 40, 60, 60)},
+				}, SetMaxEntities(2))
+			},
+			want: []string{
+				`n0 [label="depth 0\n(0, 0) to (100, 100)"];`,
+				`n1 [label="depth 1\n(0, 0) to (50, 50)\n2 entities: 1, 3" shape="box"];`,
+				`n0 -> n1;`,
+				`n2 [label="depth 1\n(50, 0) to (100, 50)\n2 entities: 2, 3" shape="box"];`,
+				`n0 -> n2;`,
+				`n3 [label="depth 1\n(0, 50) to (50, 100)\n1 entities: 3" shape="box"];`,
+				`n0 -> n3;`,
+				`n4 [label="depth 1\n(50, 50) to (100, 100)\n1 entities: 3" shape="box"];`,
+				`n0 -> n4;`,
+			},
+		},
+		{
+			name: "branch that should have collapsed",
+			tree: func() *QuadGo {
+				q := New(100, 100, SetMaxEntities(2))
+				q.split(nil)
+				q.children[0].entities = append(q.children[0].entities, &Entity{ID: 1, Bound: NewBound(0, 0, 10, 10)})
+				return q
+			},
+			want: []string{
+				`n0 [label="depth 0\n(0, 0) to (100, 100)" color="red" fontcolor="red"];`,
+				`n1 [label="depth 1\n(0, 0) to (50, 50)\n1 entities: 1" shape="box"];`,
+				`n2 [label="depth 1\n(50, 0) to (100, 50)" shape="box" style="dashed"];`,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := tt.tree().WriteDOT(&buf); err != nil {
+				t.Fatalf("QuadGo.WriteDOT() got error %v", err)
+			}
+
+			got := buf.String()
+			if !strings.HasPrefix(got, "digraph quadgo {\n") || !strings.HasSuffix(got, "}\n") {
+				t.Errorf("QuadGo.WriteDOT() = %v, want a digraph", got)
+			}
+			for _, line := range tt.want {
+				if !strings.Contains(got, "\t"+line+"\n") {
+					t.Errorf("QuadGo.WriteDOT() = %v, want line %v", got, line)
+				}
+			}
+		})
+	}
+}
+
+func TestQuadGo_WriteDOT_error(t *testing.T) {
+	q := Build(100, 100, randomEntities(200, 100, 100))
+	if err := q.WriteDOT(failWriter{}); err == nil {
+		t.Errorf("QuadGo.WriteDOT() wanted an error")
+	}
+}
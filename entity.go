@@ -87,11 +87,18 @@ type Action func()
 // Entity holds the Bound information for an entity in the tree and an Action function as a closer
 // style function type which can store a function to use later. Entity also holds an ID which is
 // by default a random uint64 value that is used to be able to accurately compare
-// entities with IsEntity()
+// entities with IsEntity(). Data can hold any user data for the entity.
+//
+// Shape can hold the precise shape of the entity. The Bound of the entity is still used to
+// place it in the tree and has to cover its Shape, but queries test the Shape of any entity
+// whose Bound they intersect. Shapes are not encoded with the tree.
 type Entity struct {
 	ID uint64
 	Bound
 	Action
+
+	Data  interface{}
+	Shape Shape
 }
 
 // NewEntity creates a new entity from the given min and max points.
@@ -100,7 +107,7 @@ type Entity struct {
 // time with time.Now().UnixNano(). If you want to set an ID you self just change the ID after creation.
 func NewEntity(minX, minY, maxX, maxY float64) *Entity {
 	return &Entity{
-		ID:     rand.New(rand.NewSource(time.Now().UnixNano())).Uint64(),
+		ID:     newID(),
 		Bound:  NewBound(minX, minY, maxX, maxY),
 		Action: nil,
 	}
@@ -115,7 +122,7 @@ func NewEntity(minX, minY, maxX, maxY float64) *Entity {
 //	})
 func NewEntityWithAction(minX, minY, maxX, maxY float64, action Action) *Entity {
 	return &Entity{
-		ID:     rand.New(rand.NewSource(time.Now().UnixNano())).Uint64(),
+		ID:     newID(),
 		Bound:  NewBound(minX, minY, maxX, maxY),
 		Action: action,
 	}
@@ -131,6 +138,11 @@ func (e *Entity) IsEqual(entity *Entity) bool {
 	return (e.ID == entity.ID) && e.Bound.IsEqual(entity.Bound)
 }
 
+// newID returns a random ID for a new entity seeded with time.Now().UnixNano().
+func newID() uint64 {
+	return rand.New(rand.NewSource(time.Now().UnixNano())).Uint64()
+}
+
 func (e *Entity) String() string {
 	return fmt.Sprintf("ID: %v, Bounds: %v Action: %v\n", e.ID, e.Bound, e.Action)
 }
@@ -0,0 +1,82 @@
+// Copyright 2019 Tskken. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package quadgo
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Entities3 is a list of Entity3's.
+type Entities3 []*Entity3
+
+// FindAndRemove finds and removes the given entity from the list of entities.
+// returns the new list of entities and an error if the given entity can not be found in the list of entities.
+func (e Entities3) FindAndRemove(entity *Entity3) (Entities3, error) {
+	for i := range e {
+		if e[i].IsEqual(entity) {
+			return append(e[:i], e[i+1:]...), nil
+		}
+	}
+
+	return nil, errors.New("could not find entity in tree to remove")
+}
+
+// Contains checks if the given entity exists with in the list of entities.
+func (e Entities3) Contains(entity *Entity3) bool {
+	for i := range e {
+		if e[i].IsEqual(entity) {
+			return true
+		}
+	}
+	return false
+}
+
+// Entity3 is the Entity structure type for OctGo.
+//
+// Entity3 is the same as Entity but with a Bound3, holding an ID used to compare entities
+// with IsEntity(), an Action function and any user Data for the entity.
+type Entity3 struct {
+	ID uint64
+	Bound3
+	Action
+
+	Data interface{}
+}
+
+// NewEntity3 creates a new entity from the given min and max points.
+//
+// The ID of the entity is set the same as NewEntity().
+func NewEntity3(minX, minY, minZ, maxX, maxY, maxZ float64) *Entity3 {
+	return &Entity3{
+		ID:     newID(),
+		Bound3: NewBound3(minX, minY, minZ, maxX, maxY, maxZ),
+		Action: nil,
+	}
+}
+
+// NewEntity3WithAction creates a new entity with the given min and max x, y and z positions of
+// its bounds along with an Action function.
+func NewEntity3WithAction(minX, minY, minZ, maxX, maxY, maxZ float64, action Action) *Entity3 {
+	return &Entity3{
+		ID:     newID(),
+		Bound3: NewBound3(minX, minY, minZ, maxX, maxY, maxZ),
+		Action: action,
+	}
+}
+
+// SetAction sets an entities action function.
+func (e *Entity3) SetAction(action Action) {
+	e.Action = action
+}
+
+// IsEqual checks if the ID and bound of the entity is the same.
+func (e *Entity3) IsEqual(entity *Entity3) bool {
+	return (e.ID == entity.ID) && e.Bound3.IsEqual(entity.Bound3)
+}
+
+func (e *Entity3) String() string {
+	return fmt.Sprintf("ID: %v, Bounds: %v Action: %v\n", e.ID, e.Bound3, e.Action)
+}
@@ -0,0 +1,237 @@
+// Copyright 2019 Tskken. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package quadgo
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"strconv"
+)
+
+// Feature is a GeoJSON feature.
+//
+// Entities imported from GeoJSON hold their Feature as their Data, which keeps the
+// features properties and full geometry for exporting back to GeoJSON.
+type Feature struct {
+	ID         interface{}            `json:"id,omitempty"`
+	BBox       []float64              `json:"bbox,omitempty"`
+	Geometry   json.RawMessage        `json:"geometry"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+// geoFeature is the JSON form of a GeoJSON feature.
+type geoFeature struct {
+	Type string `json:"type"`
+	Feature
+}
+
+// geoFeatureCollection is the JSON form of a GeoJSON feature collection.
+type geoFeatureCollection struct {
+	Type     string       `json:"type"`
+	BBox     []float64    `json:"bbox,omitempty"`
+	Features []geoFeature `json:"features"`
+}
+
+// geoGeometry is the JSON form of a GeoJSON geometry.
+type geoGeometry struct {
+	Type        string        `json:"type"`
+	Coordinates interface{}   `json:"coordinates"`
+	Geometries  []geoGeometry `json:"geometries"`
+}
+
+// ImportGeoJSON reads a GeoJSON FeatureCollection and returns a new tree with an entity
+// for each feature.
+//
+// The Bound of each entity is the bbox of its feature, either as given in the GeoJSON or
+// found from the coordinates of the features geometry. The Data of each entity is the
+// features *Feature and the ID of each entity is its index in the collection plus one.
+// Features without a geometry are skipped as they have no location.
+//
+// The bounds of the tree are the bbox of the collection if given, otherwise the bounds
+// covering all of its features. ImportGeoJSON takes the same Option functions as New.
+func ImportGeoJSON(r io.Reader, ops ...Option) (*QuadGo, error) {
+	var collection geoFeatureCollection
+	if err := json.NewDecoder(r).Decode(&collection); err != nil {
+		return nil, err
+	}
+	if collection.Type != "FeatureCollection" {
+		return nil, errors.New("GeoJSON is not a FeatureCollection")
+	}
+
+	world, hasWorld := geoBound(collection.BBox)
+
+	var entities Entities
+	for i := range collection.Features {
+		f := &collection.Features[i]
+		if f.Type != "Feature" {
+			return nil, errors.New("GeoJSON FeatureCollection has a member that is not a Feature")
+		}
+
+		// skip features with no location
+		if len(f.Geometry) == 0 || string(f.Geometry) == "null" {
+			continue
+		}
+
+		bound, ok := geoBound(f.BBox)
+		if !ok {
+			var geometry geoGeometry
+			if err := json.Unmarshal(f.Geometry, &geometry); err != nil {
+				return nil, err
+			}
+
+			bound, ok = geometry.bound()
+			if !ok {
+				continue
+			}
+		}
+
+		// grow the tree bounds to cover the feature
+		if !hasWorld {
+			if len(entities) == 0 {
+				world = bound
+			} else {
+				world = world.union(bound)
+			}
+		}
+
+		feature := f.Feature
+		entities = append(entities, &Entity{
+			ID:    uint64(i + 1),
+			Bound: bound,
+			Data:  &feature,
+		})
+	}
+
+	q := NewWithBound(world, ops...)
+	if err := q.load(entities); err != nil {
+		return nil, err
+	}
+
+	return q, nil
+}
+
+// ExportGeoJSON writes the given entities as a GeoJSON FeatureCollection, such as
+// the results of QuadGo.Intersects().
+//
+// Entities with a *Feature as their Data are written as that feature. All other entities
+// are written as a Polygon of their Bound with their ID as a string, and their Data as the
+// features properties if it is a map[string]interface{}.
+func ExportGeoJSON(w io.Writer, entities Entities) error {
+	collection := geoFeatureCollection{
+		Type:     "FeatureCollection",
+		Features: make([]geoFeature, 0, len(entities)),
+	}
+
+	for _, e := range entities {
+		if f, ok := e.Data.(*Feature); ok {
+			collection.Features = append(collection.Features, geoFeature{
+				Type:    "Feature",
+				Feature: *f,
+			})
+			continue
+		}
+
+		geometry, err := json.Marshal(geoGeometry{
+			Type: "Polygon",
+			Coordinates: [][][2]float64{{
+				{e.Min.X, e.Min.Y},
+				{e.Max.X, e.Min.Y},
+				{e.Max.X, e.Max.Y},
+				{e.Min.X, e.Max.Y},
+				{e.Min.X, e.Min.Y},
+			}},
+		})
+		if err != nil {
+			return err
+		}
+
+		properties, _ := e.Data.(map[string]interface{})
+		collection.Features = append(collection.Features, geoFeature{
+			Type: "Feature",
+			Feature: Feature{
+				ID:         strconv.FormatUint(e.ID, 10),
+				Geometry:   geometry,
+				Properties: properties,
+			},
+		})
+	}
+
+	return json.NewEncoder(w).Encode(collection)
+}
+
+// MarshalJSON encodes the geometry leaving out the members its type does not use.
+func (g geoGeometry) MarshalJSON() ([]byte, error) {
+	if g.Type == "GeometryCollection" {
+		return json.Marshal(struct {
+			Type       string        `json:"type"`
+			Geometries []geoGeometry `json:"geometries"`
+		}{g.Type, g.Geometries})
+	}
+
+	return json.Marshal(struct {
+		Type        string      `json:"type"`
+		Coordinates interface{} `json:"coordinates"`
+	}{g.Type, g.Coordinates})
+}
+
+// bound returns the bounds covering all coordinates of the geometry and
+// false if the geometry has no coordinates.
+func (g geoGeometry) bound() (Bound, bool) {
+	var bound Bound
+	found := false
+	grow := func(b Bound) {
+		if !found {
+			bound, found = b, true
+			return
+		}
+		bound = bound.union(b)
+	}
+
+	var add func(coordinates interface{})
+	add = func(coordinates interface{}) {
+		values, ok := coordinates.([]interface{})
+		if !ok || len(values) == 0 {
+			return
+		}
+
+		// check for a single position of at least x and y
+		if x, ok := values[0].(float64); ok {
+			if len(values) < 2 {
+				return
+			}
+			if y, ok := values[1].(float64); ok {
+				grow(NewBound(x, y, x, y))
+			}
+			return
+		}
+
+		for _, v := range values {
+			add(v)
+		}
+	}
+	add(g.Coordinates)
+
+	for _, geometry := range g.Geometries {
+		if b, ok := geometry.bound(); ok {
+			grow(b)
+		}
+	}
+
+	return bound, found
+}
+
+// geoBound returns the Bound for a GeoJSON bbox and false if the bbox is not given.
+//
+// A bbox holds all of the min values and then all of the max values, so a 3D bbox
+// has its max x and y at index 3 and 4.
+func geoBound(bbox []float64) (Bound, bool) {
+	if len(bbox) < 4 || len(bbox)%2 != 0 {
+		return Bound{}, false
+	}
+
+	dims := len(bbox) / 2
+	return NewBound(bbox[0], bbox[1], bbox[dims], bbox[dims+1]), true
+}
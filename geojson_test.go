@@ -0,0 +1,280 @@
+// Copyright 2019 Tskken. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package quadgo
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// readGeoJSON reads a GeoJSON test file in to generic JSON values.
+func readGeoJSON(t *testing.T, name string) map[string]interface{} {
+	data, err := ioutil.ReadFile(name)
+	if err != nil {
+		t.Fatalf("could not read %v: %v", name, err)
+	}
+
+	var v map[string]interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		t.Fatalf("could not decode %v: %v", name, err)
+	}
+	return v
+}
+
+func TestImportGeoJSON(t *testing.T) {
+	type args struct {
+		file string
+		ops  []Option
+	}
+	tests := []struct {
+		name      string
+		args      args
+		wantLen   int
+		wantBound Bound
+		wantErr   bool
+	}{
+		{
+			name: "features with every geometry type",
+			args: args{
+				file: "testdata/features.geojson",
+			},
+			wantLen:   5,
+			wantBound: NewBound(-122.5, -33.95, 151.3, 48.86),
+		},
+		{
+			name: "collection with bbox",
+			args: args{
+				file: "testdata/grid.geojson",
+				ops: []Option{
+					SetMaxEntities(2),
+				},
+			},
+			wantLen:   13,
+			wantBound: NewBound(-10, -10, 10, 10),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := os.Open(tt.args.file)
+			if err != nil {
+				t.Fatalf("could not open %v: %v", tt.args.file, err)
+			}
+			defer f.Close()
+
+			got, err := ImportGeoJSON(f, tt.args.ops...)
+			if err != nil {
+				t.Fatalf("quadgo.ImportGeoJSON() got error %v", err)
+			}
+
+			if got.Len() != tt.wantLen {
+				t.Errorf("quadgo.ImportGeoJSON() Len() = %v, want %v", got.Len(), tt.wantLen)
+			}
+			if !got.Bounds().IsEqual(tt.wantBound) {
+				t.Errorf("quadgo.ImportGeoJSON() Bounds() = %v, want %v", got.Bounds(), tt.wantBound)
+			}
+			for _, e := range <-got.All() {
+				if _, ok := e.Data.(*Feature); !ok {
+					t.Errorf("quadgo.ImportGeoJSON() entity data = %T, want *Feature", e.Data)
+				}
+			}
+		})
+	}
+}
+
+func TestImportGeoJSON_bounds(t *testing.T) {
+	f, err := os.Open("testdata/features.geojson")
+	if err != nil {
+		t.Fatalf("could not open features.geojson: %v", err)
+	}
+	defer f.Close()
+
+	q, err := ImportGeoJSON(f)
+	if err != nil {
+		t.Fatalf("quadgo.ImportGeoJSON() got error %v", err)
+	}
+
+	tests := []struct {
+		name string
+		want Bound
+	}{
+		{name: "Harbor", want: NewBound(-122.4194, 37.7749, -122.4194, 37.7749)},
+		{name: "Ferry route", want: NewBound(-122.5, 37.7, -122.4, 37.8)},
+		{name: "Lower Manhattan", want: NewBound(-74.02, 40.70, -73.97, 40.75)},
+		{name: "Sydney harbour", want: NewBound(151.1, -33.95, 151.3, -33.8)},
+		{name: "", want: NewBound(2.29, 48.85, 2.3522, 48.86)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			found := false
+			for _, e := range <-q.All() {
+				name, _ := e.Data.(*Feature).Properties["name"].(string)
+				if name == tt.name {
+					found = true
+					if !e.Bound.IsEqual(tt.want) {
+						t.Errorf("quadgo.ImportGeoJSON() bound = %v, want %v", e.Bound, tt.want)
+					}
+				}
+			}
+			if !found {
+				t.Errorf("quadgo.ImportGeoJSON() feature %q not found", tt.name)
+			}
+		})
+	}
+}
+
+func TestImportGeoJSON_errors(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{
+			name: "invalid json",
+			data: `{"type": "FeatureCollection", "features": [`,
+		},
+		{
+			name: "not a feature collection",
+			data: `{"type": "Feature", "geometry": null, "properties": null}`,
+		},
+		{
+			name: "member not a feature",
+			data: `{"type": "FeatureCollection", "features": [{"type": "Point", "coordinates": [0, 0]}]}`,
+		},
+		{
+			name: "feature outside of bbox",
+			data: `{"type": "FeatureCollection", "bbox": [0, 0, 10, 10], "features": [
+				{"type": "Feature", "geometry": {"type": "Point", "coordinates": [5, 5]}, "properties": null},
+				{"type": "Feature", "geometry": {"type": "Point", "coordinates": [50, 50]}, "properties": null}
+			]}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ImportGeoJSON(strings.NewReader(tt.data)); err == nil {
+				t.Errorf("quadgo.ImportGeoJSON() wanted an error")
+			}
+		})
+	}
+}
+
+func TestExportGeoJSON(t *testing.T) {
+	type args struct {
+		file  string
+		bound *Bound
+	}
+	tests := []struct {
+		name      string
+		args      args
+		wantCells []string
+	}{
+		{
+			name: "round trip every feature",
+			args: args{
+				file: "testdata/features.geojson",
+			},
+		},
+		{
+			name: "round trip grid",
+			args: args{
+				file: "testdata/grid.geojson",
+			},
+		},
+		{
+			name: "export query results",
+			args: args{
+				file:  "testdata/grid.geojson",
+				bound: &Bound{Min: Point{-5, -5}, Max: Point{2, 2}},
+			},
+			wantCells: []string{"b2", "b3", "c2", "c3", "center"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := os.Open(tt.args.file)
+			if err != nil {
+				t.Fatalf("could not open %v: %v", tt.args.file, err)
+			}
+			defer f.Close()
+
+			q, err := ImportGeoJSON(f, SetMaxEntities(2))
+			if err != nil {
+				t.Fatalf("quadgo.ImportGeoJSON() got error %v", err)
+			}
+
+			var entities Entities
+			if tt.args.bound != nil {
+				entities = <-q.Intersects(*tt.args.bound)
+			} else {
+				entities = <-q.All()
+			}
+			sort.Slice(entities, func(i, j int) bool {
+				return entities[i].ID < entities[j].ID
+			})
+
+			var buf bytes.Buffer
+			if err := ExportGeoJSON(&buf, entities); err != nil {
+				t.Fatalf("quadgo.ExportGeoJSON() got error %v", err)
+			}
+
+			var got map[string]interface{}
+			if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+				t.Fatalf("quadgo.ExportGeoJSON() wrote invalid json %v", err)
+			}
+
+			if tt.args.bound != nil {
+				var cells []string
+				for _, f := range got["features"].([]interface{}) {
+					properties := f.(map[string]interface{})["properties"].(map[string]interface{})
+					cells = append(cells, properties["cell"].(string))
+				}
+				sort.Strings(cells)
+				if !reflect.DeepEqual(cells, tt.wantCells) {
+					t.Errorf("quadgo.ExportGeoJSON() cells = %v, want %v", cells, tt.wantCells)
+				}
+				return
+			}
+
+			// the export should match the original file without features with no geometry
+			want := readGeoJSON(t, tt.args.file)
+			delete(want, "bbox")
+			var features []interface{}
+			for _, f := range want["features"].([]interface{}) {
+				if f.(map[string]interface{})["geometry"] != nil {
+					features = append(features, f)
+				}
+			}
+			want["features"] = features
+
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("quadgo.ExportGeoJSON() = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestExportGeoJSON_entities(t *testing.T) {
+	entities := Entities{
+		&Entity{ID: 1, Bound: NewBound(0, 0, 10, 20), Data: map[string]interface{}{"name": "wall"}},
+		&Entity{ID: 2, Bound: NewBound(-5, -5, 5, 5), Data: "not properties"},
+	}
+
+	var buf bytes.Buffer
+	if err := ExportGeoJSON(&buf, entities); err != nil {
+		t.Fatalf("quadgo.ExportGeoJSON() got error %v", err)
+	}
+
+	want := `{"type":"FeatureCollection","features":[` +
+		`{"type":"Feature","id":"1","geometry":{"type":"Polygon","coordinates":[[[0,0],[10,0],[10,20],[0,20],[0,0]]]},"properties":{"name":"wall"}},` +
+		`{"type":"Feature","id":"2","geometry":{"type":"Polygon","coordinates":[[[-5,-5],[5,-5],[5,5],[-5,5],[-5,-5]]]},"properties":null}` +
+		`]}` + "\n"
+	if got := buf.String(); got != want {
+		t.Errorf("quadgo.ExportGeoJSON() = %v, want %v", got, want)
+	}
+}
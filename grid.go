@@ -0,0 +1,234 @@
+// Copyright 2019 Tskken. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package quadgo
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// Grid is a SpatialIndex which hashes entities in to square cells of a set size.
+//
+// Each entity is stored in every cell its bound covers, the same as a QuadGo leaf node.
+// Only cells holding entities are kept so a Grid has no bounds and can hold entities
+// anywhere. A Grid works best when the cell size is around the size of the entities in it
+// and the entities are spread evenly, as an entity much larger then a cell is stored in many
+// cells and a cell holding many entities has to test them all.
+type Grid struct {
+	cellSize float64
+	cells    map[gridCell]Entities
+
+	// min and max are the lowest and highest cells that have held entities
+	min, max gridCell
+
+	// size is the number of entities inserted in to the grid
+	size int
+}
+
+// gridCell is the column and row of a cell in a Grid.
+type gridCell struct {
+	x, y int
+}
+
+// NewGrid creates an empty Grid with cells of the given size.
+//
+// NewGrid panics if the cell size is not a finite number greater then 0, as entities can
+// not be hashed in to cells of that size.
+//
+// Example:
+//  // cells around the size of the players and enemies of the game
+//  grid := quadgo.NewGrid(64)
+func NewGrid(cellSize float64) *Grid {
+	if !(cellSize > 0) || math.IsInf(cellSize, 1) {
+		panic(fmt.Errorf("Grid cell size %v is not a finite number greater then 0", cellSize))
+	}
+
+	return &Grid{
+		cellSize: cellSize,
+		cells:    make(map[gridCell]Entities),
+	}
+}
+
+// InsertEntities inserts any number of entities in to each cell their bounds cover. Any
+// entities equal to one already in the grid are skipped.
+//
+// This will return an error if you do not give it any entities.
+func (g *Grid) InsertEntities(entities ...*Entity) error {
+	if len(entities) == 0 {
+		return errors.New("no entities given to Grid.InsertEntities()")
+	}
+
+	for _, e := range entities {
+		// an entity is in every cell it covers, so if it is in its first cell it is already in the grid
+		min, max := g.span(e.Bound)
+		if g.cells[min].Contains(e) {
+			continue
+		}
+
+		if len(g.cells) == 0 {
+			g.min, g.max = min, max
+		}
+		g.min = gridCell{x: minInt(g.min.x, min.x), y: minInt(g.min.y, min.y)}
+		g.max = gridCell{x: maxInt(g.max.x, max.x), y: maxInt(g.max.y, max.y)}
+
+		for x := min.x; x <= max.x; x++ {
+			for y := min.y; y <= max.y; y++ {
+				c := gridCell{x: x, y: y}
+				g.cells[c] = append(g.cells[c], e)
+			}
+		}
+		g.size++
+	}
+	return nil
+}
+
+// Remove removes the given entity from every cell it is in.
+//
+// The given entity has to have the same ID and Bounds as the one to remove. This will return
+// an error if the entity given was not found in the grid.
+func (g *Grid) Remove(entity *Entity) error {
+	min, max := g.span(entity.Bound)
+	if !g.cells[min].Contains(entity) {
+		return errors.New("could not find entity in index to remove")
+	}
+
+	for x := min.x; x <= max.x; x++ {
+		for y := min.y; y <= max.y; y++ {
+			c := gridCell{x: x, y: y}
+			entities, err := g.cells[c].FindAndRemove(entity)
+			if err != nil {
+				continue
+			}
+			if len(entities) == 0 {
+				delete(g.cells, c)
+			} else {
+				g.cells[c] = entities
+			}
+		}
+	}
+	g.size--
+	return nil
+}
+
+// QueryFunc calls the given function for every entity that the given bound intersects with.
+// Entities stored in more then one cell are only given to the function once.
+//
+// Returning false from the given function stops the query. See QuadGo.QueryFunc().
+func (g *Grid) QueryFunc(bound Bound, fn func(*Entity) bool) {
+	if len(g.cells) == 0 {
+		return
+	}
+
+	// only look at the cells that have held entities
+	min, max := g.span(bound)
+	min = gridCell{x: maxInt(min.x, g.min.x), y: maxInt(min.y, g.min.y)}
+	max = gridCell{x: minInt(max.x, g.max.x), y: minInt(max.y, g.max.y)}
+
+	for x := min.x; x <= max.x; x++ {
+		for y := min.y; y <= max.y; y++ {
+			c := gridCell{x: x, y: y}
+			for _, e := range g.cells[c] {
+				if !e.IsIntersect(bound) {
+					continue
+				}
+
+				// an entity is only given from the cell holding the min point of its
+				// intersection with the bound, which is in every cell both of them cover
+				p := Point{X: math.Max(e.Min.X, bound.Min.X), Y: math.Max(e.Min.Y, bound.Min.Y)}
+				if g.cell(p) != c || !e.narrow(bound, nil) {
+					continue
+				}
+				if !fn(e) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// Nearest returns up to k entities in the grid closest to the given point, closest first.
+//
+// Nearest searches the cells in rings around the cell of the point until no closer entity
+// could be found. See QuadGo.Nearest().
+func (g *Grid) Nearest(point Point, k int) Entities {
+	if k <= 0 || g.size == 0 {
+		return nil
+	}
+
+	found := newNearest(k)
+	seen := make(map[*Entity]bool)
+
+	// the furthest ring that could hold a cell that has held entities
+	center := g.cell(point)
+	rings := maxInt(maxInt(absInt(center.x-g.min.x), absInt(center.x-g.max.x)),
+		maxInt(absInt(center.y-g.min.y), absInt(center.y-g.max.y)))
+
+	for r := 0; r <= rings; r++ {
+		for x := center.x - r; x <= center.x+r; x++ {
+			for y := center.y - r; y <= center.y+r; y++ {
+				// only visit the cells on the edge of the ring
+				if x != center.x-r && x != center.x+r && y != center.y-r && y != center.y+r {
+					continue
+				}
+
+				for _, e := range g.cells[gridCell{x: x, y: y}] {
+					if seen[e] {
+						continue
+					}
+					seen[e] = true
+					found.add(e, e.Bound.distance(point))
+				}
+			}
+		}
+
+		// any entity not seen yet is only in cells outside of this ring, so is at least
+		// r cells away from the point
+		if found.worst() <= float64(r)*g.cellSize || len(seen) == g.size {
+			break
+		}
+	}
+
+	return found.entities
+}
+
+// Len returns the number of entities in the grid.
+func (g *Grid) Len() int {
+	return g.size
+}
+
+// cell returns the cell holding the given point.
+func (g *Grid) cell(p Point) gridCell {
+	return gridCell{
+		x: int(math.Floor(p.X / g.cellSize)),
+		y: int(math.Floor(p.Y / g.cellSize)),
+	}
+}
+
+// span returns the lowest and highest cells the given bound covers.
+func (g *Grid) span(b Bound) (gridCell, gridCell) {
+	return g.cell(b.Min), g.cell(b.Max)
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func absInt(a int) int {
+	if a < 0 {
+		return -a
+	}
+	return a
+}
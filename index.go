@@ -0,0 +1,188 @@
+// Copyright 2019 Tskken. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package quadgo
+
+import (
+	"container/heap"
+	"math"
+)
+
+// SpatialIndex is the set of functions shared by every spatial index in QuadGo, so the
+// index used can be swapped without changing the code using it.
+//
+// QuadGo, RTree, BVH, Grid and SweepAndPrune all implement SpatialIndex. Which is fastest
+// depends on the entities held: QuadGo adapts to entities bunched together, RTree is best for
+// large or overlapping entities that do not move, BVH is best for entities that move a little
+// every frame, Grid is best for many entities of around the same size spread evenly and
+// SweepAndPrune is best for few entities spread along x.
+//
+// The functions of a SpatialIndex run on the calling go routine and, like QuadGo, are not
+// safe to call at the same time as InsertEntities() or Remove().
+type SpatialIndex interface {
+	// InsertEntities inserts any number of entities in to the index, skipping any equal to
+	// one already in it, returning an error if no entities are given.
+	InsertEntities(entities ...*Entity) error
+	// Remove removes the given entity from the index, returning an error if it was not found.
+	Remove(entity *Entity) error
+	// QueryFunc calls the given function once for every entity that the given bound intersects
+	// with, stopping if the function returns false.
+	QueryFunc(bound Bound, fn func(*Entity) bool)
+	// Nearest returns up to k entities closest to the given point, closest first.
+	Nearest(point Point, k int) Entities
+	// Len returns the number of entities in the index.
+	Len() int
+}
+
+var (
+	_ SpatialIndex = (*QuadGo)(nil)
+	_ SpatialIndex = (*Grid)(nil)
+	_ SpatialIndex = (*SweepAndPrune)(nil)
+	_ SpatialIndex = (*RTree)(nil)
+	_ SpatialIndex = (*BVH)(nil)
+)
+
+// Nearest returns up to k entities in the tree closest to the given point, closest first.
+//
+// The distance to an entity is the distance from the point to the closest point of the
+// bound of the entity, which is 0 for entities whose bound holds the point. Entities at the
+// same distance are returned in no set order.
+//
+// Nearest searches the nodes closest to the point first, so only the nodes which could hold
+// a closer entity then those already found are visited. Unlike the other read functions
+// Nearest runs on the calling go routine and returns once the search is done.
+func (q *QuadGo) Nearest(point Point, k int) Entities {
+	if k <= 0 {
+		return nil
+	}
+
+	found := make(Entities, 0, k)
+	queue := nearestQueue{{dist: q.bound.distance(point), node: q.node}}
+	for len(queue) > 0 {
+		item := heap.Pop(&queue).(nearestItem)
+
+		// entities are popped in order of distance so the next unseen entity is the next closest
+		if item.entity != nil {
+			if !containsRef(found, item.entity) {
+				found = append(found, item.entity)
+				if len(found) == k {
+					break
+				}
+			}
+			continue
+		}
+
+		n := item.node.(*node)
+		for i := range n.children {
+			heap.Push(&queue, nearestItem{dist: n.children[i].bound.distance(point), node: n.children[i]})
+		}
+		for _, e := range n.entities {
+			heap.Push(&queue, nearestItem{dist: e.Bound.distance(point), entity: e})
+		}
+	}
+
+	return found
+}
+
+// Nearest returns up to k entities in the snapshot closest to the given point, closest first.
+// See QuadGo.Nearest().
+func (s *Snapshot) Nearest(point Point, k int) Entities {
+	return s.tree.Nearest(point, k)
+}
+
+// Nearest returns up to k entities in the tree closest to the given point, closest first.
+// See QuadGo.Nearest().
+func (p *Persistent) Nearest(point Point, k int) Entities {
+	return p.tree().Nearest(point, k)
+}
+
+// nearestItem is a node or an entity waiting to be visited by a Nearest() search, with the
+// distance from the point to its bound.
+type nearestItem struct {
+	dist float64
+	// node is the node of the tree being searched, set if entity is nil
+	node   interface{}
+	entity *Entity
+}
+
+// nearestQueue is a min heap of nearestItem's ordered by distance.
+type nearestQueue []nearestItem
+
+func (q nearestQueue) Len() int { return len(q) }
+
+// Less orders entities before nodes at the same distance, so an entity touching the point
+// is returned without splitting open the nodes around it.
+func (q nearestQueue) Less(i, j int) bool {
+	if q[i].dist == q[j].dist {
+		return q[i].entity != nil && q[j].entity == nil
+	}
+	return q[i].dist < q[j].dist
+}
+
+func (q nearestQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+
+func (q *nearestQueue) Push(x interface{}) { *q = append(*q, x.(nearestItem)) }
+
+func (q *nearestQueue) Pop() interface{} {
+	old := *q
+	item := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return item
+}
+
+// nearest keeps the k closest entities given to it, for the indexes which can not visit
+// entities in order of distance.
+type nearest struct {
+	k        int
+	entities Entities
+	dists    []float64
+}
+
+// newNearest returns an empty nearest keeping up to k entities.
+func newNearest(k int) *nearest {
+	return &nearest{
+		k:        k,
+		entities: make(Entities, 0, k),
+		dists:    make([]float64, 0, k),
+	}
+}
+
+// add adds the entity at the given distance if it is closer then the k'th closest so far.
+func (n *nearest) add(e *Entity, dist float64) {
+	if dist >= n.worst() {
+		return
+	}
+
+	// drop the furthest entity to make room
+	if len(n.entities) == n.k {
+		n.entities, n.dists = n.entities[:n.k-1], n.dists[:n.k-1]
+	}
+
+	// insert keeping the entities in order of distance
+	i := len(n.dists)
+	n.entities, n.dists = append(n.entities, e), append(n.dists, dist)
+	for ; i > 0 && n.dists[i-1] > dist; i-- {
+		n.entities[i], n.dists[i] = n.entities[i-1], n.dists[i-1]
+	}
+	n.entities[i], n.dists[i] = e, dist
+}
+
+// worst returns the distance an entity has to be closer then to be kept, which is infinite
+// until k entities have been added.
+func (n *nearest) worst() float64 {
+	if len(n.entities) < n.k {
+		return math.Inf(1)
+	}
+	return n.dists[n.k-1]
+}
+
+// containsRef returns if the given entity pointer is in the list of entities.
+func containsRef(entities Entities, entity *Entity) bool {
+	for _, e := range entities {
+		if e == entity {
+			return true
+		}
+	}
+	return false
+}
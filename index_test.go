@@ -0,0 +1,396 @@
+// Copyright 2019 Tskken. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package quadgo
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// indexes is every SpatialIndex the shared tests and benchmarks are run against, each
+// created empty covering at least 0,0 to 1000,1000.
+var indexes = []struct {
+	name string
+	new  func() SpatialIndex
+}{
+	{name: "QuadGo", new: func() SpatialIndex { return New(1000, 1000, SetMaxEntities(8), SetMaxDepth(8)) }},
+	{name: "PooledQuadGo", new: func() SpatialIndex { return New(1000, 1000, SetMaxEntities(8), SetMaxDepth(8), SetNodePool(256)) }},
+	{name: "RTree", new: func() SpatialIndex { return NewRTree(SetMaxEntities(8)) }},
+	{name: "BVH", new: func() SpatialIndex { return NewBVH(2) }},
+	{name: "Grid", new: func() SpatialIndex { return NewGrid(40) }},
+	{name: "SweepAndPrune", new: func() SpatialIndex { return NewSweepAndPrune() }},
+}
+
+// ids returns the sorted IDs of the given entities.
+func ids(entities Entities) []uint64 {
+	ids := make([]uint64, 0, len(entities))
+	for _, e := range entities {
+		ids = append(ids, e.ID)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+// query returns every entity the index gives for the given bound, failing if any is given twice.
+func query(t *testing.T, index SpatialIndex, bound Bound) Entities {
+	t.Helper()
+
+	var found Entities
+	index.QueryFunc(bound, func(e *Entity) bool {
+		if containsRef(found, e) {
+			t.Errorf("SpatialIndex.QueryFunc() gave entity %v twice", e.ID)
+		}
+		found = append(found, e)
+		return true
+	})
+	return found
+}
+
+// randomBounds returns n random bounds up to 100 wide and high with in the given size.
+func randomBounds(n int, width, height float64) []Bound {
+	r := rand.New(rand.NewSource(2))
+
+	bounds := make([]Bound, n)
+	for i := range bounds {
+		x, y := r.Float64()*width, r.Float64()*height
+		bounds[i] = NewBound(x, y, x+r.Float64()*100, y+r.Float64()*100)
+	}
+	return bounds
+}
+
+func TestNewGrid(t *testing.T) {
+	tests := []struct {
+		name      string
+		cellSize  float64
+		wantPanic bool
+	}{
+		{name: "cell size", cellSize: 64, wantPanic: false},
+		{name: "small cell size", cellSize: 0.5, wantPanic: false},
+		{name: "zero cell size", cellSize: 0, wantPanic: true},
+		{name: "negative cell size", cellSize: -64, wantPanic: true},
+		{name: "NaN cell size", cellSize: math.NaN(), wantPanic: true},
+		{name: "infinite cell size", cellSize: math.Inf(1), wantPanic: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if panicked := recover() != nil; panicked != tt.wantPanic {
+					t.Errorf("quadgo.NewGrid(%v) panicked = %v, want %v", tt.cellSize, panicked, tt.wantPanic)
+				}
+			}()
+
+			g := NewGrid(tt.cellSize)
+			if g.cellSize != tt.cellSize {
+				t.Errorf("quadgo.NewGrid() cell size = %v, want %v", g.cellSize, tt.cellSize)
+			}
+		})
+	}
+}
+
+func TestSpatialIndex_InsertEntities(t *testing.T) {
+	for _, index := range indexes {
+		t.Run(index.name, func(t *testing.T) {
+			s := index.new()
+			if err := s.InsertEntities(); err == nil {
+				t.Errorf("SpatialIndex.InsertEntities() with no entities got no error")
+			}
+
+			entities := randomEntities(200, 1000, 1000)
+			if err := s.InsertEntities(entities...); err != nil {
+				t.Fatalf("SpatialIndex.InsertEntities() got error %v", err)
+			}
+			if s.Len() != len(entities) {
+				t.Errorf("SpatialIndex.Len() = %v, want %v", s.Len(), len(entities))
+			}
+			if got := ids(query(t, s, NewBound(0, 0, 1000, 1000))); !reflect.DeepEqual(got, ids(entities)) {
+				t.Errorf("SpatialIndex.QueryFunc() = %v, want %v", got, ids(entities))
+			}
+		})
+	}
+}
+
+func TestSpatialIndex_Remove(t *testing.T) {
+	for _, index := range indexes {
+		t.Run(index.name, func(t *testing.T) {
+			s := index.new()
+			entities := randomEntities(200, 1000, 1000)
+			s.InsertEntities(entities...)
+
+			for _, e := range entities[:100] {
+				if err := s.Remove(e); err != nil {
+					t.Fatalf("SpatialIndex.Remove() got error %v", err)
+				}
+			}
+			if err := s.Remove(entities[0]); err == nil {
+				t.Errorf("SpatialIndex.Remove() of a removed entity got no error")
+			}
+			if err := s.Remove(&Entity{ID: 1000, Bound: entities[150].Bound}); err == nil {
+				t.Errorf("SpatialIndex.Remove() of a missing entity got no error")
+			}
+
+			if s.Len() != 100 {
+				t.Errorf("SpatialIndex.Len() = %v, want %v", s.Len(), 100)
+			}
+			if got := ids(query(t, s, NewBound(0, 0, 1000, 1000))); !reflect.DeepEqual(got, ids(entities[100:])) {
+				t.Errorf("SpatialIndex.QueryFunc() = %v, want %v", got, ids(entities[100:]))
+			}
+
+			// removing everything and inserting again leaves a working index
+			for _, e := range entities[100:] {
+				s.Remove(e)
+			}
+			s.InsertEntities(entities[:10]...)
+			if got := ids(query(t, s, NewBound(0, 0, 1000, 1000))); !reflect.DeepEqual(got, ids(entities[:10])) {
+				t.Errorf("SpatialIndex.QueryFunc() after reinserting = %v, want %v", got, ids(entities[:10]))
+			}
+		})
+	}
+}
+
+func TestSpatialIndex_InsertEntities_same(t *testing.T) {
+	for _, index := range indexes {
+		t.Run(index.name, func(t *testing.T) {
+			s := index.new()
+			entities := randomEntities(50, 1000, 1000)
+			s.InsertEntities(entities...)
+
+			// an entity equal to one in the index is skipped, so one remove takes it out
+			e := &Entity{ID: 1000, Bound: NewBound(100, 100, 150, 150)}
+			s.InsertEntities(e, e)
+			s.InsertEntities(&Entity{ID: e.ID, Bound: e.Bound})
+			if s.Len() != len(entities)+1 {
+				t.Errorf("SpatialIndex.Len() = %v, want %v", s.Len(), len(entities)+1)
+			}
+			if err := s.Remove(e); err != nil {
+				t.Fatalf("SpatialIndex.Remove() got error %v", err)
+			}
+			if s.Len() != len(entities) {
+				t.Errorf("SpatialIndex.Len() after Remove() = %v, want %v", s.Len(), len(entities))
+			}
+			if got := ids(query(t, s, NewBound(0, 0, 1000, 1000))); !reflect.DeepEqual(got, ids(entities)) {
+				t.Errorf("SpatialIndex.QueryFunc() after Remove() = %v, want %v", got, ids(entities))
+			}
+
+			// on its own the entity is gone after one remove
+			s = index.new()
+			s.InsertEntities(e)
+			s.InsertEntities(e)
+			s.Remove(e)
+			if s.Len() != 0 {
+				t.Errorf("SpatialIndex.Len() after inserting twice and removing once = %v, want 0", s.Len())
+			}
+			if got := query(t, s, NewBound(0, 0, 1000, 1000)); len(got) != 0 {
+				t.Errorf("SpatialIndex.QueryFunc() after inserting twice and removing once = %v, want none", ids(got))
+			}
+		})
+	}
+}
+
+func TestSpatialIndex_QueryFunc(t *testing.T) {
+	entities := randomEntities(500, 1000, 1000)
+
+	// a wide entity stored in many cells and nodes
+	entities = append(entities, &Entity{ID: 1000, Bound: NewBound(100, 480, 900, 520)})
+
+	for _, index := range indexes {
+		t.Run(index.name, func(t *testing.T) {
+			s := index.new()
+			s.InsertEntities(entities...)
+
+			for _, bound := range randomBounds(100, 1000, 1000) {
+				var want Entities
+				for _, e := range entities {
+					if e.IsIntersect(bound) {
+						want = append(want, e)
+					}
+				}
+
+				if got := ids(query(t, s, bound)); !reflect.DeepEqual(got, ids(want)) {
+					t.Errorf("SpatialIndex.QueryFunc(%v) = %v, want %v", bound, got, ids(want))
+				}
+			}
+
+			count := 0
+			s.QueryFunc(NewBound(0, 0, 1000, 1000), func(*Entity) bool {
+				count++
+				return count < 3
+			})
+			if count != 3 {
+				t.Errorf("SpatialIndex.QueryFunc() stopped after %v entities, want 3", count)
+			}
+		})
+	}
+}
+
+func TestSpatialIndex_QueryFunc_shape(t *testing.T) {
+	for _, index := range indexes {
+		t.Run(index.name, func(t *testing.T) {
+			s := index.new()
+			ball := NewEntityWithShape(Circle{Center: Point{100, 100}, Radius: 50})
+			s.InsertEntities(ball)
+
+			if got := query(t, s, NewBound(55, 55, 60, 60)); len(got) != 0 {
+				t.Errorf("SpatialIndex.QueryFunc() in corner of circle bound = %v, want none", ids(got))
+			}
+			if got := query(t, s, NewBound(90, 90, 110, 110)); len(got) != 1 {
+				t.Errorf("SpatialIndex.QueryFunc() over circle = %v, want %v", ids(got), []uint64{ball.ID})
+			}
+		})
+	}
+}
+
+func TestSpatialIndex_Nearest(t *testing.T) {
+	entities := randomEntities(300, 1000, 1000)
+
+	r := rand.New(rand.NewSource(3))
+	points := []Point{{0, 0}, {1000, 1000}, {-500, 300}, {2000, -2000}}
+	for i := 0; i < 50; i++ {
+		points = append(points, Point{X: r.Float64() * 1000, Y: r.Float64() * 1000})
+	}
+
+	for _, index := range indexes {
+		t.Run(index.name, func(t *testing.T) {
+			s := index.new()
+			if got := s.Nearest(Point{}, 3); len(got) != 0 {
+				t.Errorf("SpatialIndex.Nearest() on empty index = %v, want none", ids(got))
+			}
+			s.InsertEntities(entities...)
+
+			for _, p := range points {
+				for _, k := range []int{1, 5, 20} {
+					// compare distances as entities the same distance away can be given in any order
+					dists := make([]float64, len(entities))
+					for i, e := range entities {
+						dists[i] = e.Bound.distance(p)
+					}
+					sort.Float64s(dists)
+
+					got := s.Nearest(p, k)
+					if len(got) != k {
+						t.Fatalf("SpatialIndex.Nearest(%v, %v) = %v entities, want %v", p, k, len(got), k)
+					}
+					for i, e := range got {
+						if d := e.Bound.distance(p); math.Abs(d-dists[i]) > 1e-9 {
+							t.Errorf("SpatialIndex.Nearest(%v, %v)[%v] distance = %v, want %v", p, k, i, d, dists[i])
+						}
+					}
+				}
+			}
+
+			if got := s.Nearest(Point{500, 500}, 0); len(got) != 0 {
+				t.Errorf("SpatialIndex.Nearest() with k of 0 = %v, want none", ids(got))
+			}
+			if got := s.Nearest(Point{500, 500}, 1000); len(got) != len(entities) {
+				t.Errorf("SpatialIndex.Nearest() with k over Len() = %v entities, want %v", len(got), len(entities))
+			}
+		})
+	}
+}
+
+func TestSpatialIndex_Nearest_inside(t *testing.T) {
+	for _, index := range indexes {
+		t.Run(index.name, func(t *testing.T) {
+			s := index.new()
+			big := &Entity{ID: 1, Bound: NewBound(0, 0, 900, 900)}
+			small := &Entity{ID: 2, Bound: NewBound(440, 440, 460, 460)}
+			far := &Entity{ID: 3, Bound: NewBound(950, 950, 960, 960)}
+			s.InsertEntities(big, small, far)
+
+			got := s.Nearest(Point{450, 450}, 2)
+			if !reflect.DeepEqual(ids(got), []uint64{1, 2}) {
+				t.Errorf("SpatialIndex.Nearest() = %v, want %v", ids(got), []uint64{1, 2})
+			}
+		})
+	}
+}
+
+func BenchmarkSpatialIndex_InsertEntities(b *testing.B) {
+	entities := randomEntities(1000, 1000, 1000)
+
+	for _, index := range indexes {
+		b.Run(index.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				index.new().InsertEntities(entities...)
+			}
+		})
+	}
+}
+
+func BenchmarkSpatialIndex_QueryFunc(b *testing.B) {
+	entities := randomEntities(5000, 1000, 1000)
+	bounds := randomBounds(100, 1000, 1000)
+
+	for _, index := range indexes {
+		s := index.new()
+		s.InsertEntities(entities...)
+
+		b.Run(index.name, func(b *testing.B) {
+			b.ReportAllocs()
+			found := 0
+			for i := 0; i < b.N; i++ {
+				s.QueryFunc(bounds[i%len(bounds)], func(*Entity) bool {
+					found++
+					return true
+				})
+			}
+		})
+	}
+}
+
+func BenchmarkSpatialIndex_Nearest(b *testing.B) {
+	entities := randomEntities(5000, 1000, 1000)
+	bounds := randomBounds(100, 1000, 1000)
+
+	for _, index := range indexes {
+		s := index.new()
+		s.InsertEntities(entities...)
+
+		for _, k := range []int{1, 10} {
+			b.Run(fmt.Sprintf("%v/k=%v", index.name, k), func(b *testing.B) {
+				b.ReportAllocs()
+				for i := 0; i < b.N; i++ {
+					s.Nearest(bounds[i%len(bounds)].Center, k)
+				}
+			})
+		}
+	}
+}
+
+func BenchmarkSpatialIndex_Move(b *testing.B) {
+	entities := randomEntities(5000, 1000, 1000)
+
+	for _, index := range indexes {
+		// copy the entities as moving them changes their bounds
+		moving := make(Entities, len(entities))
+		for i, e := range entities {
+			c := *e
+			moving[i] = &c
+		}
+
+		s := index.new()
+		s.InsertEntities(moving...)
+
+		b.Run(index.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				e := moving[i%len(moving)]
+				s.Remove(e)
+
+				// move the entity back and forth so it stays with in the index
+				dx := 5.0
+				if (i/len(moving))%2 == 1 {
+					dx = -5
+				}
+				e.Bound = NewBound(e.Min.X+dx, e.Min.Y, e.Max.X+dx, e.Max.Y)
+				s.InsertEntities(e)
+			}
+		})
+	}
+}
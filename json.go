@@ -0,0 +1,248 @@
+// Copyright 2019 Tskken. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package quadgo
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+// jsonMaxDepth is the largest max depth option read from the JSON format. The nodes are
+// rebuilt from the entities, so a deeper tree could split in to more nodes then the data
+// read could ever need, as the bounds of its nodes run out of precision long before it.
+const jsonMaxDepth = 64
+
+// jsonReferences is the most nodes, on average, each entity read from the JSON format can be
+// in when the tree is rebuilt, counting branch nodes as holding the entities they are split
+// from. Entities which overlap many nodes can make a tree split far more then the data read
+// needs, so a tree which needs more is not rebuilt.
+const jsonReferences = 1024
+
+// jsonBound is the JSON form of a Bound.
+type jsonBound struct {
+	Min Point `json:"min"`
+	Max Point `json:"max"`
+}
+
+// MarshalJSON encodes the bound as its min and max points.
+//
+// The center of the bound is not encoded as it is computed from the min and max points.
+//
+// Example:
+//  {"min":{"x":0,"y":0},"max":{"x":50,"y":50}}
+func (b Bound) MarshalJSON() ([]byte, error) {
+	return json.Marshal(jsonBound{
+		Min: b.Min,
+		Max: b.Max,
+	})
+}
+
+// UnmarshalJSON decodes the bound from its min and max points.
+func (b *Bound) UnmarshalJSON(data []byte) error {
+	var j jsonBound
+	if err := json.Unmarshal(data, &j); err != nil {
+		return err
+	}
+
+	*b = NewBound(j.Min.X, j.Min.Y, j.Max.X, j.Max.Y)
+	return nil
+}
+
+// jsonEntity is the JSON form of an Entity.
+type jsonEntity struct {
+	ID    uint64      `json:"id,string"`
+	Bound Bound       `json:"bound"`
+	Data  interface{} `json:"data,omitempty"`
+}
+
+// MarshalJSON encodes the entity as its ID, Bound and Data.
+//
+// The ID is encoded as a string as most JSON readers can not hold the full range of a uint64.
+// Action functions are not encoded.
+//
+// MarshalJSON has a value receiver so that both Entity and *Entity values are encoded as an
+// entity and not as only the embedded Bound.
+//
+// Example:
+//  {"id":"1","bound":{"min":{"x":0,"y":0},"max":{"x":50,"y":50}},"data":"player"}
+func (e Entity) MarshalJSON() ([]byte, error) {
+	return json.Marshal(jsonEntity{
+		ID:    e.ID,
+		Bound: e.Bound,
+		Data:  e.Data,
+	})
+}
+
+// UnmarshalJSON decodes the entity from its ID, Bound and Data.
+//
+// Data is decoded in to the default types of encoding/json, so an object is decoded as a
+// map[string]interface{}.
+func (e *Entity) UnmarshalJSON(data []byte) error {
+	var j jsonEntity
+	if err := json.Unmarshal(data, &j); err != nil {
+		return err
+	}
+
+	e.ID = j.ID
+	e.Bound = j.Bound
+	e.Data = j.Data
+	return nil
+}
+
+// jsonTree is the JSON form of a QuadGo tree.
+type jsonTree struct {
+	Bound       Bound     `json:"bound"`
+	MaxEntities uint64    `json:"maxEntities"`
+	MaxDepth    uint16    `json:"maxDepth"`
+	Entities    Entities  `json:"entities"`
+	Nodes       *jsonNode `json:"nodes,omitempty"`
+}
+
+// jsonNode is the JSON form of a node in the layout of a tree.
+type jsonNode struct {
+	Bound    Bound       `json:"bound"`
+	Depth    uint16      `json:"depth"`
+	Entities []string    `json:"entities,omitempty"`
+	Children []*jsonNode `json:"children,omitempty"`
+}
+
+// MarshalJSON encodes the tree as its bounds, options and entities.
+//
+// Each entity is only encoded once. Use MarshalJSONLayout to also encode the node layout.
+//
+// Example:
+//  {"bound":{...},"maxEntities":10,"maxDepth":5,"entities":[{"id":"1","bound":{...}}]}
+func (q *QuadGo) MarshalJSON() ([]byte, error) {
+	return json.Marshal(q.jsonTree())
+}
+
+// MarshalJSONLayout encodes the tree the same as MarshalJSON with the node layout of the tree
+// added under "nodes". Each node holds its bound, depth and children, with leaf nodes holding
+// the IDs of their entities.
+//
+// The node layout is for tools that want to show the tree and is ignored by UnmarshalJSON.
+func (q *QuadGo) MarshalJSONLayout() ([]byte, error) {
+	j := q.jsonTree()
+	j.Nodes = q.node.jsonNode()
+
+	return json.Marshal(j)
+}
+
+// UnmarshalJSON decodes the tree from its bounds, options and entities, replacing all of
+// this trees options, nodes and entities. The nodes are rebuilt the same as Build().
+//
+// This will return an error, leaving the tree as it was, if the max entities option is over
+// 65536, the max depth option is over 64, any entity is outside of the bound of the tree or
+// rebuilding the nodes would put the entities in more then 1024 nodes each on average.
+func (q *QuadGo) UnmarshalJSON(data []byte) error {
+	var j jsonTree
+	if err := json.Unmarshal(data, &j); err != nil {
+		return err
+	}
+
+	// the options size every node of the tree rebuilt, so they are checked before any are made
+	if j.MaxEntities > decodeMaxEntities {
+		return fmt.Errorf("QuadGo JSON tree has %v max entities, want at most %v", j.MaxEntities, decodeMaxEntities)
+	}
+	if j.MaxDepth > jsonMaxDepth {
+		return fmt.Errorf("QuadGo JSON tree has a max depth of %v, want at most %v", j.MaxDepth, jsonMaxDepth)
+	}
+
+	// check how large the rebuilt nodes would be before making any of them
+	budget := jsonReferences * len(j.Entities)
+	scratch := make(Entities, 0, 2*len(j.Entities))
+	if !jsonFits(j.Bound, j.Entities, 0, j.MaxDepth, int(j.MaxEntities), &budget, &scratch) {
+		return fmt.Errorf("QuadGo JSON tree splits in to more then %v nodes for each entity", jsonReferences)
+	}
+
+	t := &QuadGo{
+		node: &node{
+			parent:   nil,
+			bound:    j.Bound,
+			entities: make(Entities, 0, j.MaxEntities),
+			children: make(nodes, 0, 4),
+			depth:    0,
+		},
+		maxEntities: j.MaxEntities,
+		maxDepth:    j.MaxDepth,
+		observer:    q.observer,
+		pool:        q.pool,
+	}
+	if err := t.load(j.Entities); err != nil {
+		return err
+	}
+
+	q.node = t.node
+	q.maxEntities = t.maxEntities
+	q.maxDepth = t.maxDepth
+	q.size = t.size
+	return nil
+}
+
+// jsonFits returns if a node with the given bound and depth, built from the given entities
+// the same as node.build(), and the nodes it splits in to hold no more then the given budget
+// of entities between them, taking the entities they hold from the budget.
+//
+// jsonFits stops as soon as the budget runs out, so it does no more work then the budget.
+// scratch is used as a stack of the entities of each node, the same as node.build().
+func jsonFits(bound Bound, entities Entities, depth, maxDepth uint16, maxEntities int, budget *int, scratch *Entities) bool {
+	*budget -= len(entities)
+	if *budget < 0 {
+		return false
+	}
+	if !overfull(len(entities), maxEntities, depth, maxDepth) {
+		return true
+	}
+
+	for _, b := range bound.quadrants() {
+		start := len(*scratch)
+		for _, e := range entities {
+			if b.IsIntersect(e.Bound) {
+				*scratch = append(*scratch, e)
+			}
+		}
+
+		fits := jsonFits(b, (*scratch)[start:], depth+1, maxDepth, maxEntities, budget, scratch)
+		*scratch = (*scratch)[:start]
+		if !fits {
+			return false
+		}
+	}
+	return true
+}
+
+// jsonTree returns the JSON form of the tree without its node layout.
+func (q *QuadGo) jsonTree() jsonTree {
+	entities := make(Entities, 0, q.size)
+	q.forEach(q.bound, func(e *Entity) bool {
+		entities = append(entities, e)
+		return true
+	})
+
+	return jsonTree{
+		Bound:       q.bound,
+		MaxEntities: q.maxEntities,
+		MaxDepth:    q.maxDepth,
+		Entities:    entities,
+	}
+}
+
+// jsonNode returns the JSON form of the node and its children.
+func (n *node) jsonNode() *jsonNode {
+	j := &jsonNode{
+		Bound: n.bound,
+		Depth: n.depth,
+	}
+
+	for _, e := range n.entities {
+		j.Entities = append(j.Entities, strconv.FormatUint(e.ID, 10))
+	}
+	for i := range n.children {
+		j.Children = append(j.Children, n.children[i].jsonNode())
+	}
+
+	return j
+}
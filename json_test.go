@@ -0,0 +1,320 @@
+// Copyright 2019 Tskken. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package quadgo
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestBound_MarshalJSON(t *testing.T) {
+	tests := []struct {
+		name  string
+		bound Bound
+		want  string
+	}{
+		{
+			name:  "basic bound",
+			bound: NewBound(0, 0, 50, 50),
+			want:  `{"min":{"x":0,"y":0},"max":{"x":50,"y":50}}`,
+		},
+		{
+			name:  "fractional bound",
+			bound: NewBound(0.5, 1.25, 10, 20.75),
+			want:  `{"min":{"x":0.5,"y":1.25},"max":{"x":10,"y":20.75}}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := json.Marshal(tt.bound)
+			if err != nil {
+				t.Errorf("Bound.MarshalJSON() got error %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Bound.MarshalJSON() = %s, want %s", got, tt.want)
+			}
+
+			var bound Bound
+			if err := json.Unmarshal(got, &bound); err != nil {
+				t.Errorf("Bound.UnmarshalJSON() got error %v", err)
+			}
+			if !reflect.DeepEqual(bound, tt.bound) {
+				t.Errorf("Bound.UnmarshalJSON() = %v, want %v", bound, tt.bound)
+			}
+		})
+	}
+}
+
+func TestBound_UnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    Bound
+		wantErr bool
+	}{
+		{
+			name: "computes center",
+			data: `{"min":{"x":0,"y":0},"max":{"x":50,"y":50}}`,
+			want: NewBound(0, 0, 50, 50),
+		},
+		{
+			name:    "invalid json",
+			data:    `{"min":`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got Bound
+			err := json.Unmarshal([]byte(tt.data), &got)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Bound.UnmarshalJSON() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Bound.UnmarshalJSON() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEntity_MarshalJSON(t *testing.T) {
+	tests := []struct {
+		name   string
+		entity interface{}
+		want   string
+	}{
+		{
+			name:   "entity pointer",
+			entity: &Entity{ID: 1, Bound: NewBound(0, 0, 50, 50)},
+			want:   `{"id":"1","bound":{"min":{"x":0,"y":0},"max":{"x":50,"y":50}}}`,
+		},
+		{
+			name:   "entity value",
+			entity: Entity{ID: 1, Bound: NewBound(0, 0, 50, 50)},
+			want:   `{"id":"1","bound":{"min":{"x":0,"y":0},"max":{"x":50,"y":50}}}`,
+		},
+		{
+			name:   "entity with max id and data",
+			entity: &Entity{ID: 18446744073709551615, Bound: NewBound(0, 0, 50, 50), Data: "player", Action: func() {}},
+			want:   `{"id":"18446744073709551615","bound":{"min":{"x":0,"y":0},"max":{"x":50,"y":50}},"data":"player"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := json.Marshal(tt.entity)
+			if err != nil {
+				t.Errorf("Entity.MarshalJSON() got error %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Entity.MarshalJSON() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEntity_UnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    *Entity
+		wantErr bool
+	}{
+		{
+			name: "basic entity",
+			data: `{"id":"18446744073709551615","bound":{"min":{"x":0,"y":0},"max":{"x":50,"y":50}}}`,
+			want: &Entity{ID: 18446744073709551615, Bound: NewBound(0, 0, 50, 50)},
+		},
+		{
+			name: "entity with object data",
+			data: `{"id":"1","bound":{"min":{"x":0,"y":0},"max":{"x":50,"y":50}},"data":{"name":"wall"}}`,
+			want: &Entity{ID: 1, Bound: NewBound(0, 0, 50, 50), Data: map[string]interface{}{"name": "wall"}},
+		},
+		{
+			name:    "number id",
+			data:    `{"id":1,"bound":{"min":{"x":0,"y":0},"max":{"x":50,"y":50}}}`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := new(Entity)
+			err := json.Unmarshal([]byte(tt.data), got)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Entity.UnmarshalJSON() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Entity.UnmarshalJSON() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQuadGo_MarshalJSON(t *testing.T) {
+	type fields struct {
+		quadgo   *QuadGo
+		entities Entities
+	}
+	tests := []struct {
+		name   string
+		fields fields
+	}{
+		{
+			name: "empty tree",
+			fields: fields{
+				quadgo: New(800, 600),
+			},
+		},
+		{
+			name: "split tree with duplicate references",
+			fields: fields{
+				quadgo: New(800, 600, SetMaxEntities(3), SetMaxDepth(4)),
+				entities: append(randomEntities(100, 800, 600),
+					&Entity{ID: 1000, Bound: NewBound(0, 0, 800, 600), Data: "background"},
+				),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, e := range tt.fields.entities {
+				if err := tt.fields.quadgo.InsertEntities(e); err != nil {
+					t.Errorf("QuadGo.MarshalJSON() got error on insert %v", err)
+				}
+			}
+
+			data, err := json.Marshal(tt.fields.quadgo)
+			if err != nil {
+				t.Errorf("QuadGo.MarshalJSON() got error %v", err)
+			}
+
+			got := New(0, 0)
+			if err := json.Unmarshal(data, got); err != nil {
+				t.Errorf("QuadGo.UnmarshalJSON() got error %v", err)
+			}
+
+			if !sameNode(got.node, tt.fields.quadgo.node) {
+				t.Errorf("QuadGo.UnmarshalJSON() tree does not match original tree")
+			}
+			if got.Len() != tt.fields.quadgo.Len() {
+				t.Errorf("QuadGo.UnmarshalJSON() Len() = %v, want %v", got.Len(), tt.fields.quadgo.Len())
+			}
+			if got.maxEntities != tt.fields.quadgo.maxEntities || got.maxDepth != tt.fields.quadgo.maxDepth {
+				t.Errorf("QuadGo.UnmarshalJSON() options = %v, %v, want %v, %v",
+					got.maxEntities, got.maxDepth, tt.fields.quadgo.maxEntities, tt.fields.quadgo.maxDepth)
+			}
+		})
+	}
+}
+
+func TestQuadGo_UnmarshalJSON(t *testing.T) {
+	const bound = `"bound":{"min":{"x":0,"y":0},"max":{"x":100,"y":100}}`
+	const entity = `{"id":"1","bound":{"min":{"x":10,"y":10},"max":{"x":20,"y":20}}}`
+
+	tests := []struct {
+		name    string
+		data    string
+		wantLen int
+		wantErr bool
+	}{
+		{
+			name:    "valid tree",
+			data:    `{` + bound + `,"maxEntities":2,"maxDepth":4,"entities":[` + entity + `]}`,
+			wantLen: 1,
+		},
+		{
+			name:    "too many max entities",
+			data:    `{` + bound + `,"maxEntities":18446744073709551615,"maxDepth":4,"entities":[]}`,
+			wantErr: true,
+		},
+		{
+			name:    "max depth too deep",
+			data:    `{` + bound + `,"maxEntities":2,"maxDepth":65535,"entities":[]}`,
+			wantErr: true,
+		},
+		{
+			name:    "max depth out of range",
+			data:    `{` + bound + `,"maxEntities":2,"maxDepth":65536,"entities":[]}`,
+			wantErr: true,
+		},
+		{
+			name:    "entities overlapping every node",
+			data:    `{` + bound + `,"maxEntities":1,"maxDepth":10,"entities":[{"id":"1",` + bound + `},{"id":"2",` + bound + `}]}`,
+			wantErr: true,
+		},
+		{
+			name:    "entities overlapping many nodes",
+			data:    `{` + bound + `,"maxEntities":1,"maxDepth":3,"entities":[{"id":"1",` + bound + `},{"id":"2",` + bound + `}]}`,
+			wantLen: 2,
+		},
+		{
+			name:    "entity outside of tree",
+			data:    `{` + bound + `,"maxEntities":2,"maxDepth":4,"entities":[{"id":"2","bound":{"min":{"x":200,"y":200},"max":{"x":300,"y":300}}}]}`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := New(800, 600, SetMaxEntities(3))
+			got.InsertEntities(randomEntities(5, 800, 600)...)
+
+			err := json.Unmarshal([]byte(tt.data), got)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("QuadGo.UnmarshalJSON() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				// the tree is left as it was
+				if got.Len() != 5 || got.maxEntities != 3 || !got.node.bound.IsEqual(NewBound(0, 0, 800, 600)) {
+					t.Errorf("QuadGo.UnmarshalJSON() changed the tree on error")
+				}
+				return
+			}
+			if got.Len() != tt.wantLen {
+				t.Errorf("QuadGo.UnmarshalJSON() Len() = %v, want %v", got.Len(), tt.wantLen)
+			}
+		})
+	}
+}
+
+func TestQuadGo_MarshalJSONLayout(t *testing.T) {
+	q := New(800, 600, SetMaxEntities(2))
+	err := q.InsertEntities(
+		&Entity{ID: 1, Bound: NewBound(0, 0, 50, 50)},
+		&Entity{ID: 2, Bound: NewBound(500, 400, 700, 600)},
+		&Entity{ID: 3, Bound: NewBound(450, 0, 500, 50)},
+	)
+	if err != nil {
+		t.Errorf("QuadGo.MarshalJSONLayout() got error on insert %v", err)
+	}
+
+	data, err := q.MarshalJSONLayout()
+	if err != nil {
+		t.Errorf("QuadGo.MarshalJSONLayout() got error %v", err)
+	}
+
+	var got jsonTree
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Errorf("QuadGo.MarshalJSONLayout() got invalid json %v", err)
+	}
+
+	if got.Nodes == nil || len(got.Nodes.Children) != 4 {
+		t.Fatalf("QuadGo.MarshalJSONLayout() nodes = %v, want root with 4 children", got.Nodes)
+	}
+	want := [][]string{{"1"}, {"3"}, nil, {"2"}}
+	for i, c := range got.Nodes.Children {
+		if c.Depth != 1 || !reflect.DeepEqual(c.Entities, want[i]) {
+			t.Errorf("QuadGo.MarshalJSONLayout() child %v = %v, %v, want 1, %v", i, c.Depth, c.Entities, want[i])
+		}
+	}
+
+	// the layout should be ignored when decoding
+	tree := New(0, 0)
+	if err := json.Unmarshal(data, tree); err != nil {
+		t.Errorf("QuadGo.UnmarshalJSON() got error %v", err)
+	}
+	if !sameNode(tree.node, q.node) {
+		t.Errorf("QuadGo.UnmarshalJSON() tree does not match original tree")
+	}
+}
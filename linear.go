@@ -0,0 +1,351 @@
+// Copyright 2019 Tskken. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package quadgo
+
+import (
+	"errors"
+	"math"
+)
+
+// mortonBits is the number of bits of each axis in a Morton code, so a LinearTree is
+// split in to a grid of 65536 by 65536 of the smallest cells.
+const mortonBits = 16
+
+// LinearTree is a linear quad-tree of points, with no nodes or pointers at all.
+//
+// Each point is given the Morton code of the smallest cell of the tree it is in, which
+// interleaves the bits of its cell's x and y so that the points of every cell of the
+// quad-tree, at any depth, have a run of codes next to each other. The points are kept in
+// one list sorted by their codes, so a query only has to find the runs of codes of the cells
+// it covers with binary searches and then read the points in them in order. This keeps the
+// points of each area of the tree next to each other in memory, which makes a LinearTree far
+// kinder to the CPU cache than following the nodes of a tree for large numbers of points.
+//
+// Inserting or removing one point moves the points after it in the list, so a LinearTree is
+// best filled all at once, such as with Clear() and InsertPoints() each frame of a simulation.
+type LinearTree struct {
+	bound Bound
+
+	// scale is the number of the smallest cells per unit of the bound along each axis
+	scale Point
+
+	// maxPoints is the most points read from a cell without splitting it
+	maxPoints int
+
+	// codes and points are the codes of the points and the points, sorted by code
+	codes  []uint32
+	points []Point
+
+	// scratchCodes and scratchPoints hold the points while they are sorted
+	scratchCodes  []uint32
+	scratchPoints []Point
+}
+
+// NewLinearTree creates an empty LinearTree covering the given width and height.
+//
+// The max entities Option sets the most points read from a cell of the tree before the query
+// is split in to its four children. The max depth Option has no effect, as the depth of a
+// LinearTree is set by the number of bits in each Morton code.
+//
+// Points outside of the width and height can still be inserted and are held by the cells on
+// the edge of the tree closest to them.
+//
+// Example:
+//  particles := quadgo.NewLinearTree(800, 600, quadgo.SetMaxEntities(32))
+func NewLinearTree(width, height float64, ops ...Option) *LinearTree {
+	return NewLinearTreeWithBound(NewBound(0, 0, width, height), ops...)
+}
+
+// NewLinearTreeWithBound creates an empty LinearTree covering the given bound.
+// See NewLinearTree().
+func NewLinearTreeWithBound(bound Bound, ops ...Option) *LinearTree {
+	// copy defaults
+	o := defaultOption
+
+	// update for any given options
+	for _, op := range ops {
+		op(&o)
+	}
+
+	maxPoints := int(o.MaxEntities)
+	if maxPoints < 1 {
+		maxPoints = 1
+	}
+
+	return &LinearTree{
+		bound: bound,
+		scale: Point{
+			X: (1 << mortonBits) / (bound.Max.X - bound.Min.X),
+			Y: (1 << mortonBits) / (bound.Max.Y - bound.Min.Y),
+		},
+		maxPoints: maxPoints,
+	}
+}
+
+// BuildLinearTree creates a new LinearTree covering the given width and height filled with
+// the given points. See NewLinearTree().
+func BuildLinearTree(width, height float64, points []Point, ops ...Option) *LinearTree {
+	l := NewLinearTree(width, height, ops...)
+	if len(points) > 0 {
+		l.InsertPoints(points...)
+	}
+	return l
+}
+
+// InsertPoint inserts the given point in to the tree, moving every point with a higher
+// code along by one.
+func (l *LinearTree) InsertPoint(point Point) {
+	code := l.code(point)
+	i := l.search(0, len(l.codes), uint64(code)+1)
+
+	l.codes = append(l.codes, 0)
+	l.points = append(l.points, Point{})
+	copy(l.codes[i+1:], l.codes[i:])
+	copy(l.points[i+1:], l.points[i:])
+	l.codes[i], l.points[i] = code, point
+}
+
+// InsertPoints inserts any number of points in to the tree, adding them to the end of the
+// list of points and then radix sorting it once.
+//
+// This will return an error if you do not give it any points.
+func (l *LinearTree) InsertPoints(points ...Point) error {
+	if len(points) == 0 {
+		return errors.New("no points given to LinearTree.InsertPoints()")
+	}
+
+	for _, p := range points {
+		l.codes = append(l.codes, l.code(p))
+		l.points = append(l.points, p)
+	}
+	l.sort()
+	return nil
+}
+
+// RemovePoint removes the given point from the tree, or one of them if it was inserted
+// more then once.
+//
+// This will return an error if the point was not found in the tree.
+func (l *LinearTree) RemovePoint(point Point) error {
+	i := l.find(point)
+	if i == -1 {
+		return errors.New("could not find point in tree to remove")
+	}
+
+	l.codes = append(l.codes[:i], l.codes[i+1:]...)
+	l.points = append(l.points[:i], l.points[i+1:]...)
+	return nil
+}
+
+// Clear removes all points from the tree, keeping the memory used to hold them for the
+// next points inserted.
+func (l *LinearTree) Clear() {
+	l.codes = l.codes[:0]
+	l.points = l.points[:0]
+}
+
+// Len returns the number of points in the tree.
+func (l *LinearTree) Len() int {
+	return len(l.points)
+}
+
+// Bounds returns the bound the tree covers.
+func (l *LinearTree) Bounds() Bound {
+	return l.bound
+}
+
+// HasPoint returns if the given point is in the tree.
+func (l *LinearTree) HasPoint(point Point) bool {
+	return l.find(point) != -1
+}
+
+// QueryFunc calls the given function for every point with in the given bound, including
+// points on its edges, in order of their codes. Returning false from the given function
+// stops the query.
+func (l *LinearTree) QueryFunc(bound Bound, fn func(Point) bool) {
+	min, max := l.cell(bound.Min), l.cell(bound.Max)
+	l.query(bound, min, max, 0, 0, 0, 0, len(l.codes), fn)
+}
+
+// Points returns every point with in the given bound. See PointTree.Points().
+func (l *LinearTree) Points(bound Bound) (points []Point) {
+	l.QueryFunc(bound, func(point Point) bool {
+		points = append(points, point)
+		return true
+	})
+	return
+}
+
+// RadiusFunc calls the given function for every point with in the given radius of the given
+// center, including points at exactly the radius. Returning false from the given function
+// stops the query.
+func (l *LinearTree) RadiusFunc(center Point, radius float64, fn func(Point) bool) {
+	bound := NewBound(center.X-radius, center.Y-radius, center.X+radius, center.Y+radius)
+	l.QueryFunc(bound, func(point Point) bool {
+		if d := sub(point, center); dot(d, d) > radius*radius {
+			return true
+		}
+		return fn(point)
+	})
+}
+
+// Radius returns every point with in the given radius of the given center.
+// See PointTree.Radius().
+func (l *LinearTree) Radius(center Point, radius float64) (points []Point) {
+	l.RadiusFunc(center, radius, func(point Point) bool {
+		points = append(points, point)
+		return true
+	})
+	return
+}
+
+// ForEach calls the given function for every point in the tree in order of their codes,
+// stopping if the function returns false.
+func (l *LinearTree) ForEach(fn func(Point) bool) {
+	for _, p := range l.points {
+		if !fn(p) {
+			return
+		}
+	}
+}
+
+// cell returns the column and row of the smallest cell holding the given point, clamped to
+// the cells of the tree.
+func (l *LinearTree) cell(p Point) [2]uint32 {
+	axis := func(v, min, scale float64) uint32 {
+		return uint32(math.Min(math.Max((v-min)*scale, 0), 1<<mortonBits-1))
+	}
+	return [2]uint32{axis(p.X, l.bound.Min.X, l.scale.X), axis(p.Y, l.bound.Min.Y, l.scale.Y)}
+}
+
+// code returns the Morton code of the given point.
+func (l *LinearTree) code(p Point) uint32 {
+	c := l.cell(p)
+	return morton(c[0], c[1])
+}
+
+// find returns the index of the given point in the list of points, or -1 if it is not in it.
+func (l *LinearTree) find(point Point) int {
+	code := l.code(point)
+	for i := l.search(0, len(l.codes), uint64(code)); i < len(l.codes) && l.codes[i] == code; i++ {
+		if l.points[i].IsEqual(point) {
+			return i
+		}
+	}
+	return -1
+}
+
+// query calls the given function for every point with in the given bound in the cell at the
+// given depth, column and row, whose points are from lo to hi of the list. min and max are
+// the smallest cells holding the corners of the bound, which the cell has to overlap. query
+// returns false if the function stopped the query.
+func (l *LinearTree) query(bound Bound, min, max [2]uint32, depth, x, y uint32, lo, hi int, fn func(Point) bool) bool {
+	// the smallest cells covered by this cell
+	shift := mortonBits - depth
+	cellMin := [2]uint32{x << shift, y << shift}
+	cellMax := [2]uint32{cellMin[0] + 1<<shift - 1, cellMin[1] + 1<<shift - 1}
+
+	// read the points of small cells and cells the bound covers, as they can not be skipped
+	covered := cellMin[0] >= min[0] && cellMax[0] <= max[0] && cellMin[1] >= min[1] && cellMax[1] <= max[1]
+	if hi-lo <= l.maxPoints || covered {
+		for i := lo; i < hi; i++ {
+			if bound.containsPoint(l.points[i]) && !fn(l.points[i]) {
+				return false
+			}
+		}
+		return true
+	}
+
+	// the codes of each child follow on from the last, in the order top left, top right,
+	// bottom left and then bottom right
+	half := uint32(1) << (shift - 1)
+	start := uint64(morton(cellMin[0], cellMin[1]))
+	size := uint64(1) << (2 * (shift - 1))
+	for q := uint32(0); q < 4; q++ {
+		childMin := [2]uint32{cellMin[0] + half*(q&1), cellMin[1] + half*(q>>1)}
+		if childMin[0]+half-1 < min[0] || childMin[0] > max[0] || childMin[1]+half-1 < min[1] || childMin[1] > max[1] {
+			continue
+		}
+
+		childLo := l.search(lo, hi, start+uint64(q)*size)
+		childHi := l.search(childLo, hi, start+uint64(q+1)*size)
+		if childLo == childHi {
+			continue
+		}
+		if !l.query(bound, min, max, depth+1, x<<1|q&1, y<<1|q>>1, childLo, childHi, fn) {
+			return false
+		}
+	}
+	return true
+}
+
+// morton returns the Morton code of the given column and row, with the bits of x in the even
+// bits of the code and the bits of y in the odd bits.
+func morton(x, y uint32) uint32 {
+	return spreadBits(x) | spreadBits(y)<<1
+}
+
+// spreadBits moves the low 16 bits of v apart so there is a 0 between each of them.
+func spreadBits(v uint32) uint32 {
+	v &= 0xffff
+	v = (v | v<<8) & 0x00ff00ff
+	v = (v | v<<4) & 0x0f0f0f0f
+	v = (v | v<<2) & 0x33333333
+	v = (v | v<<1) & 0x55555555
+	return v
+}
+
+// search returns the index of the first code from lo to hi that is not less then the given
+// code, or hi if every code is less.
+func (l *LinearTree) search(lo, hi int, code uint64) int {
+	for lo < hi {
+		mid := int(uint(lo+hi) >> 1)
+		if uint64(l.codes[mid]) < code {
+			lo = mid + 1
+		} else {
+			hi = mid
+		}
+	}
+	return lo
+}
+
+// sort sorts the points by their codes with a radix sort of each byte of the codes, keeping
+// points with the same code in the order they were.
+func (l *LinearTree) sort() {
+	n := len(l.codes)
+	if cap(l.scratchCodes) < n {
+		l.scratchCodes = make([]uint32, n, cap(l.codes))
+		l.scratchPoints = make([]Point, n, cap(l.points))
+	}
+	codes, points := l.codes, l.points
+	toCodes, toPoints := l.scratchCodes[:n], l.scratchPoints[:n]
+
+	for shift := uint(0); shift < 32; shift += 8 {
+		// count the codes with each value of this byte, and then where each value starts
+		var starts [257]int
+		for _, c := range codes {
+			starts[(c>>shift)&0xff+1]++
+		}
+		if starts[(codes[0]>>shift)&0xff+1] == n {
+			// every code has the same byte so there is nothing to move
+			continue
+		}
+		for i := 1; i < len(starts); i++ {
+			starts[i] += starts[i-1]
+		}
+
+		for i, c := range codes {
+			b := (c >> shift) & 0xff
+			toCodes[starts[b]], toPoints[starts[b]] = c, points[i]
+			starts[b]++
+		}
+		codes, toCodes = toCodes, codes
+		points, toPoints = toPoints, points
+	}
+
+	// keep the sorted lists, using the others for the next sort
+	l.codes, l.scratchCodes = codes, toCodes
+	l.points, l.scratchPoints = points, toPoints
+}
@@ -0,0 +1,240 @@
+// Copyright 2019 Tskken. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package quadgo
+
+import (
+	"math/rand"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// checkLinearTree checks that the codes of the tree are sorted and match its points.
+func checkLinearTree(t *testing.T, l *LinearTree) {
+	t.Helper()
+
+	if len(l.codes) != len(l.points) {
+		t.Fatalf("LinearTree has %v codes for %v points", len(l.codes), len(l.points))
+	}
+	if !sort.SliceIsSorted(l.codes, func(i, j int) bool { return l.codes[i] < l.codes[j] }) {
+		t.Errorf("LinearTree codes are not sorted")
+	}
+	for i, p := range l.points {
+		if l.codes[i] != l.code(p) {
+			t.Errorf("LinearTree code of %v = %v, want %v", p, l.codes[i], l.code(p))
+		}
+	}
+}
+
+func Test_morton(t *testing.T) {
+	tests := []struct {
+		name string
+		x, y uint32
+		want uint32
+	}{
+		{name: "origin", x: 0, y: 0, want: 0},
+		{name: "x", x: 1, y: 0, want: 1},
+		{name: "y", x: 0, y: 1, want: 2},
+		{name: "both", x: 3, y: 3, want: 15},
+		{name: "spread", x: 5, y: 2, want: 0x19},
+		{name: "max", x: 0xffff, y: 0xffff, want: 0xffffffff},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := morton(tt.x, tt.y); got != tt.want {
+				t.Errorf("quadgo.morton() = %#x, want %#x", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLinearTree_code(t *testing.T) {
+	l := NewLinearTree(1000, 1000)
+
+	// the four quarters of the tree have codes in order top left, top right, bottom left and
+	// then bottom right
+	quarters := []Point{{250, 250}, {750, 250}, {250, 750}, {750, 750}}
+	for i, p := range quarters {
+		if got := l.code(p) >> 30; got != uint32(i) {
+			t.Errorf("LinearTree.code(%v) is in quarter %v, want %v", p, got, i)
+		}
+	}
+
+	// points outside of the tree are clamped to its edge
+	if got, want := l.code(Point{-50, 2000}), l.code(Point{0, 1000}); got != want {
+		t.Errorf("LinearTree.code() outside the tree = %#x, want %#x", got, want)
+	}
+}
+
+func TestLinearTree_InsertPoints(t *testing.T) {
+	points := randomPoints(1000, 1000, 1000)
+
+	l := NewLinearTree(1000, 1000)
+	if err := l.InsertPoints(); err == nil {
+		t.Errorf("LinearTree.InsertPoints() with no points got no error")
+	}
+
+	l.InsertPoints(points[:500]...)
+	for _, p := range points[500:] {
+		l.InsertPoint(p)
+	}
+	checkLinearTree(t, l)
+
+	if l.Len() != len(points) {
+		t.Errorf("LinearTree.Len() = %v, want %v", l.Len(), len(points))
+	}
+
+	// the tree is the same however the points were inserted
+	built := BuildLinearTree(1000, 1000, points)
+	if !reflect.DeepEqual(l.codes, built.codes) || !reflect.DeepEqual(sortPoints(l.Points(l.Bounds())), sortPoints(built.Points(built.Bounds()))) {
+		t.Errorf("LinearTree.InsertPoint() gave a different tree to quadgo.BuildLinearTree()")
+	}
+
+	l.Clear()
+	if l.Len() != 0 || cap(l.points) < len(points) {
+		t.Errorf("LinearTree.Clear() = %v points with room for %v, want 0 with room for %v", l.Len(), cap(l.points), len(points))
+	}
+}
+
+func TestLinearTree_RemovePoint(t *testing.T) {
+	points := randomPoints(300, 1000, 1000)
+	l := BuildLinearTree(1000, 1000, points)
+
+	order := rand.New(rand.NewSource(4)).Perm(len(points))
+	for _, j := range order {
+		if !l.HasPoint(points[j]) {
+			t.Errorf("LinearTree.HasPoint(%v) = false, want true", points[j])
+		}
+		if err := l.RemovePoint(points[j]); err != nil {
+			t.Fatalf("LinearTree.RemovePoint() got error %v", err)
+		}
+		if l.HasPoint(points[j]) {
+			t.Errorf("LinearTree.HasPoint() after removing %v = true", points[j])
+		}
+	}
+	checkLinearTree(t, l)
+
+	if l.Len() != 0 {
+		t.Errorf("LinearTree after removing every point has %v points", l.Len())
+	}
+	if err := l.RemovePoint(points[0]); err == nil {
+		t.Errorf("LinearTree.RemovePoint() on empty tree got no error")
+	}
+}
+
+func TestLinearTree_Points(t *testing.T) {
+	points := randomPoints(2000, 1000, 1000)
+
+	// points on the edges of the query bounds, and outside of the tree
+	points = append(points, Point{100, 100}, Point{200, 150}, Point{150, 200}, Point{-20, 500}, Point{1500, 1500}, Point{500, -1})
+	l := BuildLinearTree(1000, 1000, points, SetMaxEntities(4))
+
+	bounds := append(randomBounds(100, 1000, 1000),
+		NewBound(0, 0, 10, 10),
+		NewBound(100, 100, 200, 200),
+		NewBound(-50, 400, -10, 600),
+		NewBound(1400, 1400, 1600, 1600),
+		NewBound(-1000, -1000, 2000, 2000),
+	)
+	for _, bound := range bounds {
+		var want []Point
+		for _, point := range points {
+			if bound.containsPoint(point) {
+				want = append(want, point)
+			}
+		}
+
+		if got := l.Points(bound); !reflect.DeepEqual(sortPoints(got), sortPoints(want)) {
+			t.Errorf("LinearTree.Points(%v) = %v points, want %v", bound, len(got), len(want))
+		}
+	}
+
+	count := 0
+	l.QueryFunc(NewBound(0, 0, 1000, 1000), func(Point) bool {
+		count++
+		return count < 3
+	})
+	if count != 3 {
+		t.Errorf("LinearTree.QueryFunc() stopped after %v points, want 3", count)
+	}
+}
+
+func TestLinearTree_Radius(t *testing.T) {
+	points := randomPoints(1000, 1000, 1000)
+	l := BuildLinearTree(1000, 1000, points, SetMaxEntities(8))
+
+	tests := []struct {
+		name   string
+		center Point
+		radius float64
+	}{
+		{name: "bunched corner", center: Point{25, 25}, radius: 10},
+		{name: "middle", center: Point{500, 500}, radius: 100},
+		{name: "outside", center: Point{-100, -100}, radius: 150},
+		{name: "everything", center: Point{500, 500}, radius: 1000},
+		{name: "zero radius", center: points[0], radius: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var want []Point
+			for _, point := range points {
+				if d := sub(point, tt.center); dot(d, d) <= tt.radius*tt.radius {
+					want = append(want, point)
+				}
+			}
+
+			if got := l.Radius(tt.center, tt.radius); !reflect.DeepEqual(sortPoints(got), sortPoints(want)) {
+				t.Errorf("LinearTree.Radius() = %v points, want %v", len(got), len(want))
+			}
+		})
+	}
+}
+
+// BenchmarkLinearTree compares filling and querying a LinearTree with a PointTree and
+// QuadGo for a large simulation of points.
+func BenchmarkLinearTree(b *testing.B) {
+	points := randomPoints(100000, 1000, 1000)
+	bounds := randomBounds(100, 1000, 1000)
+
+	entities := make(Entities, len(points))
+	for i, point := range points {
+		entities[i] = &Entity{ID: uint64(i), Bound: NewBound(point.X, point.Y, point.X, point.Y)}
+	}
+
+	l := BuildLinearTree(1000, 1000, points, SetMaxEntities(64))
+	p := BuildPointTree(points, SetMaxEntities(32), SetMaxDepth(16))
+	q := Build(1000, 1000, entities, SetMaxEntities(32), SetMaxDepth(10))
+
+	b.Run("Build/LinearTree", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			l.Clear()
+			l.InsertPoints(points...)
+		}
+	})
+	b.Run("Build/PointTree", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			BuildPointTree(points, SetMaxEntities(32), SetMaxDepth(16))
+		}
+	})
+
+	queries := []struct {
+		name  string
+		query func(Bound)
+	}{
+		{name: "LinearTree", query: func(bound Bound) { l.QueryFunc(bound, func(Point) bool { return true }) }},
+		{name: "PointTree", query: func(bound Bound) { p.QueryFunc(bound, func(Point) bool { return true }) }},
+		{name: "QuadGo", query: func(bound Bound) { q.QueryFunc(bound, func(*Entity) bool { return true }) }},
+	}
+	for _, query := range queries {
+		b.Run("Query/"+query.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				query.query(bounds[i%len(bounds)])
+			}
+		})
+	}
+}
@@ -0,0 +1,11 @@
+// Copyright 2019 Tskken. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+//go:build !quadgodebug
+// +build !quadgodebug
+
+package quadgo
+
+// debug is set by the quadgodebug build tag to validate trees after every change.
+const debug = false
@@ -0,0 +1,133 @@
+// Copyright 2019 Tskken. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package quadgo
+
+import (
+	"fmt"
+	"sync/atomic"
+)
+
+// Observer is told about the work a tree does, for collecting metrics on how a tree is used.
+// An Observer is set on a tree with the SetObserver Option.
+//
+// The methods of an Observer are called on the go routine doing the work. As the read
+// functions of a tree run on their own go routines an Observer has to be safe to call from
+// many go routines at once. The methods are called in the middle of the work so they
+// should return quickly.
+type Observer interface {
+	// Inserted is called after an entity is inserted in to the tree.
+	Inserted(entity *Entity)
+	// Removed is called after an entity is removed from the tree.
+	Removed(entity *Entity)
+	// Split is called after a leaf node is split in to four children.
+	Split(node NodeInfo)
+	// Collapsed is called after the children of a node are collapsed back in to it.
+	Collapsed(node NodeInfo)
+	// Queried is called after IsIntersect(), Intersects() or QueryFunc() with the bound
+	// queried and how much work the query took.
+	Queried(bound Bound, stats QueryStats)
+}
+
+// QueryStats holds how much work a query took.
+type QueryStats struct {
+	// Nodes is the number of nodes visited by the query.
+	Nodes int
+	// Entities is the number of entities tested against the bound of the query.
+	Entities int
+	// Hits is the number of entities given as results of the query.
+	Hits int
+}
+
+// Counters is an Observer that keeps a total count of the work done by a tree.
+//
+// Counters is safe to use from many go routines and can be shared by many trees. It
+// implements expvar.Var so it can be published to show its counts at /debug/vars.
+//
+//  counters := new(quadgo.Counters)
+//  expvar.Publish("quadgo", counters)
+//  tree := quadgo.New(800, 600, quadgo.SetObserver(counters))
+type Counters struct {
+	inserts        int64
+	removes        int64
+	splits         int64
+	collapses      int64
+	queries        int64
+	nodesVisited   int64
+	entitiesTested int64
+	hits           int64
+}
+
+// Inserted counts an insert.
+func (c *Counters) Inserted(*Entity) {
+	atomic.AddInt64(&c.inserts, 1)
+}
+
+// Removed counts a remove.
+func (c *Counters) Removed(*Entity) {
+	atomic.AddInt64(&c.removes, 1)
+}
+
+// Split counts a split.
+func (c *Counters) Split(NodeInfo) {
+	atomic.AddInt64(&c.splits, 1)
+}
+
+// Collapsed counts a collapse.
+func (c *Counters) Collapsed(NodeInfo) {
+	atomic.AddInt64(&c.collapses, 1)
+}
+
+// Queried counts a query and the work it took.
+func (c *Counters) Queried(_ Bound, stats QueryStats) {
+	atomic.AddInt64(&c.queries, 1)
+	atomic.AddInt64(&c.nodesVisited, int64(stats.Nodes))
+	atomic.AddInt64(&c.entitiesTested, int64(stats.Entities))
+	atomic.AddInt64(&c.hits, int64(stats.Hits))
+}
+
+// CounterValues is a copy of the counts of a Counters at one point in time.
+type CounterValues struct {
+	Inserts        int64 `json:"inserts"`
+	Removes        int64 `json:"removes"`
+	Splits         int64 `json:"splits"`
+	Collapses      int64 `json:"collapses"`
+	Queries        int64 `json:"queries"`
+	NodesVisited   int64 `json:"nodesVisited"`
+	EntitiesTested int64 `json:"entitiesTested"`
+	Hits           int64 `json:"hits"`
+}
+
+// Values returns the current counts.
+func (c *Counters) Values() CounterValues {
+	return CounterValues{
+		Inserts:        atomic.LoadInt64(&c.inserts),
+		Removes:        atomic.LoadInt64(&c.removes),
+		Splits:         atomic.LoadInt64(&c.splits),
+		Collapses:      atomic.LoadInt64(&c.collapses),
+		Queries:        atomic.LoadInt64(&c.queries),
+		NodesVisited:   atomic.LoadInt64(&c.nodesVisited),
+		EntitiesTested: atomic.LoadInt64(&c.entitiesTested),
+		Hits:           atomic.LoadInt64(&c.hits),
+	}
+}
+
+// Reset sets all counts back to zero.
+func (c *Counters) Reset() {
+	atomic.StoreInt64(&c.inserts, 0)
+	atomic.StoreInt64(&c.removes, 0)
+	atomic.StoreInt64(&c.splits, 0)
+	atomic.StoreInt64(&c.collapses, 0)
+	atomic.StoreInt64(&c.queries, 0)
+	atomic.StoreInt64(&c.nodesVisited, 0)
+	atomic.StoreInt64(&c.entitiesTested, 0)
+	atomic.StoreInt64(&c.hits, 0)
+}
+
+// String returns the current counts as a JSON object, as expected by expvar.Var.
+func (c *Counters) String() string {
+	v := c.Values()
+	return fmt.Sprintf(`{"inserts": %v, "removes": %v, "splits": %v, "collapses": %v, "queries": %v, "nodesVisited": %v, "entitiesTested": %v, "hits": %v}`,
+		v.Inserts, v.Removes, v.Splits, v.Collapses, v.Queries, v.NodesVisited, v.EntitiesTested, v.Hits)
+}
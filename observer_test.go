@@ -0,0 +1,220 @@
+// Copyright 2019 Tskken. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package quadgo
+
+import (
+	"encoding/json"
+	"expvar"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+// recorder is an Observer that records every call made to it.
+type recorder struct {
+	mu     sync.Mutex
+	events []string
+	stats  []QueryStats
+	splits []NodeInfo
+}
+
+func (r *recorder) add(event string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event)
+}
+
+func (r *recorder) Inserted(*Entity)   { r.add("insert") }
+func (r *recorder) Removed(*Entity)    { r.add("remove") }
+func (r *recorder) Collapsed(NodeInfo) { r.add("collapse") }
+
+func (r *recorder) Split(info NodeInfo) {
+	r.add("split")
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.splits = append(r.splits, info)
+}
+
+func (r *recorder) Queried(_ Bound, stats QueryStats) {
+	r.add("query")
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.stats = append(r.stats, stats)
+}
+
+func TestSetObserver(t *testing.T) {
+	entities := Entities{
+		{ID: 1, Bound: NewBound(0, 0, 10, 10)},
+		{ID: 2, Bound: NewBound(60, 0, 70, 10)},
+		{ID: 3, Bound: NewBound(0, 60, 10, 70)},
+	}
+
+	tests := []struct {
+		name      string
+		run       func(q *QuadGo)
+		want      []string
+		wantStats []QueryStats
+	}{
+		{
+			name: "insert with split",
+			run: func(q *QuadGo) {
+				q.InsertEntities(entities...)
+			},
+			want: []string{"insert", "insert", "split", "insert"},
+		},
+		{
+			name: "remove with collapse",
+			run: func(q *QuadGo) {
+				q.InsertEntities(entities...)
+				q.Remove(entities[2])
+			},
+			want: []string{"insert", "insert", "split", "insert", "collapse", "remove"},
+		},
+		{
+			name: "insert same entity twice",
+			run: func(q *QuadGo) {
+				q.InsertEntities(entities[0], entities[0])
+			},
+			want: []string{"insert"},
+		},
+		{
+			name: "clear",
+			run: func(q *QuadGo) {
+				q.InsertEntities(entities...)
+				q.Clear()
+			},
+			want: []string{"insert", "insert", "split", "insert", "remove", "remove", "remove"},
+		},
+		{
+			name: "failed remove",
+			run: func(q *QuadGo) {
+				q.Remove(entities[0])
+			},
+		},
+		{
+			name: "queries",
+			run: func(q *QuadGo) {
+				q.InsertEntities(entities...)
+				<-q.Intersects(NewBound(0, 0, 100, 40))
+				<-q.IsIntersect(NewBound(0, 0, 5, 5))
+				q.QueryFunc(NewBound(80, 80, 90, 90), func(*Entity) bool { return true })
+			},
+			want: []string{"insert", "insert", "split", "insert", "query", "query", "query"},
+			wantStats: []QueryStats{
+				{Nodes: 3, Entities: 2, Hits: 2},
+				{Nodes: 2, Entities: 1, Hits: 1},
+				{Nodes: 2, Entities: 0, Hits: 0},
+			},
+		},
+		{
+			name: "build",
+			run: func(q *QuadGo) {
+				q.load(entities)
+			},
+			want: []string{"split", "insert", "insert", "insert"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &recorder{}
+			q := New(100, 100, SetMaxEntities(2), SetObserver(r))
+
+			tt.run(q)
+
+			if !reflect.DeepEqual(r.events, tt.want) {
+				t.Errorf("Observer events = %v, want %v", r.events, tt.want)
+			}
+			if !reflect.DeepEqual(r.stats, tt.wantStats) {
+				t.Errorf("Observer query stats = %v, want %v", r.stats, tt.wantStats)
+			}
+		})
+	}
+}
+
+func TestSetObserver_split(t *testing.T) {
+	entities := randomEntities(50, 800, 600)
+
+	tests := []struct {
+		name  string
+		build func(r *recorder)
+	}{
+		{
+			name: "insert",
+			build: func(r *recorder) {
+				New(800, 600, SetMaxEntities(2), SetObserver(r)).InsertEntities(entities...)
+			},
+		},
+		{
+			name: "build",
+			build: func(r *recorder) {
+				Build(800, 600, entities, SetMaxEntities(2), SetObserver(r))
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &recorder{}
+			tt.build(r)
+
+			// a split node is told as the branch node it became
+			if len(r.splits) == 0 {
+				t.Fatalf("Observer.Split() was not called")
+			}
+			for _, info := range r.splits {
+				if info.Leaf || info.Entities != 0 {
+					t.Errorf("Observer.Split() NodeInfo = %+v, want a branch node holding 0 entities", info)
+				}
+			}
+		})
+	}
+}
+
+func TestCounters(t *testing.T) {
+	counters := new(Counters)
+	q := New(100, 100, SetMaxEntities(2), SetObserver(counters))
+
+	entities := randomEntities(50, 100, 100)
+	q.InsertEntities(entities...)
+	for _, e := range entities[:40] {
+		q.Remove(e)
+	}
+
+	// run queries from many go routines at once
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-q.Intersects(NewBound(0, 0, 100, 100))
+		}()
+	}
+	wg.Wait()
+
+	got := counters.Values()
+	if got.Inserts != 50 || got.Removes != 40 || got.Queries != 8 {
+		t.Errorf("Counters.Values() = %+v, want 50 inserts, 40 removes and 8 queries", got)
+	}
+	if got.Splits == 0 || got.Collapses == 0 {
+		t.Errorf("Counters.Values() = %+v, want splits and collapses", got)
+	}
+	if got.Hits != 8*10 || got.NodesVisited == 0 || got.EntitiesTested < got.Hits {
+		t.Errorf("Counters.Values() = %+v, want 80 hits", got)
+	}
+
+	// check the counters are a valid expvar
+	var v expvar.Var = counters
+	var decoded CounterValues
+	if err := json.Unmarshal([]byte(v.String()), &decoded); err != nil {
+		t.Fatalf("Counters.String() = %v, not valid JSON: %v", v.String(), err)
+	}
+	if decoded != got {
+		t.Errorf("Counters.String() = %+v, want %+v", decoded, got)
+	}
+
+	counters.Reset()
+	if got := counters.Values(); got != (CounterValues{}) {
+		t.Errorf("Counters.Reset() = %+v, want all zero", got)
+	}
+}
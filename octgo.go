@@ -0,0 +1,487 @@
+// Copyright 2019 Tskken. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package quadgo
+
+import (
+	"errors"
+	"math"
+)
+
+// OctGo - Base octree data structure.
+//
+// OctGo is the 3D counterpart of QuadGo, splitting each node in to eight children instead of
+// four, and holds Entity3's with Bound3 bounds. It takes the same Option functions as QuadGo
+// and its functions work the same as those of QuadGo. SetPayloadCodec and SetObserver have no
+// effect on an OctGo.
+type OctGo struct {
+	*octNode
+
+	maxEntities uint64
+	maxDepth    uint16
+
+	// size is the number of entities inserted in to the tree
+	size int
+}
+
+// NewOctGo creates the basic OctGo instance.
+//
+// NewOctGo requires a width, height and depth but can also be given any number of other
+// supported Option functions, with the same defaults as New.
+//
+// Example:
+//  basic - quadgo.NewOctGo(800, 600, 400)
+//  with option - quadgo.NewOctGo(800, 600, 400, SetMaxDepth(5))
+func NewOctGo(width, height, depth float64, ops ...Option) *OctGo {
+	return NewOctGoWithBound(NewBound3(0, 0, 0, width, height, depth), ops...)
+}
+
+// NewOctGoWithBound creates the basic OctGo instance covering the given bound.
+func NewOctGoWithBound(bound Bound3, ops ...Option) *OctGo {
+	// copy defaults
+	o := defaultOption
+
+	// update for any given options
+	for _, op := range ops {
+		op(&o)
+	}
+
+	return &OctGo{
+		octNode: &octNode{
+			parent:   nil,
+			bound:    bound,
+			entities: make(Entities3, 0, o.MaxEntities),
+			children: make(octNodes, 0, 8),
+			depth:    0,
+		},
+		maxEntities: o.MaxEntities,
+		maxDepth:    o.MaxDepth,
+	}
+}
+
+// Insert takes the desired min and max xyz points for the inserted entity.
+//
+// Like QuadGo.Insert(), the entity is inserted in to all leaf nodes that its bounds intersect.
+// Insert panics if the bounds are outside of the tree, as they would not be in any node.
+func (o *OctGo) Insert(minX, minY, minZ, maxX, maxY, maxZ float64) {
+	if err := o.insertEntity(NewEntity3(minX, minY, minZ, maxX, maxY, maxZ)); err != nil {
+		panic(err)
+	}
+}
+
+// InsertWithAction takes the desired min and max xyz points for the inserted entity and an Action function.
+//
+// InsertWithAction panics if the bounds are outside of the tree, the same as Insert().
+func (o *OctGo) InsertWithAction(minX, minY, minZ, maxX, maxY, maxZ float64, action Action) {
+	if err := o.insertEntity(NewEntity3WithAction(minX, minY, minZ, maxX, maxY, maxZ, action)); err != nil {
+		panic(err)
+	}
+}
+
+// InsertEntities inserts any number of entities in the octree. Any entities equal to
+// one already in the tree are skipped.
+//
+// This will return an error if you do not give it any entities, or if any of them are
+// outside of the tree in which case none of them are inserted.
+func (o *OctGo) InsertEntities(entities ...*Entity3) error {
+	if len(entities) == 0 {
+		return errors.New("no entities given to OctGo.InsertEntities()")
+	}
+
+	for _, e := range entities {
+		if err := o.outside(e); err != nil {
+			return err
+		}
+	}
+	for _, e := range entities {
+		o.insertEntity(e)
+	}
+	return nil
+}
+
+// insertEntity inserts the given entity in to the tree.
+//
+// This will return an error if the entity is outside of the tree.
+func (o *OctGo) insertEntity(entity *Entity3) error {
+	if err := o.outside(entity); err != nil {
+		return err
+	}
+
+	if o.insert(entity, o.maxDepth) {
+		o.size++
+	}
+	return nil
+}
+
+// outside returns an error if the given entity is outside of the tree.
+func (o *OctGo) outside(entity *Entity3) error {
+	if !o.bound.IsIntersect(entity.Bound3) {
+		return outsideError(entity.ID, entity.Min, entity.Max, o.bound.Min, o.bound.Max)
+	}
+	return nil
+}
+
+// Remove removes the given Entity3 from the octree.
+//
+// The given entity has to have the same ID and Bounds as the one to remove. This will return
+// an error if the entity given was not found in the octree.
+func (o *OctGo) Remove(entity *Entity3) error {
+	if err := o.remove(entity); err != nil {
+		return err
+	}
+
+	o.size--
+	return nil
+}
+
+// Clear removes all entities from the tree, resetting it to an empty root node
+// with the same bounds and options it was created with.
+func (o *OctGo) Clear() {
+	o.octNode = &octNode{
+		parent:   nil,
+		bound:    o.bound,
+		entities: make(Entities3, 0, o.maxEntities),
+		children: make(octNodes, 0, 8),
+		depth:    0,
+	}
+	o.size = 0
+}
+
+// Len returns the number of entities in the tree, counting entities stored in more then
+// one leaf node and entities inserted more then once only once.
+func (o *OctGo) Len() int {
+	return o.size
+}
+
+// Bounds returns the bounds of the tree as given at creation.
+func (o *OctGo) Bounds() Bound3 {
+	return o.bound
+}
+
+// Retrieve returns all entities from all nodes the given bounds intersects with.
+// Retrieve excludes duplected entities. See QuadGo.Retrieve().
+func (o *OctGo) Retrieve(bound Bound3) <-chan Entities3 {
+	out := make(chan Entities3)
+
+	go func() {
+		out <- o.retrieve(bound)
+		close(out)
+	}()
+
+	return out
+}
+
+// IsEntity checks if a given entity exists within the tree. See QuadGo.IsEntity().
+func (o *OctGo) IsEntity(entity *Entity3) <-chan bool {
+	out := make(chan bool)
+
+	go func() {
+		out <- o.isEntity(entity)
+		close(out)
+	}()
+
+	return out
+}
+
+// IsIntersect take a bound and returns if that bound intersects any entity within the tree.
+// See QuadGo.IsIntersect().
+func (o *OctGo) IsIntersect(bound Bound3) <-chan bool {
+	out := make(chan bool)
+
+	go func() {
+		hit := false
+		o.query(bound, o.bound, func(*Entity3) bool {
+			hit = true
+			return false
+		})
+		out <- hit
+		close(out)
+	}()
+
+	return out
+}
+
+// Intersects takes a bound and returns all entities that the given bound intersects with.
+// See QuadGo.Intersects().
+func (o *OctGo) Intersects(bound Bound3) <-chan Entities3 {
+	out := make(chan Entities3)
+
+	go func() {
+		var entities Entities3
+		o.query(bound, o.bound, func(e *Entity3) bool {
+			entities = append(entities, e)
+			return true
+		})
+		out <- entities
+		close(out)
+	}()
+
+	return out
+}
+
+// QueryFunc calls the given function for every entity that the given bound intersects with,
+// stopping if the function returns false. See QuadGo.QueryFunc().
+func (o *OctGo) QueryFunc(bound Bound3, fn func(*Entity3) bool) {
+	o.query(bound, o.bound, fn)
+}
+
+// All returns every entity with in the tree. See QuadGo.All().
+func (o *OctGo) All() <-chan Entities3 {
+	out := make(chan Entities3)
+
+	go func() {
+		entities := make(Entities3, 0, o.size)
+		o.forEach(o.bound, func(e *Entity3) bool {
+			entities = append(entities, e)
+			return true
+		})
+		out <- entities
+		close(out)
+	}()
+
+	return out
+}
+
+// ForEach calls the given function for every entity with in the tree, stopping if the
+// function returns false. See QuadGo.ForEach().
+func (o *OctGo) ForEach(fn func(*Entity3) bool) {
+	o.forEach(o.bound, fn)
+}
+
+// list of octree nodes
+type octNodes []*octNode
+
+// octNode is the container that holds the branch and leaf data for the octree.
+type octNode struct {
+	parent   *octNode
+	bound    Bound3
+	entities Entities3
+	children octNodes
+	depth    uint16
+}
+
+// new creates a new node instance for a given bounds taking the member node as its parent.
+func (n *octNode) new(bound Bound3) *octNode {
+	return &octNode{
+		parent:   n,
+		bound:    bound,
+		entities: make(Entities3, 0, cap(n.entities)),
+		children: make(octNodes, 0, 8),
+		depth:    n.depth + 1,
+	}
+}
+
+// retrieve finds all of the entities with in the leaf nodes that the given bound intersects.
+func (n *octNode) retrieve(bound Bound3) (entities Entities3) {
+	if len(n.children) == 0 {
+		return n.entities
+	}
+
+	eachChild(len(n.children), func(i int) bool {
+		return n.children[i].bound.IsIntersect(bound)
+	}, func(i int) error {
+		for _, e := range n.children[i].retrieve(bound) {
+			if !entities.Contains(e) {
+				entities = append(entities, e)
+			}
+		}
+		return nil
+	})
+	return
+}
+
+// insert inserts a given entity in to the octree, returning if it was inserted. The same as
+// node.insert(), an entity equal to one already in the tree is not inserted again.
+func (n *octNode) insert(entity *Entity3, maxDepth uint16) bool {
+	if len(n.children) > 0 {
+		inserted := false
+		err := eachChild(len(n.children), func(i int) bool {
+			return n.children[i].bound.IsIntersect(entity.Bound3)
+		}, func(i int) error {
+			if n.children[i].insert(entity, maxDepth) {
+				inserted = true
+			}
+			return nil
+		})
+		// OctGo.insertEntity() only inserts entities with in the tree, so this should never happen
+		if err != nil {
+			panic(errors.New("could not find a node to insert in to from octNode.insert()"))
+		}
+		return inserted
+	}
+
+	if n.entities.Contains(entity) {
+		return false
+	}
+
+	// check if a split is needed
+	if overfull(len(n.entities)+1, cap(n.entities), n.depth, maxDepth) {
+		n.split()
+
+		// move this nodes entities to the children nodes and then insert the new entity in to them,
+		// clearing them without leaving them in the unused list to be kept alive
+		for i, e := range n.entities {
+			n.insert(e, maxDepth)
+			n.entities[i] = nil
+		}
+		n.entities = n.entities[:0]
+		return n.insert(entity, maxDepth)
+	}
+
+	n.entities = append(n.entities, entity)
+	return true
+}
+
+// remove removes the given entity from the octree, collapsing any nodes that no longer need
+// to be split.
+//
+// This will return an error if the entity is not in the tree, including when it is outside
+// of the tree and so in no node.
+func (n *octNode) remove(entity *Entity3) error {
+	if len(n.children) > 0 {
+		err := eachChild(len(n.children), func(i int) bool {
+			return n.children[i].bound.IsIntersect(entity.Bound3)
+		}, func(i int) error {
+			return n.children[i].remove(entity)
+		})
+		if err == errNoChild {
+			return errors.New("could not find entity in tree to remove")
+		}
+		if err != nil {
+			return err
+		}
+
+		n.collapse()
+		return nil
+	}
+
+	entities, err := n.entities.FindAndRemove(entity)
+	if err != nil {
+		return err
+	}
+	n.entities = entities
+
+	return nil
+}
+
+// collapse moves the entities of the children nodes back in to this node and removes the
+// children if the node is collapsible(), the same as node.collapse().
+func (n *octNode) collapse() {
+	if !n.collapsible() {
+		return
+	}
+
+	entities := n.entities[:0]
+	for _, child := range n.children {
+		for _, e := range child.entities {
+			if !entities.Contains(e) {
+				entities = append(entities, e)
+			}
+		}
+	}
+	n.entities = entities
+
+	for i := range n.children {
+		n.children[i] = nil
+	}
+	n.children = n.children[:0]
+}
+
+// collapsible returns if all of the nodes children are leaf nodes that together hold no
+// more then the max entities of a leaf, using the collapsible() rule shared with QuadGo.
+func (n *octNode) collapsible() bool {
+	return collapsible(len(n.children), cap(n.entities), func(i int) int {
+		if len(n.children[i].children) > 0 {
+			return -1
+		}
+		return len(n.children[i].entities)
+	}, func(i, e int) bool {
+		return n.children[:i].hold(n.children[i].entities[e])
+	})
+}
+
+// hold returns if any of the nodes hold the given entity.
+func (n octNodes) hold(entity *Entity3) bool {
+	for i := range n {
+		if n[i].entities.Contains(entity) {
+			return true
+		}
+	}
+	return false
+}
+
+// isEntity returns if a given entity exists in the tree.
+func (n *octNode) isEntity(entity *Entity3) bool {
+	if len(n.children) == 0 {
+		return n.entities.Contains(entity)
+	}
+
+	// stop at the first child holding the entity
+	return !eachChildWhile(len(n.children), func(i int) bool {
+		return n.children[i].bound.IsIntersect(entity.Bound3)
+	}, func(i int) bool {
+		return !n.children[i].isEntity(entity)
+	})
+}
+
+// forEach calls the given function for each entity the leaf nodes own, returning false
+// if the function stopped the iteration. Entities are owned the same as node.forEach().
+func (n *octNode) forEach(world Bound3, fn func(*Entity3) bool) bool {
+	if len(n.children) > 0 {
+		return eachChildWhile(len(n.children), nil, func(i int) bool {
+			return n.children[i].forEach(world, fn)
+		})
+	}
+
+	for _, e := range n.entities {
+		if n.owns(world.clamp(e.Min), world) && !fn(e) {
+			return false
+		}
+	}
+	return true
+}
+
+// query calls the given function for each entity the given bound intersects with in the
+// leaf nodes the bound intersects, returning false if the function stopped the query.
+// Entities are only given once the same as node.query().
+func (n *octNode) query(bound, world Bound3, fn func(*Entity3) bool) bool {
+	if len(n.children) > 0 {
+		return eachChildWhile(len(n.children), func(i int) bool {
+			return n.children[i].bound.IsIntersect(bound)
+		}, func(i int) bool {
+			return n.children[i].query(bound, world, fn)
+		})
+	}
+
+	for _, e := range n.entities {
+		if !e.IsIntersect(bound) {
+			continue
+		}
+
+		p := world.clamp(Point3{
+			X: math.Max(e.Min.X, bound.Min.X),
+			Y: math.Max(e.Min.Y, bound.Min.Y),
+			Z: math.Max(e.Min.Z, bound.Min.Z),
+		})
+		if n.owns(p, world) && !fn(e) {
+			return false
+		}
+	}
+	return true
+}
+
+// owns returns if the given point with in the tree bounds falls with in this node, with the
+// max edges of the node exclusive unless they are also the max edges of the tree.
+func (n *octNode) owns(p Point3, world Bound3) bool {
+	return owned(p.X, n.bound.Min.X, n.bound.Max.X, world.Max.X) &&
+		owned(p.Y, n.bound.Min.Y, n.bound.Max.Y, world.Max.Y) &&
+		owned(p.Z, n.bound.Min.Z, n.bound.Max.Z, world.Max.Z)
+}
+
+// split creates the children node for this node.
+func (n *octNode) split() {
+	for _, bound := range n.bound.octants() {
+		n.children = append(n.children, n.new(bound))
+	}
+}
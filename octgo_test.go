@@ -0,0 +1,623 @@
+// Copyright 2019 Tskken. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package quadgo
+
+import (
+	"errors"
+	"math/rand"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// randomEntities3 returns n entities with random bounds up to 20 wide, high and deep with in
+// the given size, using a fixed seed so every run gets the same entities.
+func randomEntities3(n int, width, height, depth float64) Entities3 {
+	r := rand.New(rand.NewSource(1))
+
+	entities := make(Entities3, 0, n)
+	for i := 0; i < n; i++ {
+		x, y, z := r.Float64()*(width-20), r.Float64()*(height-20), r.Float64()*(depth-20)
+		entities = append(entities, &Entity3{
+			ID:     uint64(i + 1),
+			Bound3: NewBound3(x, y, z, x+r.Float64()*20, y+r.Float64()*20, z+r.Float64()*20),
+		})
+	}
+
+	return entities
+}
+
+// entity3IDs returns the sorted IDs of the given entities.
+func entity3IDs(entities Entities3) []uint64 {
+	ids := make([]uint64, 0, len(entities))
+	for _, e := range entities {
+		ids = append(ids, e.ID)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+// checkOctParents checks that every node under the given node links to its parent and
+// has a depth of one more then its parent.
+func checkOctParents(t *testing.T, n *octNode) {
+	t.Helper()
+	for _, child := range n.children {
+		if child.parent != n {
+			t.Errorf("octNode at depth %v does not link to its parent", child.depth)
+		}
+		if child.depth != n.depth+1 {
+			t.Errorf("octNode.depth = %v, want %v", child.depth, n.depth+1)
+		}
+		checkOctParents(t, child)
+	}
+}
+
+func TestNewOctGo(t *testing.T) {
+	type args struct {
+		width, height, depth float64
+		ops                  []Option
+	}
+	tests := []struct {
+		name string
+		args args
+		want *OctGo
+	}{
+		{
+			name: "basic default new",
+			args: args{
+				width:  800,
+				height: 600,
+				depth:  400,
+			},
+			want: &OctGo{
+				octNode: &octNode{
+					parent:   nil,
+					bound:    NewBound3(0, 0, 0, 800, 600, 400),
+					entities: make(Entities3, 0, defaultOption.MaxEntities),
+					children: make(octNodes, 0, 8),
+					depth:    0,
+				},
+				maxEntities: defaultOption.MaxEntities,
+				maxDepth:    defaultOption.MaxDepth,
+			},
+		},
+		{
+			name: "new with options",
+			args: args{
+				width:  800,
+				height: 600,
+				depth:  400,
+				ops:    []Option{SetMaxEntities(3), SetMaxDepth(2)},
+			},
+			want: &OctGo{
+				octNode: &octNode{
+					parent:   nil,
+					bound:    NewBound3(0, 0, 0, 800, 600, 400),
+					entities: make(Entities3, 0, 3),
+					children: make(octNodes, 0, 8),
+					depth:    0,
+				},
+				maxEntities: 3,
+				maxDepth:    2,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := NewOctGo(tt.args.width, tt.args.height, tt.args.depth, tt.args.ops...)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("quadgo.NewOctGo() = %v, want %v", got, tt.want)
+			}
+			if cap(got.entities) != cap(tt.want.entities) {
+				t.Errorf("quadgo.NewOctGo() max entities = %v, want %v", cap(got.entities), cap(tt.want.entities))
+			}
+		})
+	}
+}
+
+func TestNewOctGoWithBound(t *testing.T) {
+	bound := NewBound3(-100, -50, -25, 100, 50, 25)
+	o := NewOctGoWithBound(bound, SetMaxEntities(1))
+
+	if !o.Bounds().IsEqual(bound) {
+		t.Errorf("OctGo.Bounds() = %v, want %v", o.Bounds(), bound)
+	}
+
+	o.Insert(-90, -40, -20, -80, -30, -10)
+	o.Insert(80, 30, 10, 90, 40, 20)
+	if len(o.children) != 8 {
+		t.Fatalf("OctGo children = %v, want 8", len(o.children))
+	}
+	if got := entity3IDs(<-o.Intersects(NewBound3(-100, -50, -25, 0, 0, 0))); len(got) != 1 {
+		t.Errorf("OctGo.Intersects() = %v, want 1 entity", got)
+	}
+}
+
+func TestOctGo_Insert(t *testing.T) {
+	type args struct {
+		minX, minY, minZ, maxX, maxY, maxZ float64
+	}
+	tests := []struct {
+		name         string
+		octgo        *OctGo
+		args         []args
+		wantChildren int
+		wantLen      int
+	}{
+		{
+			name:    "basic insert on empty list",
+			octgo:   NewOctGo(800, 600, 400),
+			args:    []args{{0, 0, 0, 50, 50, 50}},
+			wantLen: 1,
+		},
+		{
+			name:  "insert with a split",
+			octgo: NewOctGo(800, 600, 400, SetMaxEntities(2)),
+			args: []args{
+				{0, 0, 0, 50, 50, 50},
+				{20, 20, 20, 40, 40, 40},
+				{500, 400, 300, 550, 450, 350},
+			},
+			wantChildren: 8,
+			wantLen:      3,
+		},
+		{
+			name:  "insert at max depth",
+			octgo: NewOctGo(800, 600, 400, SetMaxEntities(1), SetMaxDepth(0)),
+			args: []args{
+				{0, 0, 0, 50, 50, 50},
+				{500, 400, 300, 550, 450, 350},
+			},
+			wantLen: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, a := range tt.args {
+				tt.octgo.Insert(a.minX, a.minY, a.minZ, a.maxX, a.maxY, a.maxZ)
+			}
+
+			if len(tt.octgo.children) != tt.wantChildren {
+				t.Errorf("OctGo.Insert() children = %v, want %v", len(tt.octgo.children), tt.wantChildren)
+			}
+			if tt.octgo.Len() != tt.wantLen {
+				t.Errorf("OctGo.Len() = %v, want %v", tt.octgo.Len(), tt.wantLen)
+			}
+			for _, a := range tt.args {
+				b := NewBound3(a.minX, a.minY, a.minZ, a.maxX, a.maxY, a.maxZ)
+				if !<-tt.octgo.IsIntersect(b) {
+					t.Errorf("OctGo.IsIntersect() = false for inserted bound %v", b)
+				}
+			}
+			checkOctParents(t, tt.octgo.octNode)
+		})
+	}
+}
+
+func TestOctGo_Insert_split(t *testing.T) {
+	o := NewOctGo(8, 8, 8, SetMaxEntities(1))
+
+	// one entity in the near top left octant and one in the far bottom right
+	near := &Entity3{ID: 1, Bound3: NewBound3(1, 1, 1, 2, 2, 2)}
+	far := &Entity3{ID: 2, Bound3: NewBound3(6, 6, 6, 7, 7, 7)}
+	o.InsertEntities(near, far)
+
+	if len(o.entities) != 0 {
+		t.Errorf("OctGo branch node entities = %v, want 0", len(o.entities))
+	}
+	for i, child := range o.children {
+		var want Entities3
+		switch i {
+		case 0:
+			want = Entities3{near}
+		case 7:
+			want = Entities3{far}
+		}
+		if len(child.entities) != len(want) || (len(want) > 0 && child.entities[0] != want[0]) {
+			t.Errorf("OctGo child %v entities = %v, want %v", i, child.entities, want)
+		}
+	}
+}
+
+func TestOctGo_Insert_same(t *testing.T) {
+	tests := []struct {
+		name string
+		ops  []Option
+	}{
+		{name: "insert same entity twice"},
+		{name: "insert same entity twice with split", ops: []Option{SetMaxEntities(2)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := NewOctGo(800, 600, 400, tt.ops...)
+			entities := randomEntities3(5, 800, 600, 400)
+			o.InsertEntities(entities...)
+			o.InsertEntities(entities[0], entities[0])
+
+			if o.Len() != len(entities) {
+				t.Errorf("OctGo.Len() = %v, want %v", o.Len(), len(entities))
+			}
+			if got := len(<-o.All()); got != len(entities) {
+				t.Errorf("OctGo.All() = %v entities, want %v", got, len(entities))
+			}
+
+			// removing the entity once removes it from every leaf
+			if err := o.Remove(entities[0]); err != nil {
+				t.Fatalf("OctGo.Remove() got error %v", err)
+			}
+			if <-o.IsEntity(entities[0]) {
+				t.Errorf("OctGo.IsEntity() = true for removed entity")
+			}
+		})
+	}
+}
+
+func TestOctGo_Insert_outside(t *testing.T) {
+	outside := &Entity3{ID: 100, Bound3: NewBound3(900, 700, 500, 950, 750, 550)}
+
+	tests := []struct {
+		name     string
+		entities Entities3
+	}{
+		{name: "empty tree", entities: nil},
+		{name: "split tree", entities: randomEntities3(20, 800, 600, 400)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := NewOctGo(800, 600, 400, SetMaxEntities(2))
+			if len(tt.entities) > 0 {
+				o.InsertEntities(tt.entities...)
+			}
+
+			// none of the entities are inserted if any are outside of the tree
+			inside := &Entity3{ID: 101, Bound3: NewBound3(0, 0, 0, 10, 10, 10)}
+			if err := o.InsertEntities(inside, outside); err == nil {
+				t.Errorf("OctGo.InsertEntities() with an entity outside of the tree got no error")
+			}
+			if o.Len() != len(tt.entities) {
+				t.Errorf("OctGo.Len() = %v, want %v", o.Len(), len(tt.entities))
+			}
+			if <-o.IsEntity(inside) {
+				t.Errorf("OctGo.IsEntity() = true for entity given with one outside of the tree")
+			}
+
+			func() {
+				defer func() {
+					if recover() == nil {
+						t.Errorf("OctGo.Insert() with bounds outside of the tree did not panic")
+					}
+				}()
+				o.Insert(900, 700, 500, 950, 750, 550)
+			}()
+			if o.Len() != len(tt.entities) {
+				t.Errorf("OctGo.Len() = %v, want %v", o.Len(), len(tt.entities))
+			}
+		})
+	}
+}
+
+func TestOctGo_InsertWithAction(t *testing.T) {
+	o := NewOctGo(800, 600, 400)
+
+	called := false
+	o.InsertWithAction(0, 0, 0, 50, 50, 50, func() {
+		called = true
+	})
+
+	entities := <-o.All()
+	if len(entities) != 1 {
+		t.Fatalf("OctGo.All() = %v, want 1 entity", entities)
+	}
+	entities[0].Action()
+	if !called {
+		t.Errorf("OctGo.InsertWithAction() action was not set")
+	}
+}
+
+func TestOctGo_InsertEntities(t *testing.T) {
+	tests := []struct {
+		name     string
+		entities Entities3
+		wantErr  error
+	}{
+		{
+			name:     "insert entities",
+			entities: randomEntities3(50, 800, 600, 400),
+			wantErr:  nil,
+		},
+		{
+			name:     "no entities",
+			entities: nil,
+			wantErr:  errors.New("no entities given to OctGo.InsertEntities()"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := NewOctGo(800, 600, 400, SetMaxEntities(4))
+			err := o.InsertEntities(tt.entities...)
+			if !reflect.DeepEqual(err, tt.wantErr) {
+				t.Fatalf("OctGo.InsertEntities() error = %v, want %v", err, tt.wantErr)
+			}
+			if o.Len() != len(tt.entities) {
+				t.Errorf("OctGo.Len() = %v, want %v", o.Len(), len(tt.entities))
+			}
+			for _, e := range tt.entities {
+				if !<-o.IsEntity(e) {
+					t.Errorf("OctGo.IsEntity() = false for inserted entity %v", e.ID)
+				}
+			}
+			checkOctParents(t, o.octNode)
+		})
+	}
+}
+
+func TestOctGo_Remove(t *testing.T) {
+	entities := Entities3{
+		&Entity3{ID: 1, Bound3: NewBound3(0, 0, 0, 50, 50, 50)},
+		&Entity3{ID: 2, Bound3: NewBound3(25, 25, 25, 50, 60, 50)},
+		&Entity3{ID: 3, Bound3: NewBound3(5, 5, 5, 90, 80, 70)},
+	}
+
+	tests := []struct {
+		name         string
+		ops          []Option
+		entity       *Entity3
+		wantErr      error
+		wantChildren int
+	}{
+		{
+			name:    "remove 1 entity",
+			entity:  &Entity3{ID: 2, Bound3: NewBound3(25, 25, 25, 50, 60, 50)},
+			wantErr: nil,
+		},
+		{
+			name:         "remove and collapse",
+			ops:          []Option{SetMaxEntities(2)},
+			entity:       &Entity3{ID: 2, Bound3: NewBound3(25, 25, 25, 50, 60, 50)},
+			wantErr:      nil,
+			wantChildren: 0,
+		},
+		{
+			name:         "remove missing entity",
+			ops:          []Option{SetMaxEntities(2)},
+			entity:       &Entity3{ID: 4, Bound3: NewBound3(25, 25, 25, 50, 60, 50)},
+			wantErr:      errors.New("could not find entity in tree to remove"),
+			wantChildren: 8,
+		},
+		{
+			name:         "remove entity outside of split tree",
+			ops:          []Option{SetMaxEntities(2)},
+			entity:       &Entity3{ID: 4, Bound3: NewBound3(900, 700, 500, 950, 750, 550)},
+			wantErr:      errors.New("could not find entity in tree to remove"),
+			wantChildren: 8,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := NewOctGo(800, 600, 400, tt.ops...)
+			o.InsertEntities(entities...)
+
+			err := o.Remove(tt.entity)
+			if !reflect.DeepEqual(err, tt.wantErr) {
+				t.Fatalf("OctGo.Remove() error = %v, want %v", err, tt.wantErr)
+			}
+			if len(o.children) != tt.wantChildren {
+				t.Errorf("OctGo.Remove() children = %v, want %v", len(o.children), tt.wantChildren)
+			}
+
+			wantLen := len(entities)
+			if err == nil {
+				wantLen--
+				if <-o.IsEntity(tt.entity) {
+					t.Errorf("OctGo.IsEntity() = true for removed entity")
+				}
+			}
+			if o.Len() != wantLen {
+				t.Errorf("OctGo.Len() = %v, want %v", o.Len(), wantLen)
+			}
+		})
+	}
+}
+
+func TestOctGo_Retrieve(t *testing.T) {
+	o := NewOctGo(8, 8, 8, SetMaxEntities(1))
+	near := &Entity3{ID: 1, Bound3: NewBound3(1, 1, 1, 2, 2, 2)}
+	far := &Entity3{ID: 2, Bound3: NewBound3(6, 6, 6, 7, 7, 7)}
+	big := &Entity3{ID: 3, Bound3: NewBound3(1, 1, 1, 7, 7, 7)}
+	o.InsertEntities(near, far, big)
+
+	tests := []struct {
+		name  string
+		bound Bound3
+		want  []uint64
+	}{
+		{name: "near octant", bound: NewBound3(0, 0, 0, 1, 1, 1), want: []uint64{1, 3}},
+		{name: "far octant", bound: NewBound3(7, 7, 7, 8, 8, 8), want: []uint64{2, 3}},
+		{name: "empty octant leaf", bound: NewBound3(5, 1, 1, 6, 2, 2), want: []uint64{3}},
+		{name: "whole tree", bound: o.Bounds(), want: []uint64{1, 2, 3}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := entity3IDs(<-o.Retrieve(tt.bound)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("OctGo.Retrieve() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOctGo_IsEntity(t *testing.T) {
+	o := NewOctGo(800, 600, 400, SetMaxEntities(2))
+	entities := randomEntities3(20, 800, 600, 400)
+	o.InsertEntities(entities...)
+
+	tests := []struct {
+		name   string
+		entity *Entity3
+		want   bool
+	}{
+		{name: "entity in tree", entity: entities[5], want: true},
+		{name: "copy of entity in tree", entity: &Entity3{ID: entities[5].ID, Bound3: entities[5].Bound3}, want: true},
+		{name: "different id", entity: &Entity3{ID: 100, Bound3: entities[5].Bound3}, want: false},
+		{name: "different bound", entity: &Entity3{ID: entities[5].ID, Bound3: NewBound3(0, 0, 0, 1, 1, 1)}, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := <-o.IsEntity(tt.entity); got != tt.want {
+				t.Errorf("OctGo.IsEntity() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOctGo_IsIntersect(t *testing.T) {
+	o := NewOctGo(100, 100, 100, SetMaxEntities(1))
+	o.Insert(10, 10, 10, 20, 20, 20)
+	o.Insert(60, 60, 60, 70, 70, 70)
+
+	tests := []struct {
+		name  string
+		bound Bound3
+		want  bool
+	}{
+		{name: "intersect", bound: NewBound3(15, 15, 15, 25, 25, 25), want: true},
+		{name: "touching", bound: NewBound3(70, 70, 70, 80, 80, 80), want: true},
+		{name: "same x and y but other z", bound: NewBound3(10, 10, 30, 20, 20, 40), want: false},
+		{name: "no intersect", bound: NewBound3(30, 30, 30, 40, 40, 40), want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := <-o.IsIntersect(tt.bound); got != tt.want {
+				t.Errorf("OctGo.IsIntersect() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOctGo_Intersects(t *testing.T) {
+	entities := randomEntities3(300, 200, 200, 200)
+	o := NewOctGo(200, 200, 200, SetMaxEntities(4), SetMaxDepth(4))
+	o.InsertEntities(entities...)
+
+	r := rand.New(rand.NewSource(2))
+	for i := 0; i < 50; i++ {
+		x, y, z := r.Float64()*200, r.Float64()*200, r.Float64()*200
+		bound := NewBound3(x, y, z, x+r.Float64()*60, y+r.Float64()*60, z+r.Float64()*60)
+
+		var want Entities3
+		for _, e := range entities {
+			if e.IsIntersect(bound) {
+				want = append(want, e)
+			}
+		}
+
+		got := <-o.Intersects(bound)
+		if !reflect.DeepEqual(entity3IDs(got), entity3IDs(want)) {
+			t.Errorf("OctGo.Intersects(%v) = %v, want %v", bound, entity3IDs(got), entity3IDs(want))
+		}
+		if len(got) > 0 != <-o.IsIntersect(bound) {
+			t.Errorf("OctGo.IsIntersect(%v) = %v, want %v", bound, !(len(got) > 0), len(got) > 0)
+		}
+	}
+}
+
+func TestOctGo_Clear(t *testing.T) {
+	o := NewOctGo(800, 600, 400, SetMaxEntities(2))
+	o.InsertEntities(randomEntities3(20, 800, 600, 400)...)
+
+	o.Clear()
+
+	want := NewOctGo(800, 600, 400, SetMaxEntities(2))
+	if !reflect.DeepEqual(o, want) {
+		t.Errorf("OctGo.Clear() = %v, want %v", o, want)
+	}
+}
+
+func TestOctGo_Len(t *testing.T) {
+	o := NewOctGo(800, 600, 400, SetMaxEntities(2))
+	entities := randomEntities3(30, 800, 600, 400)
+	o.InsertEntities(entities...)
+
+	if o.Len() != 30 {
+		t.Errorf("OctGo.Len() = %v, want %v", o.Len(), 30)
+	}
+	for _, e := range entities[:10] {
+		o.Remove(e)
+	}
+	if o.Len() != 20 {
+		t.Errorf("OctGo.Len() = %v, want %v", o.Len(), 20)
+	}
+	if got := len(<-o.All()); got != o.Len() {
+		t.Errorf("OctGo.All() = %v entities, want %v", got, o.Len())
+	}
+}
+
+func TestOctGo_Bounds(t *testing.T) {
+	o := NewOctGo(800, 600, 400)
+	if want := NewBound3(0, 0, 0, 800, 600, 400); !o.Bounds().IsEqual(want) {
+		t.Errorf("OctGo.Bounds() = %v, want %v", o.Bounds(), want)
+	}
+}
+
+func TestOctGo_All(t *testing.T) {
+	o := NewOctGo(800, 600, 400, SetMaxEntities(2))
+	entities := randomEntities3(40, 800, 600, 400)
+	o.InsertEntities(entities...)
+
+	if got := entity3IDs(<-o.All()); !reflect.DeepEqual(got, entity3IDs(entities)) {
+		t.Errorf("OctGo.All() = %v, want %v", got, entity3IDs(entities))
+	}
+}
+
+func TestOctGo_ForEach(t *testing.T) {
+	o := NewOctGo(800, 600, 400, SetMaxEntities(2))
+	entities := randomEntities3(40, 800, 600, 400)
+	o.InsertEntities(entities...)
+
+	var got Entities3
+	o.ForEach(func(e *Entity3) bool {
+		got = append(got, e)
+		return true
+	})
+	if !reflect.DeepEqual(entity3IDs(got), entity3IDs(entities)) {
+		t.Errorf("OctGo.ForEach() = %v, want %v", entity3IDs(got), entity3IDs(entities))
+	}
+
+	count := 0
+	o.ForEach(func(*Entity3) bool {
+		count++
+		return count < 5
+	})
+	if count != 5 {
+		t.Errorf("OctGo.ForEach() stopped after %v entities, want 5", count)
+	}
+}
+
+func TestOctGo_QueryFunc(t *testing.T) {
+	o := NewOctGo(100, 100, 100, SetMaxEntities(1))
+
+	// the entity spans every octant but is only given once
+	o.InsertEntities(
+		&Entity3{ID: 1, Bound3: NewBound3(10, 10, 10, 90, 90, 90)},
+		&Entity3{ID: 2, Bound3: NewBound3(60, 60, 60, 70, 70, 70)},
+	)
+
+	var got Entities3
+	o.QueryFunc(o.Bounds(), func(e *Entity3) bool {
+		got = append(got, e)
+		return true
+	})
+	if ids := entity3IDs(got); !reflect.DeepEqual(ids, []uint64{1, 2}) {
+		t.Errorf("OctGo.QueryFunc() = %v, want %v", ids, []uint64{1, 2})
+	}
+
+	count := 0
+	o.QueryFunc(o.Bounds(), func(*Entity3) bool {
+		count++
+		return false
+	})
+	if count != 1 {
+		t.Errorf("OctGo.QueryFunc() called %v times after returning false, want 1", count)
+	}
+}
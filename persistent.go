@@ -0,0 +1,348 @@
+// Copyright 2019 Tskken. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package quadgo
+
+import "errors"
+
+// Persistent is an immutable version of a QuadGo tree.
+//
+// Inserting in to or removing from a Persistent tree does not change it but returns a new
+// version of the tree. The new version shares every node the change did not touch with the
+// old version, so keeping many versions of a tree only costs the nodes along the changed
+// paths. This makes it possible to keep a history of a tree, for example the last 60 frames
+// of a game for rollback.
+//
+// As a Persistent tree never changes all of its read functions are safe to run at the same
+// time as inserts and removes.
+type Persistent struct {
+	root *node
+
+	maxEntities uint64
+	maxDepth    uint16
+
+	// size is the number of entities inserted in to the tree
+	size int
+}
+
+// NewPersistent creates an empty Persistent tree.
+//
+// NewPersistent takes the same width, height and Option functions as New.
+func NewPersistent(width, height float64, ops ...Option) *Persistent {
+	return BuildPersistent(width, height, nil, ops...)
+}
+
+// BuildPersistent creates a Persistent tree filled with the given entities.
+//
+// BuildPersistent takes the same arguments as Build and creates the tree the same way,
+// panicking on any entity outside of the width and height the same as Build.
+func BuildPersistent(width, height float64, entities Entities, ops ...Option) *Persistent {
+	// copy defaults
+	o := defaultOption
+
+	// update for any given options
+	for _, op := range ops {
+		op(&o)
+	}
+
+	bound := NewBound(0, 0, width, height)
+	if err := inside(bound, entities); err != nil {
+		panic(err)
+	}
+	entities = distinctEntities(entities)
+
+	p := &Persistent{
+		maxEntities: o.MaxEntities,
+		maxDepth:    o.MaxDepth,
+		size:        len(entities),
+	}
+	p.root = p.build(bound, 0, append(Entities(nil), entities...))
+
+	return p
+}
+
+// Insert returns a new version of the tree with the given entity inserted. If an entity
+// equal to the given entity is already in the tree this version is returned unchanged.
+//
+// Insert panics if the entity is outside of the tree, the same as BuildPersistent.
+func (p *Persistent) Insert(entity *Entity) *Persistent {
+	if !p.root.bound.IsIntersect(entity.Bound) {
+		panic(outsideError(entity.ID, entity.Min, entity.Max, p.root.bound.Min, p.root.bound.Max))
+	}
+
+	root, inserted := p.insert(p.root, entity)
+	if !inserted {
+		return p
+	}
+	return p.version(root, p.size+1)
+}
+
+// Remove returns a new version of the tree with the given entity removed.
+//
+// The given entity has to have the same ID and Bounds as the entity to remove, the same
+// as QuadGo.Remove(). This will return an error if the entity was not found in the tree.
+func (p *Persistent) Remove(entity *Entity) (*Persistent, error) {
+	root, err := p.remove(p.root, entity)
+	if err != nil {
+		return nil, err
+	}
+
+	return p.version(root, p.size-1), nil
+}
+
+// Retrieve returns all entities from all nodes the given bounds intersects with.
+// See QuadGo.Retrieve().
+func (p *Persistent) Retrieve(bound Bound) <-chan Entities {
+	return p.tree().Retrieve(bound)
+}
+
+// IsEntity checks if a given entity exists within the tree.
+// See QuadGo.IsEntity().
+func (p *Persistent) IsEntity(entity *Entity) <-chan bool {
+	return p.tree().IsEntity(entity)
+}
+
+// IsIntersect take a bound and returns if that bound intersects any entity within the tree.
+// See QuadGo.IsIntersect().
+func (p *Persistent) IsIntersect(bound Bound) <-chan bool {
+	return p.tree().IsIntersect(bound)
+}
+
+// Intersects takes a bound and returns all entities that the given bound intersects with.
+// See QuadGo.Intersects().
+func (p *Persistent) Intersects(bound Bound) <-chan Entities {
+	return p.tree().Intersects(bound)
+}
+
+// IsIntersectShape takes a shape and returns if that shape intersects any entity within the tree.
+// See QuadGo.IsIntersectShape().
+func (p *Persistent) IsIntersectShape(shape Shape) <-chan bool {
+	return p.tree().IsIntersectShape(shape)
+}
+
+// IntersectsShape takes a shape and returns all entities that the given shape intersects with.
+// See QuadGo.IntersectsShape().
+func (p *Persistent) IntersectsShape(shape Shape) <-chan Entities {
+	return p.tree().IntersectsShape(shape)
+}
+
+// QueryFunc calls the given function for every entity that the given bound intersects with.
+// See QuadGo.QueryFunc().
+func (p *Persistent) QueryFunc(bound Bound, fn func(*Entity) bool) {
+	p.tree().QueryFunc(bound, fn)
+}
+
+// All returns every entity with in the tree.
+// See QuadGo.All().
+func (p *Persistent) All() <-chan Entities {
+	return p.tree().All()
+}
+
+// ForEach calls the given function for every entity with in the tree.
+// See QuadGo.ForEach().
+func (p *Persistent) ForEach(fn func(*Entity) bool) {
+	p.tree().ForEach(fn)
+}
+
+// Walk calls the given function for every node in the tree.
+// See QuadGo.Walk().
+func (p *Persistent) Walk(fn func(NodeInfo) bool) {
+	p.tree().Walk(fn)
+}
+
+// Stats walks the tree and returns statistics about its shape.
+// See QuadGo.Stats().
+func (p *Persistent) Stats() Stats {
+	return p.tree().Stats()
+}
+
+// Len returns the number of entities in the tree.
+func (p *Persistent) Len() int {
+	return p.size
+}
+
+// Bounds returns the bounds of the tree as given at creation.
+func (p *Persistent) Bounds() Bound {
+	return p.root.bound
+}
+
+// tree wraps the root node in a QuadGo to run its read functions.
+func (p *Persistent) tree() *QuadGo {
+	return &QuadGo{
+		node:        p.root,
+		maxEntities: p.maxEntities,
+		maxDepth:    p.maxDepth,
+		size:        p.size,
+	}
+}
+
+// version returns a new version of the tree with the given root node.
+func (p *Persistent) version(root *node, size int) *Persistent {
+	return &Persistent{
+		root:        root,
+		maxEntities: p.maxEntities,
+		maxDepth:    p.maxDepth,
+		size:        size,
+	}
+}
+
+// build creates a new node for the given bound with the given entities, splitting the
+// same way as node.build().
+//
+// Nodes of a Persistent tree have no parent as they can be shared between many versions.
+func (p *Persistent) build(bound Bound, depth uint16, entities Entities) *node {
+	// check if the entities fit in a leaf node
+	if uint64(len(entities)) <= p.maxEntities || depth >= p.maxDepth {
+		return &node{
+			bound:    bound,
+			entities: entities,
+			depth:    depth,
+		}
+	}
+
+	n := &node{
+		bound:    bound,
+		children: make(nodes, 0, 4),
+		depth:    depth,
+	}
+
+	// build each child node from the entities that intersect it
+	for _, b := range bound.quadrants() {
+		var part Entities
+		for _, e := range entities {
+			if b.IsIntersect(e.Bound) {
+				part = append(part, e)
+			}
+		}
+
+		n.children = append(n.children, p.build(b, depth+1, part))
+	}
+
+	return n
+}
+
+// insert returns a copy of the given node with the given entity inserted.
+//
+// Only the nodes the entity is inserted in to are copied, all other nodes are shared.
+func (p *Persistent) insert(n *node, entity *Entity) (*node, bool) {
+	// check if you are on a leaf node
+	if len(n.children) > 0 {
+		c := &node{
+			bound:    n.bound,
+			children: make(nodes, len(n.children), 4),
+			depth:    n.depth,
+		}
+
+		// copy the children the entity intersects and share the rest
+		inserted := false
+		for i, child := range n.children {
+			if !child.bound.IsIntersect(entity.Bound) {
+				c.children[i] = child
+				continue
+			}
+
+			var ok bool
+			if c.children[i], ok = p.insert(child, entity); ok {
+				inserted = true
+			}
+		}
+		if !inserted {
+			return n, false
+		}
+
+		return c, true
+	}
+
+	// an entity is in every leaf it intersects, so if it is in this leaf it is already in the tree
+	if n.entities.Contains(entity) {
+		return n, false
+	}
+
+	// copy the leaf entities with the new entity, splitting if needed
+	entities := make(Entities, len(n.entities), len(n.entities)+1)
+	copy(entities, n.entities)
+
+	return p.build(n.bound, n.depth, append(entities, entity)), true
+}
+
+// remove returns a copy of the given node with the given entity removed.
+//
+// Only the nodes the entity is removed from are copied, all other nodes are shared.
+func (p *Persistent) remove(n *node, entity *Entity) (*node, error) {
+	// check if we are on a leaf node
+	if len(n.children) > 0 {
+		c := &node{
+			bound:    n.bound,
+			children: make(nodes, len(n.children), 4),
+			depth:    n.depth,
+		}
+
+		// copy the children the entity intersects and share the rest
+		found := false
+		for i, child := range n.children {
+			if !child.bound.IsIntersect(entity.Bound) {
+				c.children[i] = child
+				continue
+			}
+
+			found = true
+			removed, err := p.remove(child, entity)
+			if err != nil {
+				return nil, err
+			}
+			c.children[i] = removed
+		}
+		// an entity outside of the tree is in no node
+		if !found {
+			return nil, errors.New("could not find entity in tree to remove")
+		}
+
+		return p.collapse(c), nil
+	}
+
+	// copy the leaf entities before removing as the leaf can be shared
+	entities, err := append(Entities(nil), n.entities...).FindAndRemove(entity)
+	if err != nil {
+		return nil, err
+	}
+
+	return &node{
+		bound:    n.bound,
+		entities: entities,
+		depth:    n.depth,
+	}, nil
+}
+
+// collapse returns a leaf node holding the entities of the given nodes children if all of
+// its children are leaf nodes and together hold no more then the max entities.
+// Otherwise it returns the given node.
+func (p *Persistent) collapse(n *node) *node {
+	// check that all children are leaf nodes
+	for i := range n.children {
+		if len(n.children[i].children) > 0 {
+			return n
+		}
+	}
+
+	// cycle through children to find all non duplecet entities
+	var entities Entities
+	for i := range n.children {
+		for _, ent := range n.children[i].entities {
+			if !entities.Contains(ent) {
+				entities = append(entities, ent)
+			}
+		}
+	}
+
+	// check if collapse is needed
+	if uint64(len(entities)) > p.maxEntities {
+		return n
+	}
+
+	return &node{
+		bound:    n.bound,
+		entities: entities,
+		depth:    n.depth,
+	}
+}
@@ -0,0 +1,353 @@
+// Copyright 2019 Tskken. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package quadgo
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// sharedNodes counts the nodes in the given tree that are also in the other tree.
+func sharedNodes(lhs, rhs *node) int {
+	nodes := make(map[*node]bool)
+	var add func(n *node)
+	add = func(n *node) {
+		nodes[n] = true
+		for _, c := range n.children {
+			add(c)
+		}
+	}
+	add(rhs)
+
+	shared := 0
+	var count func(n *node)
+	count = func(n *node) {
+		if nodes[n] {
+			shared++
+		}
+		for _, c := range n.children {
+			count(c)
+		}
+	}
+	count(lhs)
+
+	return shared
+}
+
+func TestBuildPersistent(t *testing.T) {
+	type args struct {
+		entities Entities
+		ops      []Option
+	}
+	tests := []struct {
+		name string
+		args args
+	}{
+		{
+			name: "build empty tree",
+		},
+		{
+			name: "build with splits",
+			args: args{
+				entities: randomEntities(500, 800, 600),
+				ops: []Option{
+					SetMaxEntities(4),
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := BuildPersistent(800, 600, tt.args.entities, tt.args.ops...)
+			want := Build(800, 600, tt.args.entities, tt.args.ops...)
+
+			if !sameNode(got.root, want.node) {
+				t.Errorf("quadgo.BuildPersistent() tree does not match quadgo.Build() tree")
+			}
+			if got.Len() != want.Len() {
+				t.Errorf("quadgo.BuildPersistent() Len() = %v, want %v", got.Len(), want.Len())
+			}
+		})
+	}
+}
+
+func TestPersistent_Insert(t *testing.T) {
+	type fields struct {
+		entities Entities
+		ops      []Option
+	}
+	type args struct {
+		entity *Entity
+	}
+	tests := []struct {
+		name       string
+		fields     fields
+		args       args
+		wantShared int
+	}{
+		{
+			name: "insert in to leaf root",
+			fields: fields{
+				entities: randomEntities(5, 800, 600),
+			},
+			args: args{
+				entity: &Entity{ID: 100, Bound: NewBound(0, 0, 50, 50)},
+			},
+			wantShared: 0,
+		},
+		{
+			name: "insert with split",
+			fields: fields{
+				entities: Entities{
+					&Entity{ID: 1, Bound: NewBound(0, 0, 50, 50)},
+					&Entity{ID: 2, Bound: NewBound(500, 400, 700, 600)},
+				},
+				ops: []Option{
+					SetMaxEntities(2),
+				},
+			},
+			args: args{
+				entity: &Entity{ID: 100, Bound: NewBound(10, 10, 20, 20)},
+			},
+			wantShared: 0,
+		},
+		{
+			name: "insert in to one quadrant",
+			fields: fields{
+				entities: Entities{
+					&Entity{ID: 1, Bound: NewBound(0, 0, 50, 50)},
+					&Entity{ID: 2, Bound: NewBound(500, 400, 700, 600)},
+					&Entity{ID: 3, Bound: NewBound(500, 0, 700, 50)},
+				},
+				ops: []Option{
+					SetMaxEntities(2),
+				},
+			},
+			args: args{
+				entity: &Entity{ID: 100, Bound: NewBound(10, 10, 20, 20)},
+			},
+			wantShared: 3,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			old := BuildPersistent(800, 600, tt.fields.entities, tt.fields.ops...)
+			want := Build(800, 600, tt.fields.entities, tt.fields.ops...)
+			before := want.Clone()
+
+			got := old.Insert(tt.args.entity)
+			want.insert(tt.args.entity, want.maxDepth, nil, nil)
+
+			if !sameNode(got.root, want.node) {
+				t.Errorf("Persistent.Insert() tree does not match QuadGo tree")
+			}
+			if !sameNode(old.root, before.node) {
+				t.Errorf("Persistent.Insert() changed the old version of the tree")
+			}
+			if got.Len() != old.Len()+1 {
+				t.Errorf("Persistent.Insert() Len() = %v, want %v", got.Len(), old.Len()+1)
+			}
+			if shared := sharedNodes(got.root, old.root); shared != tt.wantShared {
+				t.Errorf("Persistent.Insert() shared %v nodes, want %v", shared, tt.wantShared)
+			}
+			if <-old.IsEntity(tt.args.entity) || !<-got.IsEntity(tt.args.entity) {
+				t.Errorf("Persistent.Insert() entity found in wrong version of tree")
+			}
+		})
+	}
+}
+
+func TestPersistent_Remove(t *testing.T) {
+	type fields struct {
+		entities Entities
+		ops      []Option
+	}
+	type args struct {
+		entity *Entity
+	}
+	tests := []struct {
+		name      string
+		fields    fields
+		args      args
+		wantSplit bool
+		wantErr   error
+	}{
+		{
+			name: "remove from leaf root",
+			fields: fields{
+				entities: Entities{
+					&Entity{ID: 1, Bound: NewBound(0, 0, 50, 50)},
+					&Entity{ID: 2, Bound: NewBound(500, 400, 700, 600)},
+				},
+			},
+			args: args{
+				entity: &Entity{ID: 1, Bound: NewBound(0, 0, 50, 50)},
+			},
+			wantSplit: false,
+			wantErr:   nil,
+		},
+		{
+			name: "remove and collapse",
+			fields: fields{
+				entities: Entities{
+					&Entity{ID: 1, Bound: NewBound(350, 250, 450, 350)},
+					&Entity{ID: 2, Bound: NewBound(0, 0, 50, 50)},
+					&Entity{ID: 3, Bound: NewBound(500, 400, 700, 600)},
+				},
+				ops: []Option{
+					SetMaxEntities(2),
+				},
+			},
+			args: args{
+				entity: &Entity{ID: 1, Bound: NewBound(350, 250, 450, 350)},
+			},
+			wantSplit: false,
+			wantErr:   nil,
+		},
+		{
+			name: "remove with no collapse of grand children",
+			fields: fields{
+				entities: Entities{
+					&Entity{ID: 1, Bound: NewBound(0, 0, 10, 10)},
+					&Entity{ID: 2, Bound: NewBound(20, 20, 30, 30)},
+					&Entity{ID: 3, Bound: NewBound(250, 200, 260, 210)},
+					&Entity{ID: 4, Bound: NewBound(500, 400, 700, 600)},
+				},
+				ops: []Option{
+					SetMaxEntities(2),
+				},
+			},
+			args: args{
+				entity: &Entity{ID: 4, Bound: NewBound(500, 400, 700, 600)},
+			},
+			wantSplit: true,
+			wantErr:   nil,
+		},
+		{
+			name: "remove non entity error",
+			fields: fields{
+				entities: randomEntities(5, 800, 600),
+			},
+			args: args{
+				entity: &Entity{ID: 100, Bound: NewBound(0, 0, 50, 50)},
+			},
+			wantErr: errors.New("could not find entity in tree to remove"),
+		},
+		{
+			name: "remove entity outside of split tree error",
+			fields: fields{
+				entities: randomEntities(50, 800, 600),
+				ops: []Option{
+					SetMaxEntities(2),
+				},
+			},
+			args: args{
+				entity: &Entity{ID: 100, Bound: NewBound(900, 700, 950, 750)},
+			},
+			wantErr: errors.New("could not find entity in tree to remove"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			old := BuildPersistent(800, 600, tt.fields.entities, tt.fields.ops...)
+			want := Build(800, 600, tt.fields.entities, tt.fields.ops...)
+			before := want.Clone()
+
+			got, err := old.Remove(tt.args.entity)
+			if !reflect.DeepEqual(err, tt.wantErr) {
+				t.Errorf("Persistent.Remove() got an unwanted error = %v, want %v", err, tt.wantErr)
+			}
+			if !sameNode(old.root, before.node) {
+				t.Errorf("Persistent.Remove() changed the old version of the tree")
+			}
+			if err != nil {
+				return
+			}
+
+			if err := want.Remove(tt.args.entity); err != nil {
+				t.Errorf("QuadGo.Remove() got error %v", err)
+			}
+			if !sameNode(got.root, want.node) {
+				t.Errorf("Persistent.Remove() tree does not match QuadGo tree")
+			}
+			if split := len(got.root.children) > 0; split != tt.wantSplit {
+				t.Errorf("Persistent.Remove() tree split = %v, want %v", split, tt.wantSplit)
+			}
+			if got.Len() != old.Len()-1 {
+				t.Errorf("Persistent.Remove() Len() = %v, want %v", got.Len(), old.Len()-1)
+			}
+			if !<-old.IsEntity(tt.args.entity) || <-got.IsEntity(tt.args.entity) {
+				t.Errorf("Persistent.Remove() entity found in wrong version of tree")
+			}
+		})
+	}
+}
+
+func TestPersistent_Insert_same(t *testing.T) {
+	entities := randomEntities(50, 800, 600)
+	p := BuildPersistent(800, 600, append(entities, entities[:10]...), SetMaxEntities(4))
+	if p.Len() != len(entities) {
+		t.Errorf("quadgo.BuildPersistent() Len() = %v, want %v", p.Len(), len(entities))
+	}
+
+	// inserting an entity already in the tree gives back the same version, the same as QuadGo
+	same := &Entity{ID: entities[3].ID, Bound: entities[3].Bound}
+	if got := p.Insert(same); got != p {
+		t.Errorf("Persistent.Insert() with an entity in the tree gave a new version with Len() %v", got.Len())
+	}
+}
+
+func TestPersistent_Insert_outside(t *testing.T) {
+	outside := &Entity{ID: 100, Bound: NewBound(900, 700, 950, 750)}
+
+	tests := []struct {
+		name  string
+		build func()
+	}{
+		{name: "Insert", build: func() { BuildPersistent(800, 600, randomEntities(50, 800, 600), SetMaxEntities(2)).Insert(outside) }},
+		{name: "BuildPersistent", build: func() { BuildPersistent(800, 600, append(randomEntities(5, 800, 600), outside)) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("quadgo.%v() with an entity outside of the tree did not panic", tt.name)
+				}
+			}()
+			tt.build()
+		})
+	}
+}
+
+func TestPersistent_history(t *testing.T) {
+	entities := randomEntities(200, 800, 600)
+
+	// keep a version of the tree for every insert and remove
+	history := []*Persistent{NewPersistent(800, 600, SetMaxEntities(4))}
+	for _, e := range entities {
+		history = append(history, history[len(history)-1].Insert(e))
+	}
+	for _, e := range entities[:100] {
+		p, err := history[len(history)-1].Remove(e)
+		if err != nil {
+			t.Errorf("Persistent.Remove() got error %v", err)
+		}
+		history = append(history, p)
+	}
+
+	// each version should only hold the entities inserted and not yet removed at that time
+	for i, p := range history {
+		want := i
+		if i > len(entities) {
+			want = len(entities) - (i - len(entities))
+		}
+
+		got := <-p.All()
+		if len(got) != want || p.Len() != want {
+			t.Errorf("Persistent version %v has %v entities, want %v", i, len(got), want)
+		}
+	}
+}
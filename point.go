@@ -8,7 +8,8 @@ import "fmt"
 
 // Point is the basic 2D coordinate structure for QuadGo
 type Point struct {
-	X, Y float64
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
 }
 
 // NewPoint creates a new point for the given x and y positions.
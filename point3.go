@@ -0,0 +1,32 @@
+// Copyright 2019 Tskken. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package quadgo
+
+import "fmt"
+
+// Point3 is the basic 3D coordinate structure for OctGo
+type Point3 struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+	Z float64 `json:"z"`
+}
+
+// NewPoint3 creates a new point for the given x, y and z positions.
+func NewPoint3(x, y, z float64) Point3 {
+	return Point3{
+		X: x,
+		Y: y,
+		Z: z,
+	}
+}
+
+// IsEqual checks if the given point is equal to this point.
+func (p Point3) IsEqual(point Point3) bool {
+	return p.X == point.X && p.Y == point.Y && p.Z == point.Z
+}
+
+func (p Point3) String() string {
+	return fmt.Sprintf("X: %v, Y: %v, Z: %v", p.X, p.Y, p.Z)
+}
@@ -0,0 +1,343 @@
+// Copyright 2019 Tskken. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package quadgo
+
+import (
+	"errors"
+	"sort"
+)
+
+// PointTree is a quad-tree of points, for things with no size such as particles, waypoints
+// or sensor readings.
+//
+// Points are stored as they are, with no Entity or Bound to create or test. Unlike QuadGo,
+// which splits each node at its center, a node of a PointTree is split at the median x and y
+// of the points it holds so around half of them are on each side of each split, keeping the
+// tree balanced however the points are bunched together. A PointTree has no bounds so it can
+// hold points anywhere.
+//
+// The same point can be inserted more then once and is then held once for each insert.
+type PointTree struct {
+	root *pointNode
+
+	maxPoints int
+	maxDepth  uint16
+}
+
+// pointNode is a node of a PointTree, holding points if it is a leaf or four children if it is not.
+type pointNode struct {
+	// split is the point the children are split at. Points with x less then the split are
+	// in the left children and points with y less then the split are in the top children.
+	split Point
+
+	points []Point
+
+	// children are ordered top left, top right, bottom left and then bottom right
+	children []*pointNode
+
+	// size is the number of points held by the node and its children
+	size  int
+	depth uint16
+}
+
+// NewPointTree creates an empty PointTree.
+//
+// The max entities Option sets the most points held by each leaf and the max depth Option
+// sets how deep the tree can split, the same as for QuadGo.
+//
+// Example:
+//  particles := quadgo.NewPointTree(quadgo.SetMaxEntities(16), quadgo.SetMaxDepth(12))
+func NewPointTree(ops ...Option) *PointTree {
+	// copy defaults
+	o := defaultOption
+
+	// update for any given options
+	for _, op := range ops {
+		op(&o)
+	}
+
+	maxPoints := int(o.MaxEntities)
+	if maxPoints < 1 {
+		maxPoints = 1
+	}
+
+	return &PointTree{
+		root:      &pointNode{},
+		maxPoints: maxPoints,
+		maxDepth:  o.MaxDepth,
+	}
+}
+
+// BuildPointTree creates a new PointTree filled with the given points.
+//
+// BuildPointTree splits the points top-down at their medians in one pass instead of
+// inserting them one at a time, which makes it the faster option for loading large sets
+// of points at once. The given list of points is left as it was.
+func BuildPointTree(points []Point, ops ...Option) *PointTree {
+	p := NewPointTree(ops...)
+
+	given := make([]Point, len(points))
+	copy(given, points)
+	p.root.build(given, p.maxPoints, p.maxDepth)
+
+	return p
+}
+
+// InsertPoint inserts the given point in to the tree.
+func (p *PointTree) InsertPoint(point Point) {
+	p.root.insert(point, p.maxPoints, p.maxDepth)
+}
+
+// InsertPoints inserts any number of points in to the tree.
+//
+// This will return an error if you do not give it any points.
+func (p *PointTree) InsertPoints(points ...Point) error {
+	if len(points) == 0 {
+		return errors.New("no points given to PointTree.InsertPoints()")
+	}
+
+	for _, point := range points {
+		p.root.insert(point, p.maxPoints, p.maxDepth)
+	}
+	return nil
+}
+
+// RemovePoint removes the given point from the tree, or one of them if it was inserted
+// more then once.
+//
+// This will return an error if the point was not found in the tree.
+func (p *PointTree) RemovePoint(point Point) error {
+	if !p.root.remove(point, p.maxPoints) {
+		return errors.New("could not find point in tree to remove")
+	}
+	return nil
+}
+
+// Clear removes all points from the tree.
+func (p *PointTree) Clear() {
+	p.root = &pointNode{}
+}
+
+// Len returns the number of points in the tree.
+func (p *PointTree) Len() int {
+	return p.root.size
+}
+
+// HasPoint returns if the given point is in the tree.
+func (p *PointTree) HasPoint(point Point) bool {
+	n := p.root
+	for len(n.children) > 0 {
+		n = n.children[n.quadrant(point)]
+	}
+
+	for _, q := range n.points {
+		if q.IsEqual(point) {
+			return true
+		}
+	}
+	return false
+}
+
+// QueryFunc calls the given function for every point with in the given bound, including
+// points on its edges. Returning false from the given function stops the query.
+func (p *PointTree) QueryFunc(bound Bound, fn func(Point) bool) {
+	p.root.query(bound, fn)
+}
+
+// Points returns every point with in the given bound.
+//
+// Example:
+//  // get every particle on screen
+//  particles := tree.Points(quadgo.NewBound(camera.X, camera.Y, camera.X+800, camera.Y+600))
+func (p *PointTree) Points(bound Bound) (points []Point) {
+	p.root.query(bound, func(point Point) bool {
+		points = append(points, point)
+		return true
+	})
+	return
+}
+
+// RadiusFunc calls the given function for every point with in the given radius of the given
+// center, including points at exactly the radius. Returning false from the given function
+// stops the query.
+func (p *PointTree) RadiusFunc(center Point, radius float64, fn func(Point) bool) {
+	bound := NewBound(center.X-radius, center.Y-radius, center.X+radius, center.Y+radius)
+	p.root.query(bound, func(point Point) bool {
+		if d := sub(point, center); dot(d, d) > radius*radius {
+			return true
+		}
+		return fn(point)
+	})
+}
+
+// Radius returns every point with in the given radius of the given center.
+//
+// Example:
+//  // get every waypoint the player can reach
+//  reachable := tree.Radius(player.Center, 200)
+func (p *PointTree) Radius(center Point, radius float64) (points []Point) {
+	p.RadiusFunc(center, radius, func(point Point) bool {
+		points = append(points, point)
+		return true
+	})
+	return
+}
+
+// ForEach calls the given function for every point in the tree, stopping if the function
+// returns false.
+func (p *PointTree) ForEach(fn func(Point) bool) {
+	p.root.forEach(fn)
+}
+
+// quadrant returns the index of the child of the node the given point is in.
+func (n *pointNode) quadrant(p Point) int {
+	i := 0
+	if p.X >= n.split.X {
+		i |= 1
+	}
+	if p.Y >= n.split.Y {
+		i |= 2
+	}
+	return i
+}
+
+// visits returns if the i'th child of the node could hold points with in the given bound.
+func (n *pointNode) visits(i int, bound Bound) bool {
+	if i&1 == 0 && bound.Min.X >= n.split.X || i&1 == 1 && bound.Max.X < n.split.X {
+		return false
+	}
+	if i&2 == 0 && bound.Min.Y >= n.split.Y || i&2 == 2 && bound.Max.Y < n.split.Y {
+		return false
+	}
+	return true
+}
+
+// build fills the node top-down with the given points, splitting them at their medians
+// while there are more points than a leaf can hold and the max depth has not been reached.
+//
+// The node keeps the given list of points if it is a leaf.
+func (n *pointNode) build(points []Point, maxPoints int, maxDepth uint16) {
+	n.size = len(points)
+	if len(points) <= maxPoints || n.depth >= maxDepth {
+		n.points = points
+		return
+	}
+
+	n.split = median(points)
+
+	var parts [4][]Point
+	for _, p := range points {
+		i := n.quadrant(p)
+		parts[i] = append(parts[i], p)
+	}
+
+	n.points = nil
+	n.children = make([]*pointNode, 4)
+	for i := range n.children {
+		n.children[i] = &pointNode{depth: n.depth + 1}
+		n.children[i].build(parts[i], maxPoints, maxDepth)
+	}
+}
+
+// insert adds the point to the leaf it is in, splitting the leaf if it becomes too full.
+func (n *pointNode) insert(p Point, maxPoints int, maxDepth uint16) {
+	if len(n.children) > 0 {
+		n.size++
+		n.children[n.quadrant(p)].insert(p, maxPoints, maxDepth)
+		return
+	}
+
+	n.points = append(n.points, p)
+	n.size++
+	if len(n.points) > maxPoints && n.depth < maxDepth {
+		n.build(n.points, maxPoints, maxDepth)
+	}
+}
+
+// remove removes the point from the leaf it is in, collapsing the node if it no longer
+// holds more points than a leaf can. remove returns if the point was found.
+func (n *pointNode) remove(p Point, maxPoints int) bool {
+	if len(n.children) > 0 {
+		if !n.children[n.quadrant(p)].remove(p, maxPoints) {
+			return false
+		}
+
+		n.size--
+		if n.size <= maxPoints {
+			n.collapse()
+		}
+		return true
+	}
+
+	for i, q := range n.points {
+		if q.IsEqual(p) {
+			n.points = append(n.points[:i], n.points[i+1:]...)
+			n.size--
+			return true
+		}
+	}
+	return false
+}
+
+// collapse moves every point of the children of the node in to the node and removes the children.
+func (n *pointNode) collapse() {
+	points := make([]Point, 0, n.size)
+	n.forEach(func(p Point) bool {
+		points = append(points, p)
+		return true
+	})
+
+	n.points = points
+	n.children = nil
+}
+
+// query calls the given function for every point with in the given bound, returning false
+// if the function stopped the query.
+func (n *pointNode) query(bound Bound, fn func(Point) bool) bool {
+	if len(n.children) > 0 {
+		for i, child := range n.children {
+			if n.visits(i, bound) && !child.query(bound, fn) {
+				return false
+			}
+		}
+		return true
+	}
+
+	for _, p := range n.points {
+		if bound.containsPoint(p) && !fn(p) {
+			return false
+		}
+	}
+	return true
+}
+
+// forEach calls the given function for every point under the node, returning false if the
+// function stopped the iteration.
+func (n *pointNode) forEach(fn func(Point) bool) bool {
+	for _, child := range n.children {
+		if !child.forEach(fn) {
+			return false
+		}
+	}
+
+	for _, p := range n.points {
+		if !fn(p) {
+			return false
+		}
+	}
+	return true
+}
+
+// median returns the point at the median x and median y of the given points.
+func median(points []Point) Point {
+	xs, ys := make([]float64, len(points)), make([]float64, len(points))
+	for i, p := range points {
+		xs[i], ys[i] = p.X, p.Y
+	}
+	sort.Float64s(xs)
+	sort.Float64s(ys)
+
+	return Point{X: xs[len(xs)/2], Y: ys[len(ys)/2]}
+}
@@ -0,0 +1,305 @@
+// Copyright 2019 Tskken. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package quadgo
+
+import (
+	"math/rand"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// randomPoints returns n random points with in the given size, half of them bunched
+// together in the top left corner.
+func randomPoints(n int, width, height float64) []Point {
+	r := rand.New(rand.NewSource(7))
+
+	points := make([]Point, n)
+	for i := range points {
+		if i%2 == 0 {
+			points[i] = Point{X: r.Float64() * width / 20, Y: r.Float64() * height / 20}
+		} else {
+			points[i] = Point{X: r.Float64() * width, Y: r.Float64() * height}
+		}
+	}
+	return points
+}
+
+// sortPoints sorts the given points by x and then y so lists of points can be compared.
+func sortPoints(points []Point) []Point {
+	sort.Slice(points, func(i, j int) bool {
+		if points[i].X == points[j].X {
+			return points[i].Y < points[j].Y
+		}
+		return points[i].X < points[j].X
+	})
+	return points
+}
+
+// checkPointTree checks that every point is on the right side of the split of each node
+// above it, that each node counts the points under it and that only full nodes are split,
+// returning the number of points in the tree.
+func checkPointTree(t *testing.T, p *PointTree) int {
+	t.Helper()
+
+	// check returns the number of points under the node, which have to be with in the given bound
+	var check func(n *pointNode, bound Bound) int
+	check = func(n *pointNode, bound Bound) int {
+		count := len(n.points)
+		for _, point := range n.points {
+			// points on a split are in the right and bottom children, so the max edges are not held
+			if point.X < bound.Min.X || point.X >= bound.Max.X || point.Y < bound.Min.Y || point.Y >= bound.Max.Y {
+				t.Errorf("pointNode at depth %v holds %v outside of %v", n.depth, point, bound)
+			}
+		}
+
+		if len(n.children) > 0 {
+			if len(n.points) > 0 {
+				t.Errorf("pointNode at depth %v holds both points and children", n.depth)
+			}
+			if n.size <= p.maxPoints {
+				t.Errorf("pointNode at depth %v is split holding %v points, want over %v", n.depth, n.size, p.maxPoints)
+			}
+
+			for i, child := range n.children {
+				b := bound
+				if i&1 == 0 {
+					b.Max.X = n.split.X
+				} else {
+					b.Min.X = n.split.X
+				}
+				if i&2 == 0 {
+					b.Max.Y = n.split.Y
+				} else {
+					b.Min.Y = n.split.Y
+				}
+				count += check(child, b)
+			}
+		} else if len(n.points) > p.maxPoints && n.depth < p.maxDepth {
+			t.Errorf("pointNode leaf at depth %v holds %v points, want at most %v", n.depth, len(n.points), p.maxPoints)
+		}
+
+		if count != n.size {
+			t.Errorf("pointNode at depth %v holds %v points, want size %v", n.depth, count, n.size)
+		}
+		return count
+	}
+
+	return check(p.root, NewBound(-1e18, -1e18, 1e18, 1e18))
+}
+
+func TestPointTree_InsertPoints(t *testing.T) {
+	p := NewPointTree(SetMaxEntities(8), SetMaxDepth(16))
+	if err := p.InsertPoints(); err == nil {
+		t.Errorf("PointTree.InsertPoints() with no points got no error")
+	}
+
+	points := randomPoints(1000, 1000, 1000)
+	for i, point := range points {
+		p.InsertPoint(point)
+		if i%100 == 0 {
+			checkPointTree(t, p)
+		}
+	}
+	checkPointTree(t, p)
+
+	if p.Len() != len(points) {
+		t.Errorf("PointTree.Len() = %v, want %v", p.Len(), len(points))
+	}
+
+	var got []Point
+	p.ForEach(func(point Point) bool {
+		got = append(got, point)
+		return true
+	})
+	if !reflect.DeepEqual(sortPoints(got), sortPoints(append([]Point(nil), points...))) {
+		t.Errorf("PointTree.ForEach() did not give every inserted point")
+	}
+}
+
+func TestPointTree_InsertPoint_same(t *testing.T) {
+	// the same point many times can not be split apart, so stops at the max depth
+	p := NewPointTree(SetMaxEntities(4), SetMaxDepth(3))
+	for i := 0; i < 20; i++ {
+		p.InsertPoint(Point{10, 10})
+	}
+	checkPointTree(t, p)
+
+	if got := p.Points(NewBound(10, 10, 10, 10)); len(got) != 20 {
+		t.Errorf("PointTree.Points() = %v points, want 20", len(got))
+	}
+}
+
+func TestBuildPointTree(t *testing.T) {
+	points := randomPoints(1000, 1000, 1000)
+	given := append([]Point(nil), points...)
+
+	p := BuildPointTree(points, SetMaxEntities(8), SetMaxDepth(16))
+	checkPointTree(t, p)
+
+	if !reflect.DeepEqual(points, given) {
+		t.Errorf("quadgo.BuildPointTree() changed the given points")
+	}
+	if p.Len() != len(points) {
+		t.Errorf("PointTree.Len() = %v, want %v", p.Len(), len(points))
+	}
+
+	// splitting at the median puts half of the points on each side of each split, even with
+	// half of them bunched in one corner
+	children := p.root.children
+	left := children[0].size + children[2].size
+	top := children[0].size + children[1].size
+	if left != len(points)/2 || top != len(points)/2 {
+		t.Errorf("quadgo.BuildPointTree() split %v points left and %v top, want %v", left, top, len(points)/2)
+	}
+
+	if got := BuildPointTree(nil); got.Len() != 0 {
+		t.Errorf("quadgo.BuildPointTree() with no points holds %v points", got.Len())
+	}
+}
+
+func TestPointTree_RemovePoint(t *testing.T) {
+	points := randomPoints(500, 1000, 1000)
+	p := BuildPointTree(points, SetMaxEntities(6), SetMaxDepth(16))
+
+	// remove in a random order checking the tree stays valid
+	order := rand.New(rand.NewSource(4)).Perm(len(points))
+	for i, j := range order {
+		if err := p.RemovePoint(points[j]); err != nil {
+			t.Fatalf("PointTree.RemovePoint() got error %v", err)
+		}
+		if p.HasPoint(points[j]) {
+			t.Errorf("PointTree.HasPoint() after removing %v = true", points[j])
+		}
+		if i%50 == 0 {
+			checkPointTree(t, p)
+		}
+	}
+
+	if p.Len() != 0 || len(p.root.children) != 0 {
+		t.Errorf("PointTree after removing every point has %v points", p.Len())
+	}
+	if err := p.RemovePoint(points[0]); err == nil {
+		t.Errorf("PointTree.RemovePoint() on empty tree got no error")
+	}
+}
+
+func TestPointTree_HasPoint(t *testing.T) {
+	points := randomPoints(200, 1000, 1000)
+	p := BuildPointTree(points, SetMaxEntities(4), SetMaxDepth(16))
+
+	for _, point := range points {
+		if !p.HasPoint(point) {
+			t.Errorf("PointTree.HasPoint(%v) = false, want true", point)
+		}
+	}
+	if p.HasPoint(Point{-1, -1}) {
+		t.Errorf("PointTree.HasPoint() of a missing point = true, want false")
+	}
+
+	p.Clear()
+	if p.Len() != 0 || p.HasPoint(points[0]) {
+		t.Errorf("PointTree.Clear() left points in the tree")
+	}
+}
+
+func TestPointTree_Points(t *testing.T) {
+	points := randomPoints(1000, 1000, 1000)
+
+	// points on the edges of the query bounds
+	points = append(points, Point{100, 100}, Point{200, 150}, Point{150, 200})
+	p := BuildPointTree(points, SetMaxEntities(8), SetMaxDepth(16))
+
+	bounds := append(randomBounds(100, 1000, 1000), NewBound(0, 0, 10, 10), NewBound(100, 100, 200, 200))
+	for _, bound := range bounds {
+		var want []Point
+		for _, point := range points {
+			if bound.containsPoint(point) {
+				want = append(want, point)
+			}
+		}
+
+		if got := p.Points(bound); !reflect.DeepEqual(sortPoints(got), sortPoints(want)) {
+			t.Errorf("PointTree.Points(%v) = %v points, want %v", bound, len(got), len(want))
+		}
+	}
+
+	count := 0
+	p.QueryFunc(NewBound(0, 0, 1000, 1000), func(Point) bool {
+		count++
+		return count < 3
+	})
+	if count != 3 {
+		t.Errorf("PointTree.QueryFunc() stopped after %v points, want 3", count)
+	}
+}
+
+func TestPointTree_Radius(t *testing.T) {
+	points := randomPoints(1000, 1000, 1000)
+	p := BuildPointTree(points, SetMaxEntities(8), SetMaxDepth(16))
+
+	tests := []struct {
+		name   string
+		center Point
+		radius float64
+	}{
+		{name: "bunched corner", center: Point{25, 25}, radius: 10},
+		{name: "middle", center: Point{500, 500}, radius: 100},
+		{name: "outside", center: Point{-100, -100}, radius: 150},
+		{name: "everything", center: Point{500, 500}, radius: 1000},
+		{name: "zero radius", center: points[0], radius: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var want []Point
+			for _, point := range points {
+				if d := sub(point, tt.center); dot(d, d) <= tt.radius*tt.radius {
+					want = append(want, point)
+				}
+			}
+
+			if got := p.Radius(tt.center, tt.radius); !reflect.DeepEqual(sortPoints(got), sortPoints(want)) {
+				t.Errorf("PointTree.Radius() = %v points, want %v", len(got), len(want))
+			}
+		})
+	}
+}
+
+// BenchmarkPointTree_Radius compares a radius search of a PointTree with QuadGo holding
+// each point as an entity with a zero size bound.
+func BenchmarkPointTree_Radius(b *testing.B) {
+	points := randomPoints(10000, 1000, 1000)
+
+	p := BuildPointTree(points, SetMaxEntities(16), SetMaxDepth(12))
+	b.Run("PointTree", func(b *testing.B) {
+		b.ReportAllocs()
+		found := 0
+		for i := 0; i < b.N; i++ {
+			p.RadiusFunc(points[i%len(points)], 20, func(Point) bool {
+				found++
+				return true
+			})
+		}
+	})
+
+	entities := make(Entities, len(points))
+	for i, point := range points {
+		entities[i] = &Entity{ID: uint64(i), Bound: NewBound(point.X, point.Y, point.X, point.Y)}
+	}
+	q := Build(1000, 1000, entities, SetMaxEntities(16), SetMaxDepth(12))
+	b.Run("QuadGo", func(b *testing.B) {
+		b.ReportAllocs()
+		found := 0
+		for i := 0; i < b.N; i++ {
+			c := points[i%len(points)]
+			q.QueryFunc(NewBound(c.X-20, c.Y-20, c.X+20, c.Y+20), func(e *Entity) bool {
+				if d := sub(e.Min, c); dot(d, d) <= 20*20 {
+					found++
+				}
+				return true
+			})
+		}
+	})
+}
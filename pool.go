@@ -0,0 +1,96 @@
+// Copyright 2019 Tskken. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package quadgo
+
+// nodePool is a free list of the nodes a QuadGo tree has collapsed or cleared, which are
+// reused for the children of the next nodes it splits. See SetNodePool().
+//
+// A nil nodePool keeps no nodes, so every node is allocated and left to the garbage collector.
+type nodePool struct {
+	free nodes
+	size int
+}
+
+// newNodePool returns a pool keeping up to the given number of nodes, or nil if it is 0.
+func newNodePool(size uint64) *nodePool {
+	if size == 0 {
+		return nil
+	}
+
+	return &nodePool{
+		free: make(nodes, 0, size),
+		size: int(size),
+	}
+}
+
+// limit returns the most nodes the pool keeps.
+func (p *nodePool) limit() uint64 {
+	if p == nil {
+		return 0
+	}
+	return uint64(p.size)
+}
+
+// get returns an empty leaf node with the given bound and depth, with room for the given
+// number of entities, reusing a node from the pool if it has one.
+func (p *nodePool) get(bound Bound, depth uint16, maxEntities int) *node {
+	if p == nil || len(p.free) == 0 {
+		return &node{
+			bound:    bound,
+			entities: make(Entities, 0, maxEntities),
+			children: make(nodes, 0, 4),
+			depth:    depth,
+		}
+	}
+
+	n := p.free[len(p.free)-1]
+	p.free[len(p.free)-1] = nil
+	p.free = p.free[:len(p.free)-1]
+
+	n.bound, n.depth = bound, depth
+
+	// the room for entities sets when a node splits, so it has to be the same as a new node
+	if cap(n.entities) < maxEntities {
+		n.entities = make(Entities, 0, maxEntities)
+	} else {
+		n.entities = n.entities[:0:maxEntities]
+	}
+	return n
+}
+
+// put adds the given leaf node to the pool, if it is not full, clearing its references to
+// its parent and entities so they are not kept alive by the pool. Nodes shared with a
+// Snapshot are left to the snapshot.
+func (p *nodePool) put(n *node) {
+	if p == nil || len(p.free) == p.size || n.shared {
+		return
+	}
+
+	entities := n.entities[:cap(n.entities)]
+	for i := range entities {
+		entities[i] = nil
+	}
+	n.entities = n.entities[:0]
+	n.children = n.children[:0]
+	n.parent = nil
+
+	p.free = append(p.free, n)
+}
+
+// release adds the given node and all of the nodes under it to the pool, other then any
+// nodes shared with a Snapshot.
+func (p *nodePool) release(n *node) {
+	if n.shared {
+		return
+	}
+
+	for i := range n.children {
+		p.release(n.children[i])
+	}
+	for i := range n.children {
+		n.children[i] = nil
+	}
+	p.put(n)
+}
@@ -0,0 +1,233 @@
+// Copyright 2019 Tskken. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package quadgo
+
+import (
+	"reflect"
+	"testing"
+)
+
+// moveEntities moves each of the given entities by dx with Remove() and InsertEntities().
+func moveEntities(t testing.TB, q *QuadGo, entities Entities, dx float64) {
+	for _, e := range entities {
+		if err := q.Remove(e); err != nil {
+			t.Fatalf("QuadGo.Remove() got error %v", err)
+		}
+		e.Bound = NewBound(e.Min.X+dx, e.Min.Y, e.Max.X+dx, e.Max.Y)
+		q.InsertEntities(e)
+	}
+}
+
+// copyEntities returns a copy of each of the given entities, so they can be moved.
+func copyEntities(entities Entities) Entities {
+	copies := make(Entities, len(entities))
+	for i, e := range entities {
+		c := *e
+		copies[i] = &c
+	}
+	return copies
+}
+
+func TestSetNodePool(t *testing.T) {
+	tests := []struct {
+		name string
+		size uint64
+		want uint64
+	}{
+		{name: "no pool", size: 0, want: 0},
+		{name: "pool", size: 64, want: 64},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := New(100, 100, SetNodePool(tt.size))
+			if got := q.pool.limit(); got != tt.want {
+				t.Errorf("quadgo.SetNodePool() pool size = %v, want %v", got, tt.want)
+			}
+			if got := q.Clone().pool.limit(); got != tt.want {
+				t.Errorf("QuadGo.Clone() pool size = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQuadGo_pool(t *testing.T) {
+	q := New(100, 100, SetMaxEntities(2), SetNodePool(4))
+	entities := Entities{
+		{ID: 1, Bound: NewBound(0, 0, 10, 10)},
+		{ID: 2, Bound: NewBound(60, 0, 70, 10)},
+		{ID: 3, Bound: NewBound(0, 60, 10, 70)},
+	}
+
+	q.InsertEntities(entities...)
+	children := append(nodes(nil), q.children...)
+
+	// collapsing keeps the children in the pool
+	q.Remove(entities[2])
+	if len(q.children) != 0 || len(q.pool.free) != 4 {
+		t.Fatalf("QuadGo.Remove() left %v children and %v pooled nodes, want 0 and 4", len(q.children), len(q.pool.free))
+	}
+	for _, n := range q.pool.free {
+		if n.parent != nil || len(n.entities) != 0 || cap(n.entities) != 2 {
+			t.Errorf("pooled node = %+v, want an empty node with no parent", n)
+		}
+	}
+
+	// splitting again takes the same nodes back out of the pool
+	q.InsertEntities(entities[2])
+	if len(q.pool.free) != 0 {
+		t.Errorf("QuadGo.InsertEntities() left %v pooled nodes, want 0", len(q.pool.free))
+	}
+	for _, c := range q.children {
+		if !containsNode(children, c) {
+			t.Errorf("QuadGo.InsertEntities() split in to a new node, want a pooled node")
+		}
+	}
+	if !checkParents(q.node) || q.Validate() != nil {
+		t.Errorf("QuadGo.InsertEntities() with pooled nodes gave a broken tree: %v", q.Validate())
+	}
+
+	// clearing fills the pool, leaving the old root to the garbage collector, and then takes
+	// the new root from it
+	q.Clear()
+	if len(q.pool.free) != 3 || !containsNode(children, q.node) {
+		t.Errorf("QuadGo.Clear() left %v pooled nodes, want 3 and a pooled root", len(q.pool.free))
+	}
+	if q.Len() != 0 || len(q.children) != 0 || q.depth != 0 || q.node.bound != q.Bounds() || cap(q.entities) != 2 {
+		t.Errorf("QuadGo.Clear() root = %+v, want an empty root", q.node)
+	}
+}
+
+// containsNode returns if the given node pointer is in the list of nodes.
+func containsNode(list nodes, n *node) bool {
+	for _, c := range list {
+		if c == n {
+			return true
+		}
+	}
+	return false
+}
+
+func TestQuadGo_pool_same(t *testing.T) {
+	// trees with and without a pool are the same after the same moves
+	entities := randomEntities(300, 1000, 1000)
+	pooled, plain := copyEntities(entities), copyEntities(entities)
+
+	p := New(1000, 1000, SetMaxEntities(4), SetMaxDepth(6), SetNodePool(32))
+	q := New(1000, 1000, SetMaxEntities(4), SetMaxDepth(6))
+	p.InsertEntities(pooled...)
+	q.InsertEntities(plain...)
+
+	for i := 0; i < 10; i++ {
+		dx := 20.0
+		if i%2 == 1 {
+			dx = -20
+		}
+		moveEntities(t, p, pooled, dx)
+		moveEntities(t, q, plain, dx)
+	}
+
+	if err := p.Validate(); err != nil {
+		t.Fatalf("QuadGo.Validate() with pool got error %v", err)
+	}
+
+	var got, want []NodeInfo
+	p.Walk(func(n NodeInfo) bool {
+		got = append(got, n)
+		return true
+	})
+	q.Walk(func(n NodeInfo) bool {
+		want = append(want, n)
+		return true
+	})
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("QuadGo with pool has %v nodes, want the same %v nodes as without", len(got), len(want))
+	}
+}
+
+func TestQuadGo_pool_branches(t *testing.T) {
+	q := New(1000, 1000, SetMaxEntities(4), SetNodePool(64))
+	entities := copyEntities(randomEntities(200, 1000, 1000))
+	q.InsertEntities(entities...)
+
+	// moving the entities collapses some nodes and fails to collapse others
+	moveEntities(t, q, entities, 10)
+	for _, e := range entities[:150] {
+		if err := q.Remove(e); err != nil {
+			t.Fatalf("QuadGo.Remove() got error %v", err)
+		}
+	}
+
+	// branch nodes keep no entities in their unused list, so the pool can not keep them alive
+	var check func(n *node)
+	check = func(n *node) {
+		if len(n.children) == 0 {
+			return
+		}
+		for _, e := range n.entities[:cap(n.entities)] {
+			if e != nil {
+				t.Errorf("branch node at depth %v keeps entity %v", n.depth, e.ID)
+			}
+		}
+		for i := range n.children {
+			check(n.children[i])
+		}
+	}
+	check(q.node)
+}
+
+func TestQuadGo_pool_allocs(t *testing.T) {
+	if debug {
+		t.Skip("the quadgodebug tag validates the tree after every change, which allocates")
+	}
+
+	q := New(1000, 1000, SetMaxEntities(8), SetMaxDepth(8), SetNodePool(256))
+	entities := copyEntities(randomEntities(1000, 1000, 1000))
+	q.InsertEntities(entities...)
+
+	// move the entities back and forth once to fill the pool
+	moveEntities(t, q, entities, 10)
+	moveEntities(t, q, entities, -10)
+
+	dx := 10.0
+	allocs := testing.AllocsPerRun(10, func() {
+		moveEntities(t, q, entities, dx)
+		dx = -dx
+	})
+	if allocs != 0 {
+		t.Errorf("QuadGo.Remove() and QuadGo.InsertEntities() with pool allocated %v times, want 0", allocs)
+	}
+}
+
+func BenchmarkQuadGo_Move(b *testing.B) {
+	entities := randomEntities(5000, 1000, 1000)
+
+	for _, size := range []uint64{0, 1024} {
+		name := "no pool"
+		if size > 0 {
+			name = "pool"
+		}
+
+		b.Run(name, func(b *testing.B) {
+			q := New(1000, 1000, SetMaxEntities(8), SetMaxDepth(8), SetNodePool(size))
+			moving := copyEntities(entities)
+			q.InsertEntities(moving...)
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				e := moving[i%len(moving)]
+				q.Remove(e)
+
+				// move the entity back and forth so it stays with in the tree
+				dx := 5.0
+				if (i/len(moving))%2 == 1 {
+					dx = -5
+				}
+				e.Bound = NewBound(e.Min.X+dx, e.Min.Y, e.Max.X+dx, e.Max.Y)
+				q.InsertEntities(e)
+			}
+		})
+	}
+}
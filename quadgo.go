@@ -16,6 +16,7 @@ package quadgo
 
 import (
 	"errors"
+	"math"
 )
 
 // Option function type for setting the options of a new tree.
@@ -23,8 +24,11 @@ type Option func(*options)
 
 // options struct which holds all the information for creating a new quad-tree with its given information.
 type options struct {
-	MaxEntities uint64
-	MaxDepth    uint16
+	MaxEntities  uint64
+	MaxDepth     uint16
+	PayloadCodec PayloadCodec
+	Observer     Observer
+	NodePool     uint64
 }
 
 // defaultOptions for QuadGo
@@ -47,11 +51,47 @@ func SetMaxDepth(maxDepth uint16) Option {
 	}
 }
 
+// SetPayloadCodec sets the PayloadCodec used to encode and decode the Data of each entity
+// when the tree is written to or read from its binary format.
+func SetPayloadCodec(codec PayloadCodec) Option {
+	return func(o *options) {
+		o.PayloadCodec = codec
+	}
+}
+
+// SetObserver sets the Observer told about the inserts, removes, splits, collapses and
+// queries of the tree.
+func SetObserver(observer Observer) Option {
+	return func(o *options) {
+		o.Observer = observer
+	}
+}
+
+// SetNodePool sets the most nodes the tree keeps to reuse after they are collapsed or
+// cleared, instead of leaving them for the garbage collector.
+//
+// Splitting a node needs four children nodes, which are taken from the pool when it has any.
+// With a pool large enough for the nodes a tree splits and collapses between, entities can be
+// moved around the tree with Remove() and InsertEntities() without allocating any memory.
+// The default of 0 keeps no nodes.
+func SetNodePool(size uint64) Option {
+	return func(o *options) {
+		o.NodePool = size
+	}
+}
+
 // QuadGo - Base quad-tree data structure.
 type QuadGo struct {
 	*node
 
-	maxDepth uint16
+	maxEntities uint64
+	maxDepth    uint16
+	codec       PayloadCodec
+	observer    Observer
+	pool        *nodePool
+
+	// size is the number of entities inserted in to the tree
+	size int
 }
 
 // New creates the basic QuadGo instance.
@@ -64,6 +104,17 @@ type QuadGo struct {
 //
 // QuadGo sets the New defaults for max depth to 5 and max entities to 10.
 func New(width, height float64, ops ...Option) *QuadGo {
+	return NewWithBound(NewBound(0, 0, width, height), ops...)
+}
+
+// NewWithBound creates the basic QuadGo instance covering the given bound.
+//
+// NewWithBound is the same as New but for trees that do not start at 0,0, such as
+// trees of map coordinates which can be negative.
+//
+// Example:
+//  quadgo.NewWithBound(quadgo.NewBound(-180, -90, 180, 90))
+func NewWithBound(bound Bound, ops ...Option) *QuadGo {
 	// copy defaults
 	o := defaultOption
 
@@ -76,13 +127,102 @@ func New(width, height float64, ops ...Option) *QuadGo {
 	return &QuadGo{
 		node: &node{
 			parent:   nil,
-			bound:    NewBound(0, 0, width, height),
+			bound:    bound,
 			entities: make(Entities, 0, o.MaxEntities),
 			children: make(nodes, 0, 4),
 			depth:    0,
 		},
-		maxDepth: o.MaxDepth,
+		maxEntities: o.MaxEntities,
+		maxDepth:    o.MaxDepth,
+		codec:       o.PayloadCodec,
+		observer:    o.Observer,
+		pool:        newNodePool(o.NodePool),
+	}
+}
+
+// Build creates a new QuadGo instance filled with the given entities.
+//
+// Build takes the same width, height and Option functions as New but constructs the
+// tree top-down in a single pass, partitioning the entities by quadrant, instead of
+// inserting them one at a time. The resulting tree is the same as inserting every entity
+// with InsertEntities() but no entity is ever moved between nodes on a split, which makes
+// it the faster option for loading large sets of entities at once.
+//
+// Build panics if any of the given entities are outside of the width and height, the same
+// as Insert(), as they would not be in any node.
+//
+// Example:
+//  quadgo.Build(800, 600, entities, SetMaxEntities(20))
+func Build(width, height float64, entities Entities, ops ...Option) *QuadGo {
+	q := New(width, height, ops...)
+	if err := q.load(entities); err != nil {
+		panic(err)
+	}
+
+	return q
+}
+
+// load fills the empty tree with the given entities, building it top-down the same as Build().
+//
+// This will return an error, leaving the tree empty, if any of the entities are outside of
+// the bounds of the tree.
+func (q *QuadGo) load(entities Entities) error {
+	if err := inside(q.bound, entities); err != nil {
+		return err
+	}
+	entities = distinctEntities(entities)
+
+	scratch := make(Entities, 0, 2*len(entities))
+	q.build(entities, q.maxDepth, &scratch, q.observer, q.pool)
+	q.size = len(entities)
+
+	if q.observer != nil {
+		for _, e := range entities {
+			q.observer.Inserted(e)
+		}
+	}
+	q.check()
+	return nil
+}
+
+// inside returns an error for the first of the given entities outside of the given tree bound.
+func inside(bound Bound, entities Entities) error {
+	for _, e := range entities {
+		if !bound.IsIntersect(e.Bound) {
+			return outsideError(e.ID, e.Min, e.Max, bound.Min, bound.Max)
+		}
 	}
+	return nil
+}
+
+// distinctEntities returns the given entities without any entities equal to one before them,
+// which are skipped the same as inserting them in to a tree would. The list of entities is only
+// copied if there are any to skip.
+func distinctEntities(entities Entities) Entities {
+	type key struct {
+		id    uint64
+		bound Bound
+	}
+	seen := make(map[key]bool, len(entities))
+	var distinct Entities
+	for i, e := range entities {
+		k := key{id: e.ID, bound: e.Bound}
+		if seen[k] {
+			if distinct == nil {
+				distinct = append(make(Entities, 0, len(entities)), entities[:i]...)
+			}
+			continue
+		}
+		seen[k] = true
+
+		if distinct != nil {
+			distinct = append(distinct, e)
+		}
+	}
+	if distinct != nil {
+		return distinct
+	}
+	return entities
 }
 
 // Insert takes the desired min and max xy points for the inserted entity.
@@ -91,27 +231,73 @@ func New(width, height float64, ops ...Option) *QuadGo {
 // the given bounds intersects with. This can mean duplicate references if the given bound
 // is large and can intersect many leaf nodes. These are Entity references which help save
 // on memory use but be aware if you insert large objects it can hinder performance.
+//
+// Insert panics if the bounds are outside of the tree, as they would not be in any node.
 func (q *QuadGo) Insert(minX, minY, maxX, maxY float64) {
-	q.insert(NewEntity(minX, minY, maxX, maxY), q.maxDepth)
+	if err := q.insertEntity(NewEntity(minX, minY, maxX, maxY)); err != nil {
+		panic(err)
+	}
+	q.check()
 }
 
 // InsertWithAction takes the desired min and max xy points for the inserted entity and an Action function.
+//
+// InsertWithAction panics if the bounds are outside of the tree, the same as Insert().
 func (q *QuadGo) InsertWithAction(minX, minY, maxX, maxY float64, action Action) {
-	q.insert(NewEntityWithAction(minX, minY, maxX, maxY, action), q.maxDepth)
+	if err := q.insertEntity(NewEntityWithAction(minX, minY, maxX, maxY, action)); err != nil {
+		panic(err)
+	}
+	q.check()
 }
 
-// InsertEntities inserts any number of entities in the quad-tree.
+// InsertEntities inserts any number of entities in the quad-tree. Any entities equal to
+// one already in the tree are skipped.
 //
-// This will return an error if you do not give it any entities.
+// This will return an error if you do not give it any entities, or if any of them are
+// outside of the tree in which case none of them are inserted.
 func (q *QuadGo) InsertEntities(entities ...*Entity) error {
 	// check for no entities given on function call
 	if len(entities) == 0 {
 		return errors.New("no entities given to QuadGo.InsertEntities()")
 	}
 
+	// check all entities before inserting any, so the tree is left as it was on an error
+	if err := inside(q.bound, entities); err != nil {
+		return err
+	}
+
 	// insert each given entities to the tree
 	for _, e := range entities {
-		q.insert(e, q.maxDepth)
+		q.insertEntity(e)
+	}
+	q.check()
+	return nil
+}
+
+// insertEntity inserts the given entity in to the tree, telling the observer of the tree.
+//
+// This will return an error if the entity is outside of the tree.
+func (q *QuadGo) insertEntity(entity *Entity) error {
+	if err := q.outside(entity); err != nil {
+		return err
+	}
+
+	q.node = q.node.writable(nil, q.pool)
+	if !q.insert(entity, q.maxDepth, q.observer, q.pool) {
+		return nil
+	}
+	q.size++
+
+	if q.observer != nil {
+		q.observer.Inserted(entity)
+	}
+	return nil
+}
+
+// outside returns an error if the given entity is outside of the tree.
+func (q *QuadGo) outside(entity *Entity) error {
+	if !q.bound.IsIntersect(entity.Bound) {
+		return outsideError(entity.ID, entity.Min, entity.Max, q.bound.Min, q.bound.Max)
 	}
 	return nil
 }
@@ -122,9 +308,65 @@ func (q *QuadGo) InsertEntities(entities ...*Entity) error {
 // uses the Entities ID and a comparison with its Bounds to confirm that the found entity is
 // in fact the entity to remove.
 //
-// This will return an error if the entity given was not found in the quad-tree.
+// This will return an error if the entity given was not found in the quad-tree, including
+// when it is outside of the tree and so in no node.
 func (q *QuadGo) Remove(entity *Entity) error {
-	return q.remove(entity)
+	q.node = q.node.writable(nil, q.pool)
+	err := q.remove(entity, q.observer, q.pool)
+	if err != nil {
+		return err
+	}
+
+	q.size--
+	if q.observer != nil {
+		q.observer.Removed(entity)
+	}
+	q.check()
+	return nil
+}
+
+// Clear removes all entities from the tree, resetting it to an empty root node
+// with the same bounds and options it was created with.
+//
+// The observer of the tree is told of each entity removed, the same as Remove(). If the tree
+// has a node pool the cleared nodes are kept in it to be reused.
+func (q *QuadGo) Clear() {
+	if q.observer != nil {
+		q.forEach(q.bound, func(e *Entity) bool {
+			q.observer.Removed(e)
+			return true
+		})
+	}
+
+	if q.pool != nil {
+		q.pool.release(q.node)
+		q.node = q.pool.get(q.bound, 0, int(q.maxEntities))
+		q.size = 0
+		return
+	}
+
+	q.node = &node{
+		parent:   nil,
+		bound:    q.bound,
+		entities: make(Entities, 0, q.maxEntities),
+		children: make(nodes, 0, 4),
+		depth:    0,
+	}
+	q.size = 0
+}
+
+// Len returns the number of entities in the tree.
+//
+// Entities which are stored in more then one leaf node are only counted once, and inserting
+// an entity equal to one already in the tree does not add it again. The count is kept as
+// entities are inserted and removed so calling Len is constant time.
+func (q *QuadGo) Len() int {
+	return q.size
+}
+
+// Bounds returns the bounds of the tree as given at creation.
+func (q *QuadGo) Bounds() Bound {
+	return q.bound
 }
 
 // Retrieve returns all entities from all nodes the given bounds intersects with.
@@ -177,6 +419,7 @@ func (q *QuadGo) IsEntity(entity *Entity) <-chan bool {
 }
 
 // IsIntersect take a bound and returns if that bound intersects any entity within the tree.
+// Entities with a Shape only intersect the bound if their Shape does.
 //
 // The return of this function is a <-channel of bool. This is due to
 // the fact that all reads are run concurrently. If You want to just wait for this
@@ -191,7 +434,12 @@ func (q *QuadGo) IsIntersect(bound Bound) <-chan bool {
 	out := make(chan bool)
 
 	go func() {
-		out <- q.retrieve(bound).isIntersect(bound)
+		hit := false
+		q.observedQuery(bound, nil, func(*Entity) bool {
+			hit = true
+			return false
+		})
+		out <- hit
 		close(out)
 	}()
 
@@ -199,7 +447,8 @@ func (q *QuadGo) IsIntersect(bound Bound) <-chan bool {
 }
 
 // Intersects takes a bound and returns all entities that the given bound intersects with.
-// If no entities were found it will return an empty list of Entities.
+// If no entities were found it will return an empty list of Entities. Entities with a Shape
+// are only returned if their Shape intersects the bound.
 //
 // The return of this function is a <-channel of Entities. This is due to
 // the fact that all reads are run concurrently. If You want to just wait for this
@@ -214,13 +463,154 @@ func (q *QuadGo) Intersects(bound Bound) <-chan Entities {
 	out := make(chan Entities)
 
 	go func() {
-		out <- q.retrieve(bound).intersects(bound)
+		var entities Entities
+		q.observedQuery(bound, nil, func(e *Entity) bool {
+			entities = append(entities, e)
+			return true
+		})
+		out <- entities
 		close(out)
 	}()
 
 	return out
 }
 
+// QueryFunc calls the given function for every entity that the given bound intersects with.
+// Entities stored in more then one leaf node are only given to the function once.
+//
+// Returning false from the given function stops the query. Entities are given to the function
+// straight from the leaf nodes as they are found so QueryFunc does not allocate, making it the
+// better option for checks like finding if any entity or the first few entities intersect a bound.
+//
+// Example:
+//  // find up to the first 5 entities that intersect bound
+//  found := 0
+//  tree.QueryFunc(bound, func(e *quadgo.Entity) bool {
+//  	found++
+//  	return found < 5
+//  })
+//
+// Unlike the other read functions QueryFunc runs on the calling go routine and returns
+// once the query is done.
+func (q *QuadGo) QueryFunc(bound Bound, fn func(*Entity) bool) {
+	q.observedQuery(bound, nil, fn)
+}
+
+// observedQuery runs a query for the given bound and hull, telling the observer of the tree
+// how much work the query took.
+func (q *QuadGo) observedQuery(bound Bound, h *hull, fn func(*Entity) bool) {
+	if q.observer == nil {
+		q.query(bound, q.bound, h, fn, nil)
+		return
+	}
+
+	var stats QueryStats
+	q.query(bound, q.bound, h, func(e *Entity) bool {
+		stats.Hits++
+		return fn(e)
+	}, &stats)
+	q.observer.Queried(bound, stats)
+}
+
+// Clone returns a copy of the tree which can be changed independently of this tree.
+//
+// The nodes of the tree are copied but the entities are not, both trees hold references
+// to the same entities. Use DeepClone to also copy the entities.
+func (q *QuadGo) Clone() *QuadGo {
+	return q.clone(func(e *Entity) *Entity {
+		return e
+	})
+}
+
+// DeepClone returns a copy of the tree and all of its entities.
+//
+// Each entity is copied once, so an entity referenced from more then one leaf node is still
+// a single entity in the new tree. Action functions are shared between the copied entities.
+func (q *QuadGo) DeepClone() *QuadGo {
+	copies := make(map[*Entity]*Entity, q.size)
+	return q.clone(func(e *Entity) *Entity {
+		c, ok := copies[e]
+		if !ok {
+			c = new(Entity)
+			*c = *e
+			copies[e] = c
+		}
+		return c
+	})
+}
+
+// clone copies the tree using the given function to copy each entity reference.
+func (q *QuadGo) clone(copyEntity func(*Entity) *Entity) *QuadGo {
+	return &QuadGo{
+		node:        q.node.clone(nil, copyEntity),
+		maxEntities: q.maxEntities,
+		maxDepth:    q.maxDepth,
+		codec:       q.codec,
+		observer:    q.observer,
+		pool:        newNodePool(q.pool.limit()),
+		size:        q.size,
+	}
+}
+
+// All returns every entity with in the tree. Entities stored in more then one
+// leaf node are only returned once.
+//
+// The return of this function is a <-channel of Entities. This is due to
+// the fact that all reads are run concurrently. If You want to just wait for this
+// function to return and block the hole time you can just call it with
+// `entities := <-quadgo.All()`. This will block until entities are returned
+// on the chan.
+func (q *QuadGo) All() <-chan Entities {
+	out := make(chan Entities)
+
+	go func() {
+		entities := make(Entities, 0, q.size)
+		q.forEach(q.bound, func(e *Entity) bool {
+			entities = append(entities, e)
+			return true
+		})
+		out <- entities
+		close(out)
+	}()
+
+	return out
+}
+
+// ForEach calls the given function for every entity with in the tree. Entities stored
+// in more then one leaf node are only given to the function once.
+//
+// Returning false from the given function stops the iteration.
+//
+// Unlike the other read functions ForEach runs on the calling go routine and returns
+// once the iteration is done.
+func (q *QuadGo) ForEach(fn func(*Entity) bool) {
+	q.forEach(q.bound, fn)
+}
+
+// NodeInfo is the information about a single node in the tree given to the
+// function passed to QuadGo.Walk().
+type NodeInfo struct {
+	// Bound is the bounds of the node.
+	Bound Bound
+	// Depth is the depth of the node with the root at 0.
+	Depth uint16
+	// Leaf is true if the node has no children nodes.
+	Leaf bool
+	// Entities is the number of entities stored in the node.
+	Entities int
+}
+
+// Walk calls the given function for every node in the tree, visiting each node
+// before its children starting at the root.
+//
+// Returning false from the given function stops the walk.
+//
+// Unlike the other read functions Walk runs on the calling go routine and returns
+// once the walk is done.
+func (q *QuadGo) Walk(fn func(NodeInfo) bool) {
+	q.walk(fn)
+}
+
 // list of nodes
 type nodes []*node
 
@@ -231,10 +621,21 @@ type node struct {
 	entities Entities
 	children nodes
 	depth    uint16
+
+	// shared is set once the node is shared with a Snapshot, after which the tree copies the
+	// node before changing it. See QuadGo.Snapshot().
+	shared bool
 }
 
-// new creates a new node instance for a given bounds taking the member node as its parent.
-func (n *node) new(bound Bound) *node {
+// new creates a new node instance for a given bounds taking the member node as its parent,
+// reusing a node from the given pool if it has one.
+func (n *node) new(bound Bound, pool *nodePool) *node {
+	if pool != nil {
+		c := pool.get(bound, n.depth+1, cap(n.entities))
+		c.parent = n
+		return c
+	}
+
 	return &node{
 		parent:   n,
 		bound:    bound,
@@ -244,26 +645,41 @@ func (n *node) new(bound Bound) *node {
 	}
 }
 
+// writable returns the node for the tree to change, which is the node itself unless it is
+// shared with a Snapshot. A shared node is copied with the given parent, taking the copy from
+// the given pool, and its children are marked as shared in turn so only the nodes along the
+// path of a change are copied.
+func (n *node) writable(parent *node, pool *nodePool) *node {
+	if !n.shared {
+		return n
+	}
+
+	c := pool.get(n.bound, n.depth, cap(n.entities))
+	c.parent = parent
+	c.entities = append(c.entities, n.entities...)
+	for i := range n.children {
+		n.children[i].shared = true
+		c.children = append(c.children, n.children[i])
+	}
+	return c
+}
+
 // retrieve finds all of the entities with in a quadrant that the given point fits with in.
 func (n *node) retrieve(bound Bound) (entities Entities) {
 	// check if you are at a leaf node
 	if len(n.children) > 0 {
-		// get all child nodes the given bounds intersects
-		nodes := n.getQuadrant(bound)
-		// panic if no nodes were returned. This is a fatel error but should never happen in any normal situation.
-		if len(nodes) == 0 {
-			panic(errors.New("could not find a node to retrive from in node.retrieve().getQuadrent()"))
-		}
-
-		// recursive call to retrieve all entities from children nodes found from getQuadrent().
-		for i := range nodes {
-			ents := nodes[i].retrieve(bound)
-			for i := range ents {
-				if !entities.Contains(ents[i]) {
-					entities = append(entities, ents[i])
+		// recursive call to retrieve all entities from the children nodes the given bounds
+		// intersects, which are none for a bound outside of the tree
+		eachChild(len(n.children), func(i int) bool {
+			return n.children[i].bound.IsIntersect(bound)
+		}, func(i int) error {
+			for _, e := range n.children[i].retrieve(bound) {
+				if !entities.Contains(e) {
+					entities = append(entities, e)
 				}
 			}
-		}
+			return nil
+		})
 		return
 	}
 
@@ -271,60 +687,113 @@ func (n *node) retrieve(bound Bound) (entities Entities) {
 	return n.entities
 }
 
-// insert inserts a given entity in to the quad-tree.
-func (n *node) insert(entity *Entity, maxDepth uint16) {
+// insert inserts a given entity in to the quad-tree, telling the given observer of any splits
+// and taking the children of any splits from the given pool.
+//
+// An entity equal to one already in the tree is not inserted again. insert returns if the
+// entity was inserted.
+func (n *node) insert(entity *Entity, maxDepth uint16, observer Observer, pool *nodePool) bool {
 	// check if you are on a leaf node
 	if len(n.children) > 0 {
-		// get all child nodes the given bounds intersects
-		nodes := n.getQuadrant(entity.Bound)
-		// panic if no nodes were returned. This is a fatel error but should never happen in any normal situation.
-		if len(nodes) == 0 {
-			panic(errors.New("could not find a node to insert in to from node.insert().getQuadrent()"))
+		// recersive insert for all child nodes the given bounds intersects
+		inserted := false
+		err := eachChild(len(n.children), func(i int) bool {
+			return n.children[i].bound.IsIntersect(entity.Bound)
+		}, func(i int) error {
+			n.children[i] = n.children[i].writable(n, pool)
+			if n.children[i].insert(entity, maxDepth, observer, pool) {
+				inserted = true
+			}
+			return nil
+		})
+		// QuadGo.insertEntity() only inserts entities with in the tree, so this should never happen
+		if err != nil {
+			panic(errors.New("could not find a node to insert in to from node.insert()"))
 		}
+		return inserted
+	}
 
-		// recersive insert for all nodes found.
-		for i := range nodes {
-			nodes[i].insert(entity, maxDepth)
-		}
-		return
+	// an entity is in every leaf it intersects, so if it is in this leaf it is already in the tree
+	if n.entities.Contains(entity) {
+		return false
 	}
 
 	// check if a split is needed
-	if len(n.entities)+1 > cap(n.entities) && n.depth < maxDepth {
-		// split node in to child nodes
-		n.split()
+	if overfull(len(n.entities)+1, cap(n.entities), n.depth, maxDepth) {
+		// split node in to child nodes and move this nodes entities to them, telling the
+		// observer once the node is a branch holding no entities the same as build()
+		n.split(pool)
+		n.moveEntities(n.entities, maxDepth, observer, pool)
+		if observer != nil {
+			observer.Split(n.info())
+		}
 
-		// move this nodes entities to the children nodes
-		n.moveEntities(append(n.entities, entity), maxDepth)
-		return
+		// insert the new entity in to the children nodes
+		return n.insert(entity, maxDepth, observer, pool)
 	}
 
 	// add Entity to node
 	n.entities = append(n.entities, entity)
+	return true
 }
 
-// remove removes the given Entity from the quadtree.
-func (n *node) remove(entity *Entity) error {
+// build fills the node top-down with the given entities, splitting while there are
+// more entities than the node can hold and the max depth has not been reached.
+//
+// scratch is used as a stack to hold the partition of entities for each child node
+// so that building a tree only allocates for the nodes themselves.
+func (n *node) build(entities Entities, maxDepth uint16, scratch *Entities, observer Observer, pool *nodePool) {
+	// check if the entities fit in this node as a leaf
+	if !overfull(len(entities), cap(n.entities), n.depth, maxDepth) {
+		n.entities = append(n.entities, entities...)
+		return
+	}
+
+	// split node in to child nodes
+	n.split(pool)
+	if observer != nil {
+		observer.Split(n.info())
+	}
+
+	// build each child node from the entities that intersect it
+	for i := range n.children {
+		start := len(*scratch)
+		for _, e := range entities {
+			if n.children[i].bound.IsIntersect(e.Bound) {
+				*scratch = append(*scratch, e)
+			}
+		}
+
+		n.children[i].build((*scratch)[start:], maxDepth, scratch, observer, pool)
+
+		// pop this child's partition off of the stack
+		*scratch = (*scratch)[:start]
+	}
+}
+
+// remove removes the given Entity from the quadtree, telling the given observer of any collapses
+// and putting the children of any collapses in to the given pool.
+func (n *node) remove(entity *Entity, observer Observer, pool *nodePool) error {
 	// check if we are on a leaf node
 	if len(n.children) > 0 {
-		// get all child nodes the given bounds intersects
-		nodes := n.getQuadrant(entity.Bound)
-		// panic if no nodes were returned. This is a fatel error but should never happen in any normal situation.
-		if len(nodes) == 0 {
-			panic(errors.New("could not find a node to remove in to from node.remove().getQuadrent()"))
+		// recersive call for all child nodes the given bounds intersects
+		err := eachChild(len(n.children), func(i int) bool {
+			return n.children[i].bound.IsIntersect(entity.Bound)
+		}, func(i int) error {
+			n.children[i] = n.children[i].writable(n, pool)
+			return n.children[i].remove(entity, observer, pool)
+		})
+		// an entity outside of the tree is in no node
+		if err == errNoChild {
+			return errors.New("could not find entity in tree to remove")
 		}
-
-		// recersive call for all nodes found to remove from
-		for i := range nodes {
-			err := nodes[i].remove(entity)
-			if err != nil {
-				return err
-			}
+		if err != nil {
+			return err
 		}
 
-		// collapse if needed if you are not at root
-		if n.parent != nil {
-			n.parent.collapse()
+		// collapse this node if its children no longer need to be split
+		if n.collapse(pool) && observer != nil {
+			observer.Collapsed(n.info())
 		}
 
 		return nil
@@ -340,18 +809,22 @@ func (n *node) remove(entity *Entity) error {
 	// replace old version fo entities with new list of entities with given entity removed
 	n.entities = entities
 
-	// collapse if needed if you are not at root
-	if n.parent != nil {
-		n.parent.collapse()
-	}
-
 	return nil
 }
 
-// collapse takes all entities from the children nodes and moves them to the parent and then removes the children.
-func (n *node) collapse() {
-	// create an Entity array to coppy the entities to
-	entities := make(Entities, 0, cap(n.entities))
+// collapse takes all entities from the children nodes and moves them to the parent and then removes
+// the children, putting them in to the given pool.
+//
+// A node is only collapsed if it is collapsible(), so the entities list of the node is only
+// changed once the collapse is certain. collapse returns if the node was collapsed.
+func (n *node) collapse(pool *nodePool) bool {
+	if !n.collapsible() {
+		return false
+	}
+
+	// copy the entities in to the unused entities list of this branch node, which has
+	// room for all of them as the node is collapsible
+	entities := n.entities[:0]
 
 	// cycle through children to find all non duplecet entities
 	for i := range n.children {
@@ -362,69 +835,197 @@ func (n *node) collapse() {
 		}
 	}
 
-	// check if collapse is needed
-	if len(entities) <= cap(n.entities) {
-		// set parent entities to list of non duplecet entities
-		n.entities = entities
+	// set parent entities to list of non duplecet entities
+	n.entities = entities
 
-		// clear children
-		n.children = n.children[:0]
+	// clear children
+	for i := range n.children {
+		pool.put(n.children[i])
+		n.children[i] = nil
 	}
+	n.children = n.children[:0]
+	return true
+}
+
+// collapsible returns if all of the nodes children are leaf nodes that together hold no
+// more then the max entities of a leaf, which is when collapse() merges them. See the
+// collapsible() rule shared with OctGo.
+func (n *node) collapsible() bool {
+	return collapsible(len(n.children), cap(n.entities), func(i int) int {
+		if len(n.children[i].children) > 0 {
+			return -1
+		}
+		return len(n.children[i].entities)
+	}, func(i, e int) bool {
+		return n.children[:i].hold(n.children[i].entities[e])
+	})
+}
+
+// hold returns if any of the nodes hold the given entity.
+func (n nodes) hold(entity *Entity) bool {
+	for i := range n {
+		if n[i].entities.Contains(entity) {
+			return true
+		}
+	}
+	return false
 }
 
 // isEntity returns if a given entity exists in the tree.
 func (n *node) isEntity(entity *Entity) bool {
 	// check if you are at a leaf
 	if len(n.children) > 0 {
-		// get all child nodes the given bounds intersects
-		nodes := n.getQuadrant(entity.Bound)
-		// panic if no nodes were returned. This is a fatel error but should never happen in any normal situation.
-		if len(nodes) == 0 {
-			panic(errors.New("could not find a node for node.isEntity().getQuadrent()"))
+		// recersive call to check the child nodes the given bounds intersects, stopping at
+		// the first one holding the entity
+		return !eachChildWhile(len(n.children), func(i int) bool {
+			return n.children[i].bound.IsIntersect(entity.Bound)
+		}, func(i int) bool {
+			return !n.children[i].isEntity(entity)
+		})
+	}
+
+	// return if given entity is contained in entities list
+	return n.entities.Contains(entity)
+}
+
+// forEach calls the given function for each entity the leaf nodes own, returning false
+// if the function stopped the iteration.
+//
+// An entity is owned by the one leaf node that owns the min point of its bounds
+// clamped to the tree bounds. This makes sure each entity is only given once without
+// having to keep track of what entities were already seen.
+func (n *node) forEach(world Bound, fn func(*Entity) bool) bool {
+	// check if you are at a leaf node
+	if len(n.children) > 0 {
+		return eachChildWhile(len(n.children), nil, func(i int) bool {
+			return n.children[i].forEach(world, fn)
+		})
+	}
+
+	for _, e := range n.entities {
+		if n.owns(world.clamp(e.Min), world) && !fn(e) {
+			return false
 		}
+	}
+	return true
+}
 
-		// recersive call to check found nodes
-		for i := range nodes {
-			if nodes[i].isEntity(entity) {
-				return true
-			}
+// query calls the given function for each entity the given bound intersects with in the
+// leaf nodes the bound intersects, returning false if the function stopped the query.
+//
+// An entity is only given from the one leaf node that owns the min point of the
+// intersection of the entity and the bound clamped to the tree bounds.
+//
+// Entities which intersect the bound are then tested against the given hull, or against the
+// bound if the hull is nil, with their precise Shape if they have one.
+//
+// If stats is not nil the nodes visited and entities tested are counted in to it.
+func (n *node) query(bound, world Bound, h *hull, fn func(*Entity) bool, stats *QueryStats) bool {
+	if stats != nil {
+		stats.Nodes++
+	}
+
+	// check if you are at a leaf node
+	if len(n.children) > 0 {
+		return eachChildWhile(len(n.children), func(i int) bool {
+			return n.children[i].bound.IsIntersect(bound)
+		}, func(i int) bool {
+			return n.children[i].query(bound, world, h, fn, stats)
+		})
+	}
+
+	for _, e := range n.entities {
+		if stats != nil {
+			stats.Entities++
+		}
+		if !e.IsIntersect(bound) {
+			continue
 		}
 
+		p := world.clamp(Point{X: math.Max(e.Min.X, bound.Min.X), Y: math.Max(e.Min.Y, bound.Min.Y)})
+		if !n.owns(p, world) || !e.narrow(bound, h) {
+			continue
+		}
+		if !fn(e) {
+			return false
+		}
+	}
+	return true
+}
+
+// owns returns if the given point with in the tree bounds falls with in this node.
+//
+// The max edges of the node are exclusive unless they are also the max edges of the
+// tree, so any point with in the tree bounds is owned by exactly one leaf node.
+func (n *node) owns(p Point, world Bound) bool {
+	return owned(p.X, n.bound.Min.X, n.bound.Max.X, world.Max.X) &&
+		owned(p.Y, n.bound.Min.Y, n.bound.Max.Y, world.Max.Y)
+}
+
+// walk calls the given function for this node and then its children, returning false
+// if the function stopped the walk.
+func (n *node) walk(fn func(NodeInfo) bool) bool {
+	if !fn(n.info()) {
 		return false
 	}
 
-	// return if given entity is contained in entities list
-	return n.entities.Contains(entity)
+	for i := range n.children {
+		if !n.children[i].walk(fn) {
+			return false
+		}
+	}
+	return true
+}
+
+// info returns the NodeInfo of the node.
+func (n *node) info() NodeInfo {
+	return NodeInfo{
+		Bound:    n.bound,
+		Depth:    n.depth,
+		Leaf:     len(n.children) == 0,
+		Entities: len(n.entities),
+	}
 }
 
-// split creates the children node for this node.
-func (n *node) split() {
-	n.children = append(n.children,
-		n.new(NewBound(n.bound.Min.X, n.bound.Min.Y, n.bound.Center.X, n.bound.Center.Y)), // Top Left child node
-		n.new(NewBound(n.bound.Center.X, n.bound.Min.Y, n.bound.Max.X, n.bound.Center.Y)), // Top Right child node
-		n.new(NewBound(n.bound.Min.X, n.bound.Center.Y, n.bound.Center.X, n.bound.Max.Y)), // Bottom Left child node
-		n.new(NewBound(n.bound.Center.X, n.bound.Center.Y, n.bound.Max.X, n.bound.Max.Y)), // Bottom Right child node
-	)
+// clone copies the node and all of its children with the given parent node, using the given
+// function to copy each entity reference.
+func (n *node) clone(parent *node, copyEntity func(*Entity) *Entity) *node {
+	c := &node{
+		parent:   parent,
+		bound:    n.bound,
+		entities: make(Entities, len(n.entities), cap(n.entities)),
+		children: make(nodes, len(n.children), 4),
+		depth:    n.depth,
+	}
+
+	for i, e := range n.entities {
+		c.entities[i] = copyEntity(e)
+	}
+	for i := range n.children {
+		c.children[i] = n.children[i].clone(c, copyEntity)
+	}
+
+	return c
+}
+
+// split creates the children node for this node, taking them from the given pool if it has any.
+func (n *node) split(pool *nodePool) {
+	for _, bound := range n.bound.quadrants() {
+		n.children = append(n.children, n.new(bound, pool))
+	}
 }
 
 // moveEntities moves the given entities to the children nodes of this node
-func (n *node) moveEntities(entities Entities, maxDepth uint16) {
+func (n *node) moveEntities(entities Entities, maxDepth uint16, observer Observer, pool *nodePool) {
 	// loop through all entities to add them to there appropriate child node
 	for _, e := range entities {
 		// get the next node that the given entity fits in and insert it
-		n.insert(e, maxDepth)
+		n.insert(e, maxDepth, observer, pool)
 	}
 
-	// clear entities for branch node
-	n.entities = n.entities[:0]
-}
-
-// getQuadrant returns the children nodes the given bound intersects with
-func (n *node) getQuadrant(bound Bound) (nodes nodes) {
-	for i := range n.children {
-		if n.children[i].bound.IsIntersect(bound) {
-			nodes = append(nodes, n.children[i])
-		}
+	// clear entities for branch node, without leaving them in the unused list to be kept alive
+	for i := range n.entities {
+		n.entities[i] = nil
 	}
-	return
+	n.entities = n.entities[:0]
 }
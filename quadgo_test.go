@@ -7,6 +7,7 @@ package quadgo
 import (
 	"errors"
 	"fmt"
+	"math/rand"
 	"reflect"
 	"testing"
 )
@@ -190,6 +191,269 @@ func TestNew(t *testing.T) {
 	}
 }
 
+func TestNewWithBound(t *testing.T) {
+	type args struct {
+		bound Bound
+		ops   []Option
+	}
+	tests := []struct {
+		name     string
+		args     args
+		entities Entities
+	}{
+		{
+			name: "bound with negative points",
+			args: args{
+				bound: NewBound(-180, -90, 180, 90),
+				ops: []Option{
+					SetMaxEntities(2),
+				},
+			},
+			entities: Entities{
+				&Entity{ID: 1, Bound: NewBound(-170, -80, -160, -70)},
+				&Entity{ID: 2, Bound: NewBound(100, 10, 120, 20)},
+				&Entity{ID: 3, Bound: NewBound(-10, -10, 10, 10)},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := NewWithBound(tt.args.bound, tt.args.ops...)
+			if !got.Bounds().IsEqual(tt.args.bound) {
+				t.Errorf("quadgo.NewWithBound() for bounds = %v, want %v", got.Bounds(), tt.args.bound)
+			}
+
+			if err := got.InsertEntities(tt.entities...); err != nil {
+				t.Errorf("quadgo.NewWithBound() got error on insert %v", err)
+			}
+			if len(got.children) != 4 || !got.children[0].bound.IsEqual(NewBound(-180, -90, 0, 0)) {
+				t.Errorf("quadgo.NewWithBound() did not split at the center of its bounds")
+			}
+			for _, e := range tt.entities {
+				if !<-got.IsEntity(e) {
+					t.Errorf("quadgo.NewWithBound() could not find %v in tree", e)
+				}
+			}
+		})
+	}
+}
+
+// randomEntities creates n entities with random bounds with in the given width and height.
+func randomEntities(n int, width, height float64) Entities {
+	r := rand.New(rand.NewSource(1))
+
+	entities := make(Entities, 0, n)
+	for i := 0; i < n; i++ {
+		x, y := r.Float64()*(width-20), r.Float64()*(height-20)
+		entities = append(entities, &Entity{
+			ID:    uint64(i + 1),
+			Bound: NewBound(x, y, x+r.Float64()*20, y+r.Float64()*20),
+		})
+	}
+
+	return entities
+}
+
+// sameNode checks if the two given nodes have the same structure and entities.
+func sameNode(lhs, rhs *node) bool {
+	if !lhs.bound.IsEqual(rhs.bound) || lhs.depth != rhs.depth ||
+		len(lhs.children) != len(rhs.children) || len(lhs.entities) != len(rhs.entities) {
+		return false
+	}
+
+	for _, e := range lhs.entities {
+		if !rhs.entities.Contains(e) {
+			return false
+		}
+	}
+
+	for i := range lhs.children {
+		if !sameNode(lhs.children[i], rhs.children[i]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func TestBuild(t *testing.T) {
+	type args struct {
+		width, height float64
+		entities      Entities
+		ops           []Option
+	}
+	tests := []struct {
+		name string
+		args args
+	}{
+		{
+			name: "build empty tree",
+			args: args{
+				width:  800,
+				height: 600,
+			},
+		},
+		{
+			name: "build with no split",
+			args: args{
+				width:    800,
+				height:   600,
+				entities: randomEntities(5, 800, 600),
+			},
+		},
+		{
+			name: "build with splits",
+			args: args{
+				width:    800,
+				height:   600,
+				entities: randomEntities(500, 800, 600),
+				ops: []Option{
+					SetMaxEntities(4),
+				},
+			},
+		},
+		{
+			name: "build with max depth",
+			args: args{
+				width:    800,
+				height:   600,
+				entities: randomEntities(500, 800, 600),
+				ops: []Option{
+					SetMaxEntities(2),
+					SetMaxDepth(2),
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Build(tt.args.width, tt.args.height, tt.args.entities, tt.args.ops...)
+
+			want := New(tt.args.width, tt.args.height, tt.args.ops...)
+			for _, e := range tt.args.entities {
+				want.insert(e, want.maxDepth, nil, nil)
+			}
+
+			if !sameNode(got.node, want.node) {
+				t.Errorf("quadgo.Build() tree does not match tree from inserting each entity")
+			}
+
+			for _, e := range tt.args.entities {
+				if !<-got.IsEntity(e) {
+					t.Errorf("quadgo.Build() could not find %v in tree", e)
+				}
+			}
+		})
+	}
+}
+
+func TestBuild_same(t *testing.T) {
+	entities := randomEntities(50, 800, 600)
+
+	// entities equal to one before them are skipped the same as inserting them
+	given := append(append(Entities{}, entities...), entities[:20]...)
+	given = append(given, &Entity{ID: entities[5].ID, Bound: entities[5].Bound})
+
+	got := Build(800, 600, given, SetMaxEntities(4))
+	want := Build(800, 600, entities, SetMaxEntities(4))
+	if got.Len() != len(entities) {
+		t.Errorf("quadgo.Build() Len() = %v, want %v", got.Len(), len(entities))
+	}
+	if !sameNode(got.node, want.node) {
+		t.Errorf("quadgo.Build() with the same entities more then once does not match tree with each once")
+	}
+	if err := got.Validate(); err != nil {
+		t.Errorf("quadgo.Build() with the same entities more then once got error from Validate() %v", err)
+	}
+}
+
+func TestQuadGo_Insert_outside(t *testing.T) {
+	outside := &Entity{ID: 100, Bound: NewBound(900, 700, 950, 750)}
+
+	tests := []struct {
+		name     string
+		entities Entities
+	}{
+		{name: "empty tree", entities: nil},
+		{name: "split tree", entities: randomEntities(20, 800, 600)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := New(800, 600, SetMaxEntities(2))
+			if len(tt.entities) > 0 {
+				q.InsertEntities(tt.entities...)
+			}
+
+			// none of the entities are inserted if any are outside of the tree
+			inside := &Entity{ID: 101, Bound: NewBound(0, 0, 10, 10)}
+			if err := q.InsertEntities(inside, outside); err == nil {
+				t.Errorf("QuadGo.InsertEntities() with an entity outside of the tree got no error")
+			}
+			if q.Len() != len(tt.entities) {
+				t.Errorf("QuadGo.Len() = %v, want %v", q.Len(), len(tt.entities))
+			}
+			if <-q.IsEntity(inside) || <-q.IsEntity(outside) {
+				t.Errorf("QuadGo.IsEntity() = true for entity given with one outside of the tree")
+			}
+
+			func() {
+				defer func() {
+					if recover() == nil {
+						t.Errorf("QuadGo.Insert() with bounds outside of the tree did not panic")
+					}
+				}()
+				q.Insert(900, 700, 950, 750)
+			}()
+			if q.Len() != len(tt.entities) {
+				t.Errorf("QuadGo.Len() = %v, want %v", q.Len(), len(tt.entities))
+			}
+			if err := q.Validate(); err != nil {
+				t.Errorf("QuadGo.Validate() got error %v", err)
+			}
+		})
+	}
+}
+
+func TestBuild_outside(t *testing.T) {
+	entities := append(randomEntities(20, 100, 100), &Entity{ID: 100, Bound: NewBound(150, 150, 160, 160)})
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Errorf("quadgo.Build() with an entity outside of the tree did not panic")
+			}
+		}()
+		Build(100, 100, entities, SetMaxEntities(4))
+	}()
+
+	q := New(100, 100, SetMaxEntities(4))
+	if err := q.load(entities); err == nil {
+		t.Errorf("QuadGo.load() with an entity outside of the tree got no error")
+	}
+	if q.Len() != 0 || len(q.children) != 0 {
+		t.Errorf("QuadGo.load() with an entity outside of the tree = %v entities, want 0", q.Len())
+	}
+}
+
+func BenchmarkBuild(b *testing.B) {
+	entities := randomEntities(10000, 8000, 6000)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		Build(8000, 6000, entities, SetMaxDepth(8))
+	}
+}
+
+func BenchmarkQuadGo_InsertEntities(b *testing.B) {
+	entities := randomEntities(10000, 8000, 6000)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		q := New(8000, 6000, SetMaxDepth(8))
+		_ = q.InsertEntities(entities...)
+	}
+}
+
 func TestQuadGo_Insert(t *testing.T) {
 	type fields struct {
 		quadgo *QuadGo
@@ -545,6 +809,17 @@ func TestQuadGo_Remove(t *testing.T) {
 			},
 			wantErr: errors.New("could not find entity in tree to remove"),
 		},
+		{
+			name: "remove entity outside of split tree error",
+			fields: fields{
+				quadgo:   New(800, 600, SetMaxEntities(2)),
+				entities: randomEntities(50, 800, 600),
+			},
+			args: args{
+				&Entity{ID: 100, Bound: NewBound(900, 700, 950, 750)},
+			},
+			wantErr: errors.New("could not find entity in tree to remove"),
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -896,3 +1171,781 @@ func TestQuadGo_Intersects(t *testing.T) {
 		})
 	}
 }
+
+func TestQuadGo_Clear(t *testing.T) {
+	type fields struct {
+		quadgo   *QuadGo
+		entities Entities
+	}
+	tests := []struct {
+		name   string
+		fields fields
+	}{
+		{
+			name: "clear empty tree",
+			fields: fields{
+				quadgo: New(800, 600),
+			},
+		},
+		{
+			name: "clear leaf root",
+			fields: fields{
+				quadgo:   New(800, 600),
+				entities: randomEntities(5, 800, 600),
+			},
+		},
+		{
+			name: "clear split tree",
+			fields: fields{
+				quadgo:   New(800, 600, SetMaxEntities(2)),
+				entities: randomEntities(100, 800, 600),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, e := range tt.fields.entities {
+				if err := tt.fields.quadgo.InsertEntities(e); err != nil {
+					t.Errorf("QuadGo.Clear() got error on insert %v", err)
+				}
+			}
+
+			tt.fields.quadgo.Clear()
+
+			if got := tt.fields.quadgo.Len(); got != 0 {
+				t.Errorf("QuadGo.Clear() Len() = %v, want 0", got)
+			}
+			if len(tt.fields.quadgo.children) != 0 || len(tt.fields.quadgo.entities) != 0 {
+				t.Errorf("QuadGo.Clear() root node not empty")
+			}
+			if cap(tt.fields.quadgo.entities) != int(tt.fields.quadgo.maxEntities) {
+				t.Errorf("QuadGo.Clear() max entities = %v, want %v", cap(tt.fields.quadgo.entities), tt.fields.quadgo.maxEntities)
+			}
+			for _, e := range tt.fields.entities {
+				if <-tt.fields.quadgo.IsEntity(e) {
+					t.Errorf("QuadGo.Clear() found entity %v after clear", e)
+				}
+			}
+
+			// the tree should split the same as a new tree after being cleared
+			want := New(800, 600, SetMaxEntities(uint64(cap(tt.fields.quadgo.entities))))
+			for _, e := range tt.fields.entities {
+				tt.fields.quadgo.insert(e, tt.fields.quadgo.maxDepth, nil, nil)
+				want.insert(e, want.maxDepth, nil, nil)
+			}
+			if !sameNode(tt.fields.quadgo.node, want.node) {
+				t.Errorf("QuadGo.Clear() tree does not match a new tree after inserting")
+			}
+		})
+	}
+}
+
+func TestQuadGo_Len(t *testing.T) {
+	type fields struct {
+		quadgo   *QuadGo
+		entities Entities
+	}
+	type args struct {
+		remove Entities
+	}
+	tests := []struct {
+		name      string
+		fields    fields
+		args      args
+		want      int
+		wantSplit bool
+	}{
+		{
+			name: "empty tree",
+			fields: fields{
+				quadgo: New(800, 600),
+			},
+			want:      0,
+			wantSplit: false,
+		},
+		{
+			name: "insert with split",
+			fields: fields{
+				quadgo: New(800, 600, SetMaxEntities(2)),
+				entities: Entities{
+					&Entity{ID: 1, Bound: NewBound(0, 0, 50, 50)},
+					&Entity{ID: 2, Bound: NewBound(500, 400, 700, 600)},
+					&Entity{ID: 3, Bound: NewBound(350, 250, 450, 350)},
+				},
+			},
+			want:      3,
+			wantSplit: true,
+		},
+		{
+			name: "remove with collapse",
+			fields: fields{
+				quadgo: New(800, 600, SetMaxEntities(2)),
+				entities: Entities{
+					&Entity{ID: 1, Bound: NewBound(0, 0, 50, 50)},
+					&Entity{ID: 2, Bound: NewBound(500, 400, 700, 600)},
+					&Entity{ID: 3, Bound: NewBound(350, 250, 450, 350)},
+				},
+			},
+			args: args{
+				remove: Entities{
+					&Entity{ID: 3, Bound: NewBound(350, 250, 450, 350)},
+				},
+			},
+			want:      2,
+			wantSplit: false,
+		},
+		{
+			name: "remove entity in all children with collapse",
+			fields: fields{
+				quadgo: New(800, 600, SetMaxEntities(2)),
+				entities: Entities{
+					&Entity{ID: 1, Bound: NewBound(350, 250, 450, 350)},
+					&Entity{ID: 2, Bound: NewBound(0, 0, 50, 50)},
+					&Entity{ID: 3, Bound: NewBound(500, 400, 700, 600)},
+				},
+			},
+			args: args{
+				remove: Entities{
+					&Entity{ID: 1, Bound: NewBound(350, 250, 450, 350)},
+				},
+			},
+			want:      2,
+			wantSplit: false,
+		},
+		{
+			name: "remove with no collapse of grand children",
+			fields: fields{
+				quadgo: New(800, 600, SetMaxEntities(2)),
+				entities: Entities{
+					&Entity{ID: 1, Bound: NewBound(0, 0, 10, 10)},
+					&Entity{ID: 2, Bound: NewBound(20, 20, 30, 30)},
+					&Entity{ID: 3, Bound: NewBound(250, 200, 260, 210)},
+					&Entity{ID: 4, Bound: NewBound(500, 400, 700, 600)},
+				},
+			},
+			args: args{
+				remove: Entities{
+					&Entity{ID: 4, Bound: NewBound(500, 400, 700, 600)},
+				},
+			},
+			want:      3,
+			wantSplit: true,
+		},
+		{
+			name: "insert same entity twice",
+			fields: fields{
+				quadgo: New(800, 600, SetMaxEntities(2)),
+				entities: Entities{
+					&Entity{ID: 1, Bound: NewBound(0, 0, 50, 50)},
+					&Entity{ID: 2, Bound: NewBound(350, 250, 450, 350)},
+					&Entity{ID: 1, Bound: NewBound(0, 0, 50, 50)},
+					&Entity{ID: 2, Bound: NewBound(350, 250, 450, 350)},
+				},
+			},
+			want:      2,
+			wantSplit: false,
+		},
+		{
+			name: "insert same entity twice with split",
+			fields: fields{
+				quadgo: New(800, 600, SetMaxEntities(2)),
+				entities: Entities{
+					&Entity{ID: 1, Bound: NewBound(0, 0, 50, 50)},
+					&Entity{ID: 2, Bound: NewBound(500, 400, 700, 600)},
+					&Entity{ID: 3, Bound: NewBound(350, 250, 450, 350)},
+					&Entity{ID: 3, Bound: NewBound(350, 250, 450, 350)},
+				},
+			},
+			args: args{
+				remove: Entities{
+					&Entity{ID: 1, Bound: NewBound(0, 0, 50, 50)},
+				},
+			},
+			want:      2,
+			wantSplit: false,
+		},
+		{
+			name: "remove all",
+			fields: fields{
+				quadgo:   New(800, 600, SetMaxEntities(2)),
+				entities: randomEntities(50, 800, 600),
+			},
+			args: args{
+				remove: randomEntities(50, 800, 600),
+			},
+			want:      0,
+			wantSplit: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, e := range tt.fields.entities {
+				if err := tt.fields.quadgo.InsertEntities(e); err != nil {
+					t.Errorf("QuadGo.Len() got error on insert %v", err)
+				}
+			}
+
+			for _, e := range tt.args.remove {
+				if err := tt.fields.quadgo.Remove(e); err != nil {
+					t.Errorf("QuadGo.Len() got error on remove %v", err)
+				}
+			}
+
+			if got := tt.fields.quadgo.Len(); got != tt.want {
+				t.Errorf("QuadGo.Len() = %v, want %v", got, tt.want)
+			}
+			if got := len(tt.fields.quadgo.children) > 0; got != tt.wantSplit {
+				t.Errorf("QuadGo.Len() tree split = %v, want %v", got, tt.wantSplit)
+			}
+
+			// every entity not removed should still be in the tree
+			for _, e := range tt.fields.entities {
+				if !tt.args.remove.Contains(e) && !<-tt.fields.quadgo.IsEntity(e) {
+					t.Errorf("QuadGo.Len() lost entity %v", e)
+				}
+			}
+		})
+	}
+}
+
+func TestQuadGo_Bounds(t *testing.T) {
+	type fields struct {
+		quadgo   *QuadGo
+		entities Entities
+	}
+	tests := []struct {
+		name   string
+		fields fields
+		want   Bound
+	}{
+		{
+			name: "empty tree",
+			fields: fields{
+				quadgo: New(800, 600),
+			},
+			want: NewBound(0, 0, 800, 600),
+		},
+		{
+			name: "split tree",
+			fields: fields{
+				quadgo:   New(800, 600, SetMaxEntities(2)),
+				entities: randomEntities(20, 800, 600),
+			},
+			want: NewBound(0, 0, 800, 600),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, e := range tt.fields.entities {
+				if err := tt.fields.quadgo.InsertEntities(e); err != nil {
+					t.Errorf("QuadGo.Bounds() got error on insert %v", err)
+				}
+			}
+
+			if got := tt.fields.quadgo.Bounds(); !got.IsEqual(tt.want) {
+				t.Errorf("QuadGo.Bounds() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQuadGo_All(t *testing.T) {
+	type fields struct {
+		quadgo   *QuadGo
+		entities Entities
+	}
+	tests := []struct {
+		name   string
+		fields fields
+	}{
+		{
+			name: "empty tree",
+			fields: fields{
+				quadgo: New(800, 600),
+			},
+		},
+		{
+			name: "leaf root",
+			fields: fields{
+				quadgo:   New(800, 600),
+				entities: randomEntities(5, 800, 600),
+			},
+		},
+		{
+			name: "split tree with duplicate references",
+			fields: fields{
+				quadgo: New(800, 600, SetMaxEntities(2)),
+				entities: append(randomEntities(200, 800, 600),
+					&Entity{ID: 1000, Bound: NewBound(0, 0, 800, 600)},
+					&Entity{ID: 1001, Bound: NewBound(-50, -50, 400, 300)},
+					&Entity{ID: 1002, Bound: NewBound(400, 300, 850, 650)},
+				),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, e := range tt.fields.entities {
+				if err := tt.fields.quadgo.InsertEntities(e); err != nil {
+					t.Errorf("QuadGo.All() got error on insert %v", err)
+				}
+			}
+
+			got := <-tt.fields.quadgo.All()
+
+			if len(got) != len(tt.fields.entities) {
+				t.Errorf("QuadGo.All() returned %v entities, want %v", len(got), len(tt.fields.entities))
+			}
+			for _, e := range tt.fields.entities {
+				if !got.Contains(e) {
+					t.Errorf("QuadGo.All() did not return %v", e)
+				}
+			}
+		})
+	}
+}
+
+func TestQuadGo_ForEach(t *testing.T) {
+	type fields struct {
+		quadgo   *QuadGo
+		entities Entities
+	}
+	type args struct {
+		stop int
+	}
+	tests := []struct {
+		name   string
+		fields fields
+		args   args
+		want   int
+	}{
+		{
+			name: "visit all",
+			fields: fields{
+				quadgo:   New(800, 600, SetMaxEntities(2)),
+				entities: randomEntities(100, 800, 600),
+			},
+			args: args{
+				stop: -1,
+			},
+			want: 100,
+		},
+		{
+			name: "stop early",
+			fields: fields{
+				quadgo:   New(800, 600, SetMaxEntities(2)),
+				entities: randomEntities(100, 800, 600),
+			},
+			args: args{
+				stop: 10,
+			},
+			want: 10,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.fields.quadgo.InsertEntities(tt.fields.entities...)
+			if err != nil {
+				t.Errorf("QuadGo.ForEach() got error on insert %v", err)
+			}
+
+			seen := make(map[*Entity]bool)
+			tt.fields.quadgo.ForEach(func(e *Entity) bool {
+				if seen[e] {
+					t.Errorf("QuadGo.ForEach() visited %v more then once", e)
+				}
+				seen[e] = true
+				return len(seen) != tt.args.stop
+			})
+
+			if len(seen) != tt.want {
+				t.Errorf("QuadGo.ForEach() visited %v entities, want %v", len(seen), tt.want)
+			}
+		})
+	}
+}
+
+func TestQuadGo_Walk(t *testing.T) {
+	type fields struct {
+		quadgo   *QuadGo
+		entities Entities
+	}
+	type args struct {
+		stop int
+	}
+	tests := []struct {
+		name       string
+		fields     fields
+		args       args
+		wantNodes  int
+		wantLeaves int
+	}{
+		{
+			name: "leaf root",
+			fields: fields{
+				quadgo:   New(800, 600),
+				entities: randomEntities(5, 800, 600),
+			},
+			args: args{
+				stop: -1,
+			},
+			wantNodes:  1,
+			wantLeaves: 1,
+		},
+		{
+			name: "split root",
+			fields: fields{
+				quadgo: New(800, 600, SetMaxEntities(2)),
+				entities: Entities{
+					&Entity{ID: 1, Bound: NewBound(0, 0, 50, 50)},
+					&Entity{ID: 2, Bound: NewBound(500, 400, 700, 600)},
+					&Entity{ID: 3, Bound: NewBound(450, 0, 500, 50)},
+				},
+			},
+			args: args{
+				stop: -1,
+			},
+			wantNodes:  5,
+			wantLeaves: 4,
+		},
+		{
+			name: "stop early",
+			fields: fields{
+				quadgo: New(800, 600, SetMaxEntities(2)),
+				entities: Entities{
+					&Entity{ID: 1, Bound: NewBound(0, 0, 50, 50)},
+					&Entity{ID: 2, Bound: NewBound(500, 400, 700, 600)},
+					&Entity{ID: 3, Bound: NewBound(450, 0, 500, 50)},
+				},
+			},
+			args: args{
+				stop: 2,
+			},
+			wantNodes:  2,
+			wantLeaves: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.fields.quadgo.InsertEntities(tt.fields.entities...)
+			if err != nil {
+				t.Errorf("QuadGo.Walk() got error on insert %v", err)
+			}
+
+			var nodes, leaves, entities int
+			tt.fields.quadgo.Walk(func(info NodeInfo) bool {
+				nodes++
+				if info.Leaf {
+					leaves++
+					entities += info.Entities
+				} else if info.Entities != 0 {
+					t.Errorf("QuadGo.Walk() branch node with %v entities", info.Entities)
+				}
+				if info.Depth > 0 && info.Bound.IsEqual(tt.fields.quadgo.Bounds()) {
+					t.Errorf("QuadGo.Walk() child node with tree bounds at depth %v", info.Depth)
+				}
+				return nodes != tt.args.stop
+			})
+
+			if nodes != tt.wantNodes {
+				t.Errorf("QuadGo.Walk() visited %v nodes, want %v", nodes, tt.wantNodes)
+			}
+			if leaves != tt.wantLeaves {
+				t.Errorf("QuadGo.Walk() visited %v leaves, want %v", leaves, tt.wantLeaves)
+			}
+			if tt.args.stop < 0 && entities < len(tt.fields.entities) {
+				t.Errorf("QuadGo.Walk() counted %v entities, want at least %v", entities, len(tt.fields.entities))
+			}
+		})
+	}
+}
+
+func TestQuadGo_QueryFunc(t *testing.T) {
+	type fields struct {
+		quadgo   *QuadGo
+		entities Entities
+	}
+	type args struct {
+		bound Bound
+		stop  int
+	}
+	tests := []struct {
+		name   string
+		fields fields
+		args   args
+		want   int
+	}{
+		{
+			name: "query leaf root",
+			fields: fields{
+				quadgo: New(800, 600),
+				entities: Entities{
+					&Entity{ID: 1, Bound: NewBound(0, 0, 50, 50)},
+					&Entity{ID: 2, Bound: NewBound(60, 60, 70, 70)},
+				},
+			},
+			args: args{
+				bound: NewBound(5, 5, 10, 10),
+				stop:  -1,
+			},
+			want: 1,
+		},
+		{
+			name: "query across children",
+			fields: fields{
+				quadgo: New(800, 600, SetMaxEntities(2)),
+				entities: Entities{
+					&Entity{ID: 1, Bound: NewBound(350, 250, 450, 350)},
+					&Entity{ID: 2, Bound: NewBound(0, 0, 50, 50)},
+					&Entity{ID: 3, Bound: NewBound(500, 400, 700, 600)},
+				},
+			},
+			args: args{
+				bound: NewBound(300, 200, 500, 400),
+				stop:  -1,
+			},
+			want: 2,
+		},
+		{
+			name: "query random tree",
+			fields: fields{
+				quadgo:   New(800, 600, SetMaxEntities(4)),
+				entities: randomEntities(500, 800, 600),
+			},
+			args: args{
+				bound: NewBound(100, 100, 400, 300),
+				stop:  -1,
+			},
+			want: -1,
+		},
+		{
+			name: "stop on first hit",
+			fields: fields{
+				quadgo:   New(800, 600, SetMaxEntities(4)),
+				entities: randomEntities(500, 800, 600),
+			},
+			args: args{
+				bound: NewBound(0, 0, 800, 600),
+				stop:  1,
+			},
+			want: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.fields.quadgo.InsertEntities(tt.fields.entities...)
+			if err != nil {
+				t.Errorf("QuadGo.QueryFunc() got error on insert %v", err)
+			}
+
+			// count the entities that intersect the bound when no count was given
+			want := tt.want
+			if want < 0 {
+				want = len(tt.fields.entities.intersects(tt.args.bound))
+			}
+
+			var got Entities
+			tt.fields.quadgo.QueryFunc(tt.args.bound, func(e *Entity) bool {
+				if got.Contains(e) {
+					t.Errorf("QuadGo.QueryFunc() returned %v more then once", e)
+				}
+				if !e.IsIntersect(tt.args.bound) {
+					t.Errorf("QuadGo.QueryFunc() returned %v which does not intersect %v", e, tt.args.bound)
+				}
+				got = append(got, e)
+				return len(got) != tt.args.stop
+			})
+
+			if len(got) != want {
+				t.Errorf("QuadGo.QueryFunc() returned %v entities, want %v", len(got), want)
+			}
+		})
+	}
+}
+
+func TestQuadGo_QueryFunc_allocs(t *testing.T) {
+	q := New(800, 600, SetMaxEntities(4))
+	if err := q.InsertEntities(randomEntities(500, 800, 600)...); err != nil {
+		t.Errorf("QuadGo.QueryFunc() got error on insert %v", err)
+	}
+
+	bound := NewBound(100, 100, 400, 300)
+	found := 0
+	fn := func(*Entity) bool {
+		found++
+		return found < 5
+	}
+
+	allocs := testing.AllocsPerRun(100, func() {
+		found = 0
+		q.QueryFunc(bound, fn)
+	})
+	if allocs != 0 {
+		t.Errorf("QuadGo.QueryFunc() allocated %v times, want 0", allocs)
+	}
+}
+
+// checkParents checks that every child of the given node has the node as its parent.
+func checkParents(n *node) bool {
+	for _, c := range n.children {
+		if c.parent != n || !checkParents(c) {
+			return false
+		}
+	}
+	return true
+}
+
+func TestQuadGo_Clone(t *testing.T) {
+	type fields struct {
+		quadgo   *QuadGo
+		entities Entities
+	}
+	type args struct {
+		insert Entities
+		remove Entities
+	}
+	tests := []struct {
+		name   string
+		fields fields
+		args   args
+	}{
+		{
+			name: "clone empty tree",
+			fields: fields{
+				quadgo: New(800, 600),
+			},
+			args: args{
+				insert: randomEntities(5, 800, 600),
+			},
+		},
+		{
+			name: "clone split tree and insert",
+			fields: fields{
+				quadgo:   New(800, 600, SetMaxEntities(2)),
+				entities: randomEntities(50, 800, 600),
+			},
+			args: args{
+				insert: Entities{
+					&Entity{ID: 100, Bound: NewBound(0, 0, 800, 600)},
+				},
+			},
+		},
+		{
+			name: "clone split tree and remove",
+			fields: fields{
+				quadgo:   New(800, 600, SetMaxEntities(2)),
+				entities: randomEntities(50, 800, 600),
+			},
+			args: args{
+				remove: randomEntities(50, 800, 600)[:40],
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, e := range tt.fields.entities {
+				if err := tt.fields.quadgo.InsertEntities(e); err != nil {
+					t.Errorf("QuadGo.Clone() got error on insert %v", err)
+				}
+			}
+
+			got := tt.fields.quadgo.Clone()
+
+			if !sameNode(got.node, tt.fields.quadgo.node) || !checkParents(got.node) {
+				t.Errorf("QuadGo.Clone() tree does not match original tree")
+			}
+			if got.Len() != tt.fields.quadgo.Len() || got.maxDepth != tt.fields.quadgo.maxDepth {
+				t.Errorf("QuadGo.Clone() = %v, %v, want %v, %v", got.Len(), got.maxDepth, tt.fields.quadgo.Len(), tt.fields.quadgo.maxDepth)
+			}
+			for _, e := range <-got.All() {
+				if !(<-tt.fields.quadgo.All()).Contains(e) {
+					t.Errorf("QuadGo.Clone() got entity %v not in original tree", e)
+				}
+			}
+
+			// changing the clone should not change the original tree
+			want := tt.fields.quadgo.DeepClone()
+			for _, e := range tt.args.insert {
+				if err := got.InsertEntities(e); err != nil {
+					t.Errorf("QuadGo.Clone() got error on insert %v", err)
+				}
+			}
+			for _, e := range tt.args.remove {
+				if err := got.Remove(e); err != nil {
+					t.Errorf("QuadGo.Clone() got error on remove %v", err)
+				}
+			}
+			if !sameNode(tt.fields.quadgo.node, want.node) {
+				t.Errorf("QuadGo.Clone() original tree changed with clone")
+			}
+		})
+	}
+}
+
+func TestQuadGo_DeepClone(t *testing.T) {
+	type fields struct {
+		quadgo   *QuadGo
+		entities Entities
+	}
+	tests := []struct {
+		name   string
+		fields fields
+	}{
+		{
+			name: "deep clone leaf root",
+			fields: fields{
+				quadgo:   New(800, 600),
+				entities: randomEntities(5, 800, 600),
+			},
+		},
+		{
+			name: "deep clone with duplicate references",
+			fields: fields{
+				quadgo: New(800, 600, SetMaxEntities(2)),
+				entities: append(randomEntities(50, 800, 600),
+					&Entity{ID: 100, Bound: NewBound(0, 0, 800, 600)},
+				),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, e := range tt.fields.entities {
+				if err := tt.fields.quadgo.InsertEntities(e); err != nil {
+					t.Errorf("QuadGo.DeepClone() got error on insert %v", err)
+				}
+			}
+
+			got := tt.fields.quadgo.DeepClone()
+
+			if !sameNode(got.node, tt.fields.quadgo.node) || !checkParents(got.node) {
+				t.Errorf("QuadGo.DeepClone() tree does not match original tree")
+			}
+
+			// every reference in the clone should be to a copied entity, with one copy per entity
+			copies := make(map[uint64]*Entity)
+			var check func(n *node)
+			check = func(n *node) {
+				for _, e := range n.entities {
+					for _, o := range tt.fields.entities {
+						if o == e {
+							t.Errorf("QuadGo.DeepClone() entity %v was not copied", e)
+						}
+					}
+					if c, ok := copies[e.ID]; ok && c != e {
+						t.Errorf("QuadGo.DeepClone() entity %v copied more then once", e)
+					}
+					copies[e.ID] = e
+				}
+				for _, c := range n.children {
+					check(c)
+				}
+			}
+			check(got.node)
+
+			if len(copies) != len(tt.fields.entities) {
+				t.Errorf("QuadGo.DeepClone() got %v entities, want %v", len(copies), len(tt.fields.entities))
+			}
+		})
+	}
+}
@@ -0,0 +1,114 @@
+// Copyright 2019 Tskken. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package quadgo
+
+import (
+	"errors"
+	"fmt"
+)
+
+// The functions in this file are the rules QuadGo and OctGo share for their nodes, which
+// split their bounds in to equal children no matter how many dimensions they have. As the
+// trees hold different bound and entity types the functions work on the index of each child,
+// the same as RTree.pack(), and leave the bounds to the functions given to them.
+
+// errNoChild is returned by eachChild when the entity intersects none of the children of a
+// node, which can only happen for an entity outside of the bounds of the tree.
+var errNoChild = errors.New("entity does not intersect any child node")
+
+// eachChild calls fn with the index of each of the given number of children of a node that
+// intersects returns true for, stopping at the first error fn returns.
+//
+// This will return errNoChild if intersects did not return true for any child.
+func eachChild(children int, intersects func(i int) bool, fn func(i int) error) error {
+	found := false
+	for i := 0; i < children; i++ {
+		if !intersects(i) {
+			continue
+		}
+
+		found = true
+		if err := fn(i); err != nil {
+			return err
+		}
+	}
+	if !found {
+		return errNoChild
+	}
+	return nil
+}
+
+// eachChildWhile calls fn with the index of each of the given number of children of a node
+// that intersects returns true for, returning false as soon as fn does. A nil intersects is
+// true for every child.
+func eachChildWhile(children int, intersects func(i int) bool, fn func(i int) bool) bool {
+	for i := 0; i < children; i++ {
+		if intersects != nil && !intersects(i) {
+			continue
+		}
+		if !fn(i) {
+			return false
+		}
+	}
+	return true
+}
+
+// collapsible returns if the given number of children of a node are all leaf nodes that
+// together hold no more then the capacity of a leaf, which is when the node is collapsed in
+// to a leaf holding their entities.
+//
+// entities returns the number of entities held by child i, or -1 if it is a branch node as
+// the entities of any grand children would be lost. heldBefore returns if entity e of child
+// i is held by any child before it, so entities held by more then one child are counted once
+// without a list of them having to be made.
+func collapsible(children, capacity int, entities func(i int) int, heldBefore func(i, e int) bool) bool {
+	if children == 0 {
+		return false
+	}
+
+	// check that all children are leaf nodes
+	for i := 0; i < children; i++ {
+		if entities(i) < 0 {
+			return false
+		}
+	}
+
+	count := 0
+	for i := 0; i < children; i++ {
+		for e, held := 0, entities(i); e < held; e++ {
+			if heldBefore(i, e) {
+				continue
+			}
+
+			// stop as soon as there are more entities then a leaf can hold
+			count++
+			if count > capacity {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// overfull returns if a leaf node at the given depth has to be split to hold the given number
+// of entities, which it does once it would hold more then its capacity until the max depth.
+func overfull(entities, capacity int, depth, maxDepth uint16) bool {
+	return entities > capacity && depth < maxDepth
+}
+
+// owned returns if the given value along one axis of the tree falls with in the given range
+// of a node along that axis.
+//
+// The max of the node is exclusive unless it is also the max of the tree, so any point with
+// in the tree bounds is owned by exactly one leaf node.
+func owned(v, min, max, worldMax float64) bool {
+	return v >= min && (v < max || max == worldMax)
+}
+
+// outsideError returns the error for the entity with the given ID and min and max points
+// being outside of the tree with the given min and max points.
+func outsideError(id uint64, min, max, worldMin, worldMax fmt.Stringer) error {
+	return fmt.Errorf("entity %v from %v to %v is outside of the tree from %v to %v", id, min, max, worldMin, worldMax)
+}
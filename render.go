@@ -0,0 +1,105 @@
+// Copyright 2019 Tskken. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package quadgo
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+	"math"
+)
+
+// Render draws the tree to a new image of the given size in pixels.
+//
+// The image is drawn the same way as WriteSVG(), with each node filled by the color of its
+// depth, each entity outlined and the entities hit by each given query filled in. The
+// bounds of the tree are scaled to fill the whole image.
+func (q *QuadGo) Render(width, height int, queries ...Bound) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	r := renderer{
+		img:    img,
+		world:  q.bound,
+		scaleX: float64(width) / (q.bound.Max.X - q.bound.Min.X),
+		scaleY: float64(height) / (q.bound.Max.Y - q.bound.Min.Y),
+	}
+
+	// find all entities hit by the queries
+	hits := make(map[*Entity]bool)
+	for _, query := range queries {
+		q.query(query, q.bound, nil, func(e *Entity) bool {
+			hits[e] = true
+			return true
+		}, nil)
+	}
+
+	// draw nodes from the root down so children are drawn over their parents
+	q.walk(func(info NodeInfo) bool {
+		rect := r.rect(info.Bound)
+		draw.Draw(img, rect, image.NewUniform(depthColor(info.Depth, q.maxDepth)), image.Point{}, draw.Src)
+		r.outline(rect, nodeStrokeColor)
+		return true
+	})
+
+	// draw each entity once, filling the ones hit by a query
+	fill := image.NewUniform(color.NRGBA{R: hitColor.R, G: hitColor.G, B: hitColor.B, A: 0x66})
+	q.forEach(q.bound, func(e *Entity) bool {
+		rect := r.rect(e.Bound)
+		if hits[e] {
+			draw.Draw(img, rect, fill, image.Point{}, draw.Over)
+			r.outline(rect, hitColor)
+		} else {
+			r.outline(rect, entityColor)
+		}
+		return true
+	})
+
+	// draw the queries over everything else
+	for _, query := range queries {
+		r.outline(r.rect(query), queryColor)
+	}
+
+	return img
+}
+
+// WritePNG draws the tree to the given writer as a PNG image of the given size in pixels.
+// See Render().
+func (q *QuadGo) WritePNG(w io.Writer, width, height int, queries ...Bound) error {
+	return png.Encode(w, q.Render(width, height, queries...))
+}
+
+// renderer draws bounds of a tree on to an image.
+type renderer struct {
+	img            *image.RGBA
+	world          Bound
+	scaleX, scaleY float64
+}
+
+// rect returns the pixels covered by the given bound, which is always at least one pixel.
+func (r *renderer) rect(b Bound) image.Rectangle {
+	rect := image.Rect(
+		int(math.Floor((b.Min.X-r.world.Min.X)*r.scaleX)),
+		int(math.Floor((b.Min.Y-r.world.Min.Y)*r.scaleY)),
+		int(math.Ceil((b.Max.X-r.world.Min.X)*r.scaleX)),
+		int(math.Ceil((b.Max.Y-r.world.Min.Y)*r.scaleY)),
+	)
+	if rect.Dx() == 0 {
+		rect.Max.X++
+	}
+	if rect.Dy() == 0 {
+		rect.Max.Y++
+	}
+	return rect
+}
+
+// outline draws a one pixel border on the inside of the given rectangle.
+func (r *renderer) outline(rect image.Rectangle, c color.RGBA) {
+	u := image.NewUniform(c)
+	draw.Draw(r.img, image.Rect(rect.Min.X, rect.Min.Y, rect.Max.X, rect.Min.Y+1), u, image.Point{}, draw.Src)
+	draw.Draw(r.img, image.Rect(rect.Min.X, rect.Max.Y-1, rect.Max.X, rect.Max.Y), u, image.Point{}, draw.Src)
+	draw.Draw(r.img, image.Rect(rect.Min.X, rect.Min.Y, rect.Min.X+1, rect.Max.Y), u, image.Point{}, draw.Src)
+	draw.Draw(r.img, image.Rect(rect.Max.X-1, rect.Min.Y, rect.Max.X, rect.Max.Y), u, image.Point{}, draw.Src)
+}
@@ -0,0 +1,171 @@
+// Copyright 2019 Tskken. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package quadgo
+
+import (
+	"bytes"
+	"flag"
+	"image"
+	"image/png"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// update rewrites the golden images with the current output.
+//
+//  go test -run TestQuadGo_Render -update
+var update = flag.Bool("update", false, "update golden images in testdata/golden")
+
+// checkGolden compares the given image to the golden image of the given name, writing the
+// image next to the golden image on a mismatch so the two can be compared.
+func checkGolden(t *testing.T, name string, got *image.RGBA) {
+	t.Helper()
+
+	path := filepath.Join("testdata", "golden", name+".png")
+	if *update {
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, got); err != nil {
+			t.Fatalf("could not encode %v: %v", path, err)
+		}
+		if err := ioutil.WriteFile(path, buf.Bytes(), 0644); err != nil {
+			t.Fatalf("could not write %v: %v", path, err)
+		}
+		return
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("could not open golden image, run with -update to create it: %v", err)
+	}
+	defer f.Close()
+
+	want, err := png.Decode(f)
+	if err != nil {
+		t.Fatalf("could not decode %v: %v", path, err)
+	}
+
+	if diff := imageDiff(got, want); diff > 0 {
+		gotPath := filepath.Join("testdata", "golden", name+".got.png")
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, got); err == nil {
+			ioutil.WriteFile(gotPath, buf.Bytes(), 0644)
+		}
+		t.Errorf("image differs from %v by %v pixels, see %v", path, diff, gotPath)
+	}
+}
+
+// imageDiff returns the number of pixels that differ between the two images.
+func imageDiff(lhs, rhs image.Image) int {
+	if !lhs.Bounds().Eq(rhs.Bounds()) {
+		return lhs.Bounds().Dx() * lhs.Bounds().Dy()
+	}
+
+	diff := 0
+	b := lhs.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			r1, g1, b1, a1 := lhs.At(x, y).RGBA()
+			r2, g2, b2, a2 := rhs.At(x, y).RGBA()
+			if r1 != r2 || g1 != g2 || b1 != b2 || a1 != a2 {
+				diff++
+			}
+		}
+	}
+	return diff
+}
+
+func TestQuadGo_Render(t *testing.T) {
+	entities := randomEntities(40, 100, 100)
+
+	tests := []struct {
+		name    string
+		tree    func() *QuadGo
+		queries []Bound
+	}{
+		{
+			name: "empty",
+			tree: func() *QuadGo {
+				return New(100, 100)
+			},
+		},
+		{
+			name: "split",
+			tree: func() *QuadGo {
+				return Build(100, 100, entities, SetMaxEntities(3))
+			},
+		},
+		{
+			name: "collapsed",
+			tree: func() *QuadGo {
+				q := Build(100, 100, entities, SetMaxEntities(3))
+				for _, e := range entities[:36] {
+					if err := q.Remove(e); err != nil {
+						t.Fatalf("QuadGo.Remove() got error %v", err)
+					}
+				}
+				return q
+			},
+		},
+		{
+			name: "query",
+			tree: func() *QuadGo {
+				return Build(100, 100, entities, SetMaxEntities(3))
+			},
+			queries: []Bound{NewBound(20, 20, 60, 45)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkGolden(t, "render_"+tt.name, tt.tree().Render(200, 200, tt.queries...))
+		})
+	}
+}
+
+func TestQuadGo_WritePNG(t *testing.T) {
+	q := Build(100, 100, randomEntities(40, 100, 100), SetMaxEntities(3))
+
+	var buf bytes.Buffer
+	if err := q.WritePNG(&buf, 64, 32); err != nil {
+		t.Fatalf("QuadGo.WritePNG() got error %v", err)
+	}
+
+	img, err := png.Decode(&buf)
+	if err != nil {
+		t.Fatalf("could not decode png: %v", err)
+	}
+	if want := image.Rect(0, 0, 64, 32); !img.Bounds().Eq(want) {
+		t.Errorf("QuadGo.WritePNG() bounds = %v, want %v", img.Bounds(), want)
+	}
+	if diff := imageDiff(img, q.Render(64, 32)); diff > 0 {
+		t.Errorf("QuadGo.WritePNG() differs from QuadGo.Render() by %v pixels", diff)
+	}
+}
+
+func Test_renderer_rect(t *testing.T) {
+	r := renderer{
+		world:  NewBound(-50, 0, 50, 100),
+		scaleX: 2,
+		scaleY: 2,
+	}
+
+	tests := []struct {
+		name  string
+		bound Bound
+		want  image.Rectangle
+	}{
+		{name: "whole world", bound: NewBound(-50, 0, 50, 100), want: image.Rect(0, 0, 200, 200)},
+		{name: "fractional bound", bound: NewBound(-49.8, 0.2, -45.1, 10.6), want: image.Rect(0, 0, 10, 22)},
+		{name: "point", bound: NewBound(0, 50, 0, 50), want: image.Rect(100, 100, 101, 101)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := r.rect(tt.bound); !got.Eq(tt.want) {
+				t.Errorf("renderer.rect() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
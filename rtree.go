@@ -0,0 +1,615 @@
+// Copyright 2019 Tskken. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package quadgo
+
+import (
+	"container/heap"
+	"errors"
+	"math"
+	"sort"
+)
+
+// RTree is an R-tree of entities, a SpatialIndex which groups entities in to nodes by how
+// close they are instead of splitting space in to set quadrants.
+//
+// Each node of an RTree is the bound of the entities or nodes it holds, so unlike QuadGo every
+// entity is held by exactly one leaf no matter how large it is. This makes an RTree the better
+// option for static level geometry with many large or overlapping entities. An RTree built from
+// all of its entities at once with BuildRTree() is packed far tighter then one built with
+// inserts, so it is best built once and then only read from.
+//
+// RTree has the same read functions as QuadGo.
+type RTree struct {
+	root *rNode
+
+	// maxEntries and minEntries are the most and least entries held by each node other then the root
+	maxEntries int
+	minEntries int
+
+	// size is the number of entities inserted in to the tree
+	size int
+}
+
+// rNode is a node of an RTree, holding either entities if it is a leaf or children nodes.
+type rNode struct {
+	bound    Bound
+	leaf     bool
+	entities Entities
+	children []*rNode
+}
+
+// NewRTree creates an empty RTree.
+//
+// The max entities Option sets the most entries held by each node, with a min of 2. The max
+// depth Option has no effect as the depth of an RTree is set by the number of entities it holds.
+func NewRTree(ops ...Option) *RTree {
+	// copy defaults
+	o := defaultOption
+
+	// update for any given options
+	for _, op := range ops {
+		op(&o)
+	}
+
+	maxEntries := int(o.MaxEntities)
+	if maxEntries < 2 {
+		maxEntries = 2
+	}
+
+	return &RTree{
+		root:       &rNode{leaf: true},
+		maxEntries: maxEntries,
+		minEntries: int(math.Max(1, math.Floor(float64(maxEntries)*0.4))),
+	}
+}
+
+// BuildRTree creates a new RTree filled with the given entities.
+//
+// BuildRTree packs the entities using Sort-Tile-Recursive, sorting them in to vertical slices
+// by x and then in to leaves by y, and then packing the nodes the same way up to the root.
+// Every node but the last of each level is full, giving the smallest tree with the least
+// overlap between nodes, which makes it the best option for static level geometry. Entities
+// equal to one before them are skipped, the same as inserting them would.
+//
+// Example:
+//  walls := quadgo.BuildRTree(level.Walls, quadgo.SetMaxEntities(16))
+func BuildRTree(entities Entities, ops ...Option) *RTree {
+	r := NewRTree(ops...)
+	entities = distinctEntities(entities)
+	if len(entities) == 0 {
+		return r
+	}
+
+	// copy the entities so sorting them leaves the given list as it was
+	leaves := make(Entities, len(entities))
+	copy(leaves, entities)
+
+	var nodes []*rNode
+	r.pack(len(leaves), func(i int) Bound { return leaves[i].Bound }, func(i, j int) {
+		leaves[i], leaves[j] = leaves[j], leaves[i]
+	}, func(start, end int) {
+		n := &rNode{leaf: true, entities: append(Entities(nil), leaves[start:end]...)}
+		n.refresh()
+		nodes = append(nodes, n)
+	})
+
+	// pack each level of nodes in to the level above until only the root is left
+	for len(nodes) > 1 {
+		level := nodes
+		nodes = nil
+		r.pack(len(level), func(i int) Bound { return level[i].bound }, func(i, j int) {
+			level[i], level[j] = level[j], level[i]
+		}, func(start, end int) {
+			n := &rNode{children: append([]*rNode(nil), level[start:end]...)}
+			n.refresh()
+			nodes = append(nodes, n)
+		})
+	}
+
+	r.root = nodes[0]
+	r.size = len(entities)
+	return r
+}
+
+// pack sorts n entries by the Sort-Tile-Recursive order and calls group for each run of
+// entries which make a node. bound returns the bound of the i'th entry and swap swaps two entries.
+func (r *RTree) pack(n int, bound func(i int) Bound, swap func(i, j int), group func(start, end int)) {
+	nodes := int(math.Ceil(float64(n) / float64(r.maxEntries)))
+	slices := int(math.Ceil(math.Sqrt(float64(nodes))))
+	sliceSize := slices * r.maxEntries
+
+	sort.Sort(rSorter{n: n, swap: swap, less: func(i, j int) bool { return bound(i).Center.X < bound(j).Center.X }})
+
+	for start := 0; start < n; start += sliceSize {
+		end := minInt(start+sliceSize, n)
+
+		sort.Sort(rSorter{n: end - start, swap: func(i, j int) { swap(start+i, start+j) }, less: func(i, j int) bool {
+			return bound(start+i).Center.Y < bound(start+j).Center.Y
+		}})
+
+		for i := start; i < end; i += r.maxEntries {
+			group(i, minInt(i+r.maxEntries, end))
+		}
+	}
+}
+
+// rSorter sorts the entries of an RTree being packed.
+type rSorter struct {
+	n    int
+	swap func(i, j int)
+	less func(i, j int) bool
+}
+
+func (s rSorter) Len() int           { return s.n }
+func (s rSorter) Less(i, j int) bool { return s.less(i, j) }
+func (s rSorter) Swap(i, j int)      { s.swap(i, j) }
+
+// Insert takes the desired min and max xy points for the inserted entity.
+func (r *RTree) Insert(minX, minY, maxX, maxY float64) {
+	r.insertEntity(NewEntity(minX, minY, maxX, maxY))
+}
+
+// InsertEntities inserts any number of entities in to the tree. Any entities equal to one
+// already in the tree are skipped.
+//
+// Each entity is added to the leaf whose bound would grow the least to hold it, splitting
+// nodes that become too full. This will return an error if you do not give it any entities.
+func (r *RTree) InsertEntities(entities ...*Entity) error {
+	if len(entities) == 0 {
+		return errors.New("no entities given to RTree.InsertEntities()")
+	}
+
+	for _, e := range entities {
+		r.insertEntity(e)
+	}
+	return nil
+}
+
+// insertEntity inserts the given entity, growing the tree by a new root if the root splits.
+// An entity equal to one already in the tree is not inserted again.
+func (r *RTree) insertEntity(entity *Entity) {
+	if r.root.isEntity(entity) {
+		return
+	}
+
+	r.insert(entity)
+	r.size++
+}
+
+// insert adds the entity to the tree without counting it.
+func (r *RTree) insert(entity *Entity) {
+	if sibling := r.root.insert(entity, r.maxEntries, r.minEntries); sibling != nil {
+		root := &rNode{children: []*rNode{r.root, sibling}}
+		root.refresh()
+		r.root = root
+	}
+}
+
+// Remove removes the given Entity from the tree.
+//
+// The given entity has to have the same ID and Bounds as the one to remove. Nodes left with
+// too few entries are removed and their entities inserted again. This will return an error
+// if the entity given was not found in the tree.
+func (r *RTree) Remove(entity *Entity) error {
+	found, orphans := r.root.remove(entity, r.minEntries)
+	if !found {
+		return errors.New("could not find entity in tree to remove")
+	}
+	r.size--
+
+	// shrink the tree while the root only has one child
+	for !r.root.leaf && len(r.root.children) == 1 {
+		r.root = r.root.children[0]
+	}
+	if !r.root.leaf && len(r.root.children) == 0 {
+		r.root = &rNode{leaf: true}
+	}
+
+	for _, e := range orphans {
+		r.insert(e)
+	}
+	return nil
+}
+
+// Clear removes all entities from the tree.
+func (r *RTree) Clear() {
+	r.root = &rNode{leaf: true}
+	r.size = 0
+}
+
+// Len returns the number of entities in the tree.
+func (r *RTree) Len() int {
+	return r.size
+}
+
+// Bounds returns the bound covering every entity in the tree.
+func (r *RTree) Bounds() Bound {
+	return r.root.bound
+}
+
+// Retrieve returns all entities from all leaf nodes the given bounds intersects with.
+// See QuadGo.Retrieve().
+func (r *RTree) Retrieve(bound Bound) <-chan Entities {
+	out := make(chan Entities)
+
+	go func() {
+		var entities Entities
+		r.root.retrieve(bound, &entities)
+		out <- entities
+		close(out)
+	}()
+
+	return out
+}
+
+// IsEntity checks if a given entity exists within the tree. See QuadGo.IsEntity().
+func (r *RTree) IsEntity(entity *Entity) <-chan bool {
+	out := make(chan bool)
+
+	go func() {
+		out <- r.root.isEntity(entity)
+		close(out)
+	}()
+
+	return out
+}
+
+// IsIntersect take a bound and returns if that bound intersects any entity within the tree.
+// See QuadGo.IsIntersect().
+func (r *RTree) IsIntersect(bound Bound) <-chan bool {
+	out := make(chan bool)
+
+	go func() {
+		hit := false
+		r.QueryFunc(bound, func(*Entity) bool {
+			hit = true
+			return false
+		})
+		out <- hit
+		close(out)
+	}()
+
+	return out
+}
+
+// Intersects takes a bound and returns all entities that the given bound intersects with.
+// See QuadGo.Intersects().
+func (r *RTree) Intersects(bound Bound) <-chan Entities {
+	out := make(chan Entities)
+
+	go func() {
+		var entities Entities
+		r.QueryFunc(bound, func(e *Entity) bool {
+			entities = append(entities, e)
+			return true
+		})
+		out <- entities
+		close(out)
+	}()
+
+	return out
+}
+
+// QueryFunc calls the given function for every entity that the given bound intersects with.
+// As each entity is held by one leaf, every entity is only given once.
+//
+// Returning false from the given function stops the query. See QuadGo.QueryFunc().
+func (r *RTree) QueryFunc(bound Bound, fn func(*Entity) bool) {
+	r.root.query(bound, func(e *Entity) bool {
+		if !e.narrow(bound, nil) {
+			return true
+		}
+		return fn(e)
+	})
+}
+
+// Nearest returns up to k entities in the tree closest to the given point, closest first.
+// See QuadGo.Nearest().
+func (r *RTree) Nearest(point Point, k int) Entities {
+	if k <= 0 || r.size == 0 {
+		return nil
+	}
+
+	found := make(Entities, 0, k)
+	queue := nearestQueue{{dist: r.root.bound.distance(point), node: r.root}}
+	for len(queue) > 0 && len(found) < k {
+		item := heap.Pop(&queue).(nearestItem)
+		if item.entity != nil {
+			found = append(found, item.entity)
+			continue
+		}
+
+		n := item.node.(*rNode)
+		for _, child := range n.children {
+			heap.Push(&queue, nearestItem{dist: child.bound.distance(point), node: child})
+		}
+		for _, e := range n.entities {
+			heap.Push(&queue, nearestItem{dist: e.Bound.distance(point), entity: e})
+		}
+	}
+
+	return found
+}
+
+// ForEach calls the given function for every entity with in the tree, stopping if the
+// function returns false. See QuadGo.ForEach().
+func (r *RTree) ForEach(fn func(*Entity) bool) {
+	r.root.forEach(fn)
+}
+
+// len returns the number of entries the node holds.
+func (n *rNode) len() int {
+	if n.leaf {
+		return len(n.entities)
+	}
+	return len(n.children)
+}
+
+// refresh sets the bound of the node to cover all of its entries.
+func (n *rNode) refresh() {
+	if n.len() == 0 {
+		n.bound = Bound{}
+		return
+	}
+
+	if n.leaf {
+		n.bound = n.entities[0].Bound
+		for _, e := range n.entities[1:] {
+			n.bound = n.bound.union(e.Bound)
+		}
+		return
+	}
+
+	n.bound = n.children[0].bound
+	for _, child := range n.children[1:] {
+		n.bound = n.bound.union(child.bound)
+	}
+}
+
+// insert adds the entity to the leaf under this node whose bound would grow the least,
+// returning the new sibling of this node if it had to be split.
+func (n *rNode) insert(entity *Entity, maxEntries, minEntries int) *rNode {
+	empty := n.len() == 0
+	if n.leaf {
+		n.entities = append(n.entities, entity)
+	} else {
+		child := n.choose(entity.Bound)
+		if sibling := child.insert(entity, maxEntries, minEntries); sibling != nil {
+			n.children = append(n.children, sibling)
+		}
+	}
+
+	if n.len() > maxEntries {
+		return n.split(minEntries)
+	}
+
+	if empty {
+		n.bound = entity.Bound
+	} else {
+		n.bound = n.bound.union(entity.Bound)
+	}
+	return nil
+}
+
+// choose returns the child that would grow the least to hold the given bound, picking the
+// smallest child if more then one would grow the same.
+func (n *rNode) choose(bound Bound) *rNode {
+	best := n.children[0]
+	bestGrowth, bestArea := math.Inf(1), math.Inf(1)
+	for _, child := range n.children {
+		area := child.bound.area()
+		growth := child.bound.union(bound).area() - area
+		if growth < bestGrowth || (growth == bestGrowth && area < bestArea) {
+			best, bestGrowth, bestArea = child, growth, area
+		}
+	}
+	return best
+}
+
+// split splits the entries of the node in to two groups with Guttman's quadratic split,
+// keeping the first in this node and returning a new node holding the second.
+func (n *rNode) split(minEntries int) *rNode {
+	bounds := make([]Bound, n.len())
+	for i := range bounds {
+		if n.leaf {
+			bounds[i] = n.entities[i].Bound
+		} else {
+			bounds[i] = n.children[i].bound
+		}
+	}
+
+	a, b := quadraticSplit(bounds, minEntries)
+
+	sibling := &rNode{leaf: n.leaf}
+	if n.leaf {
+		entities := n.entities
+		n.entities = make(Entities, 0, len(a))
+		for _, i := range a {
+			n.entities = append(n.entities, entities[i])
+		}
+		for _, i := range b {
+			sibling.entities = append(sibling.entities, entities[i])
+		}
+	} else {
+		children := n.children
+		n.children = make([]*rNode, 0, len(a))
+		for _, i := range a {
+			n.children = append(n.children, children[i])
+		}
+		for _, i := range b {
+			sibling.children = append(sibling.children, children[i])
+		}
+	}
+
+	n.refresh()
+	sibling.refresh()
+	return sibling
+}
+
+// quadraticSplit splits the given bounds in to two groups of at least minEntries each,
+// returning the indexes of the bounds in each group.
+//
+// The two bounds that would waste the most area together start the groups, then each
+// remaining bound is added to the group it would grow the least, picking the bound with
+// the most preference for one group first.
+func quadraticSplit(bounds []Bound, minEntries int) ([]int, []int) {
+	// pick the two seeds that waste the most area if grouped together
+	seedA, seedB, worst := 0, 1, math.Inf(-1)
+	for i := range bounds {
+		for j := i + 1; j < len(bounds); j++ {
+			if d := bounds[i].union(bounds[j]).area() - bounds[i].area() - bounds[j].area(); d > worst {
+				seedA, seedB, worst = i, j, d
+			}
+		}
+	}
+
+	a, b := []int{seedA}, []int{seedB}
+	boundA, boundB := bounds[seedA], bounds[seedB]
+
+	assigned := make([]bool, len(bounds))
+	assigned[seedA], assigned[seedB] = true, true
+	for left := len(bounds) - 2; left > 0; left-- {
+		// give all remaining bounds to a group if it needs them to reach the min
+		if len(a)+left == minEntries || len(b)+left == minEntries {
+			for i := range bounds {
+				if assigned[i] {
+					continue
+				}
+				if len(a) < minEntries {
+					a, boundA = append(a, i), boundA.union(bounds[i])
+				} else {
+					b, boundB = append(b, i), boundB.union(bounds[i])
+				}
+				assigned[i] = true
+			}
+			break
+		}
+
+		// pick the bound with the biggest difference in growth between the groups
+		next, growA, growB, diff := -1, 0.0, 0.0, math.Inf(-1)
+		for i := range bounds {
+			if assigned[i] {
+				continue
+			}
+			ga := boundA.union(bounds[i]).area() - boundA.area()
+			gb := boundB.union(bounds[i]).area() - boundB.area()
+			if d := math.Abs(ga - gb); d > diff {
+				next, growA, growB, diff = i, ga, gb, d
+			}
+		}
+
+		assigned[next] = true
+		if growA < growB || (growA == growB && len(a) <= len(b)) {
+			a, boundA = append(a, next), boundA.union(bounds[next])
+		} else {
+			b, boundB = append(b, next), boundB.union(bounds[next])
+		}
+	}
+
+	return a, b
+}
+
+// remove removes the entity from under this node, returning if it was found and the
+// entities of any nodes removed for having less then minEntries entries.
+func (n *rNode) remove(entity *Entity, minEntries int) (bool, Entities) {
+	if n.leaf {
+		entities, err := n.entities.FindAndRemove(entity)
+		if err != nil {
+			return false, nil
+		}
+		n.entities = entities
+		n.refresh()
+		return true, nil
+	}
+
+	for i, child := range n.children {
+		if !child.bound.IsIntersect(entity.Bound) {
+			continue
+		}
+
+		found, orphans := child.remove(entity, minEntries)
+		if !found {
+			continue
+		}
+
+		// remove the child if it is too small, keeping its entities to insert again
+		if child.len() < minEntries {
+			n.children = append(n.children[:i], n.children[i+1:]...)
+			child.forEach(func(e *Entity) bool {
+				orphans = append(orphans, e)
+				return true
+			})
+		}
+		n.refresh()
+		return true, orphans
+	}
+
+	return false, nil
+}
+
+// retrieve appends the entities of every leaf under this node the given bound intersects.
+func (n *rNode) retrieve(bound Bound, entities *Entities) {
+	if n.len() == 0 || !n.bound.IsIntersect(bound) {
+		return
+	}
+
+	if n.leaf {
+		*entities = append(*entities, n.entities...)
+		return
+	}
+	for _, child := range n.children {
+		child.retrieve(bound, entities)
+	}
+}
+
+// query calls the given function for each entity under this node the given bound intersects,
+// returning false if the function stopped the query.
+func (n *rNode) query(bound Bound, fn func(*Entity) bool) bool {
+	if n.len() == 0 || !n.bound.IsIntersect(bound) {
+		return true
+	}
+
+	if n.leaf {
+		for _, e := range n.entities {
+			if e.IsIntersect(bound) && !fn(e) {
+				return false
+			}
+		}
+		return true
+	}
+
+	for _, child := range n.children {
+		if !child.query(bound, fn) {
+			return false
+		}
+	}
+	return true
+}
+
+// isEntity returns if the given entity is under this node.
+func (n *rNode) isEntity(entity *Entity) bool {
+	found := false
+	n.query(entity.Bound, func(e *Entity) bool {
+		found = e.IsEqual(entity)
+		return !found
+	})
+	return found
+}
+
+// forEach calls the given function for each entity under this node, returning false if the
+// function stopped the iteration.
+func (n *rNode) forEach(fn func(*Entity) bool) bool {
+	for _, e := range n.entities {
+		if !fn(e) {
+			return false
+		}
+	}
+	for _, child := range n.children {
+		if !child.forEach(fn) {
+			return false
+		}
+	}
+	return true
+}
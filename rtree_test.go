@@ -0,0 +1,353 @@
+// Copyright 2019 Tskken. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package quadgo
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+// checkRTree checks that every node of the tree covers exactly its entries, holds between
+// the min and max entries and that every leaf is at the same depth, returning the number of
+// entities in the tree.
+func checkRTree(t *testing.T, r *RTree) int {
+	t.Helper()
+
+	leafDepth := -1
+
+	// check returns the number of entities under the node and the bound covering them
+	var check func(n *rNode, depth int) (int, Bound)
+	check = func(n *rNode, depth int) (int, Bound) {
+		if n != r.root && (n.len() < r.minEntries || n.len() > r.maxEntries) {
+			t.Errorf("rNode at depth %v holds %v entries, want %v to %v", depth, n.len(), r.minEntries, r.maxEntries)
+		}
+		if n.leaf && len(n.children) > 0 || !n.leaf && len(n.entities) > 0 {
+			t.Errorf("rNode at depth %v holds both entities and children", depth)
+		}
+
+		count := 0
+		var bounds []Bound
+		if n.leaf {
+			if leafDepth == -1 {
+				leafDepth = depth
+			} else if depth != leafDepth {
+				t.Errorf("rNode leaf at depth %v, want all leaves at depth %v", depth, leafDepth)
+			}
+			count = len(n.entities)
+			for _, e := range n.entities {
+				bounds = append(bounds, e.Bound)
+			}
+		}
+		for _, child := range n.children {
+			c, b := check(child, depth+1)
+			count += c
+			bounds = append(bounds, b)
+		}
+
+		var want Bound
+		for i, b := range bounds {
+			if i == 0 {
+				want = b
+			} else {
+				want = want.union(b)
+			}
+		}
+		if !n.bound.IsEqual(want) {
+			t.Errorf("rNode at depth %v has bound %v, want %v", depth, n.bound, want)
+		}
+		return count, want
+	}
+
+	count, _ := check(r.root, 0)
+	if count != r.Len() {
+		t.Errorf("RTree holds %v entities, want Len() %v", count, r.Len())
+	}
+	return count
+}
+
+func TestNewRTree(t *testing.T) {
+	tests := []struct {
+		name           string
+		ops            []Option
+		wantMax        int
+		wantMin        int
+		wantRootIsLeaf bool
+	}{
+		{name: "default", wantMax: 10, wantMin: 4},
+		{name: "max entities", ops: []Option{SetMaxEntities(16)}, wantMax: 16, wantMin: 6},
+		{name: "max entities below 2", ops: []Option{SetMaxEntities(1)}, wantMax: 2, wantMin: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewRTree(tt.ops...)
+			if r.maxEntries != tt.wantMax || r.minEntries != tt.wantMin {
+				t.Errorf("quadgo.NewRTree() entries = %v to %v, want %v to %v", r.minEntries, r.maxEntries, tt.wantMin, tt.wantMax)
+			}
+			if !r.root.leaf || r.Len() != 0 {
+				t.Errorf("quadgo.NewRTree() is not empty")
+			}
+		})
+	}
+}
+
+func TestBuildRTree(t *testing.T) {
+	tests := []struct {
+		name       string
+		n          int
+		ops        []Option
+		wantLeaves int
+		wantHeight int
+	}{
+		{name: "no entities", n: 0, wantLeaves: 1, wantHeight: 1},
+		{name: "one leaf", n: 8, ops: []Option{SetMaxEntities(8)}, wantLeaves: 1, wantHeight: 1},
+		{name: "two levels", n: 64, ops: []Option{SetMaxEntities(8)}, wantLeaves: 8, wantHeight: 2},
+		{name: "three levels", n: 1000, ops: []Option{SetMaxEntities(10)}, wantLeaves: 100, wantHeight: 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entities := randomEntities(tt.n, 1000, 1000)
+			given := make(Entities, len(entities))
+			copy(given, entities)
+
+			r := BuildRTree(entities, tt.ops...)
+			if r.Len() != tt.n {
+				t.Errorf("RTree.Len() = %v, want %v", r.Len(), tt.n)
+			}
+			if !reflect.DeepEqual(entities, given) {
+				t.Errorf("quadgo.BuildRTree() changed the order of the given entities")
+			}
+
+			leaves, height := 0, 0
+			for n := r.root; ; n = n.children[0] {
+				height++
+				if n.leaf {
+					break
+				}
+			}
+			var count func(n *rNode)
+			count = func(n *rNode) {
+				if n.leaf {
+					leaves++
+				}
+				for _, child := range n.children {
+					count(child)
+				}
+			}
+			count(r.root)
+
+			if leaves != tt.wantLeaves || height != tt.wantHeight {
+				t.Errorf("quadgo.BuildRTree() = %v leaves and height %v, want %v and %v", leaves, height, tt.wantLeaves, tt.wantHeight)
+			}
+			checkRTree(t, r)
+		})
+	}
+}
+
+func TestBuildRTree_same(t *testing.T) {
+	entities := randomEntities(50, 1000, 1000)
+
+	// entities equal to one before them are skipped the same as inserting them
+	given := append(append(Entities{}, entities...), entities[:20]...)
+	given = append(given, &Entity{ID: entities[5].ID, Bound: entities[5].Bound})
+
+	r := BuildRTree(given, SetMaxEntities(4))
+	if r.Len() != len(entities) {
+		t.Errorf("quadgo.BuildRTree() Len() = %v, want %v", r.Len(), len(entities))
+	}
+	if got := checkRTree(t, r); got != len(entities) {
+		t.Errorf("quadgo.BuildRTree() holds %v entities, want %v", got, len(entities))
+	}
+
+	// inserting an entity already in the tree leaves it as it was
+	r.InsertEntities(&Entity{ID: entities[3].ID, Bound: entities[3].Bound})
+	if r.Len() != len(entities) {
+		t.Errorf("RTree.InsertEntities() with an entity in the tree Len() = %v, want %v", r.Len(), len(entities))
+	}
+	if got := checkRTree(t, r); got != len(entities) {
+		t.Errorf("RTree.InsertEntities() with an entity in the tree holds %v entities, want %v", got, len(entities))
+	}
+}
+
+func TestRTree_InsertEntities(t *testing.T) {
+	r := NewRTree(SetMaxEntities(4))
+	entities := randomEntities(500, 1000, 1000)
+
+	for i, e := range entities {
+		r.InsertEntities(e)
+		if i%50 == 0 {
+			checkRTree(t, r)
+		}
+	}
+	checkRTree(t, r)
+
+	r.Insert(10, 10, 20, 20)
+	if r.Len() != len(entities)+1 {
+		t.Errorf("RTree.Len() = %v, want %v", r.Len(), len(entities)+1)
+	}
+}
+
+func TestRTree_Remove(t *testing.T) {
+	entities := randomEntities(300, 1000, 1000)
+
+	for _, build := range []bool{false, true} {
+		name := "inserted"
+		if build {
+			name = "built"
+		}
+		t.Run(name, func(t *testing.T) {
+			var r *RTree
+			if build {
+				r = BuildRTree(entities, SetMaxEntities(6))
+			} else {
+				r = NewRTree(SetMaxEntities(6))
+				r.InsertEntities(entities...)
+			}
+
+			// remove in a random order checking the tree stays valid
+			order := rand.New(rand.NewSource(4)).Perm(len(entities))
+			for i, j := range order {
+				if err := r.Remove(entities[j]); err != nil {
+					t.Fatalf("RTree.Remove() got error %v", err)
+				}
+				if i%25 == 0 {
+					checkRTree(t, r)
+				}
+			}
+
+			if r.Len() != 0 || !r.root.leaf || len(r.root.entities) != 0 {
+				t.Errorf("RTree after removing every entity has %v entities", r.Len())
+			}
+			if err := r.Remove(entities[0]); err == nil {
+				t.Errorf("RTree.Remove() on empty tree got no error")
+			}
+		})
+	}
+}
+
+func TestRTree_reads(t *testing.T) {
+	r := BuildRTree(Entities{
+		&Entity{ID: 1, Bound: NewBound(0, 0, 10, 10)},
+		&Entity{ID: 2, Bound: NewBound(5, 5, 100, 100)},
+		&Entity{ID: 3, Bound: NewBound(200, 200, 210, 210)},
+		&Entity{ID: 4, Bound: NewBound(205, 0, 210, 10)},
+	}, SetMaxEntities(2))
+
+	tests := []struct {
+		name          string
+		bound         Bound
+		wantIntersect []uint64
+	}{
+		{name: "inside big entity", bound: NewBound(50, 50, 60, 60), wantIntersect: []uint64{2}},
+		{name: "overlapping entities", bound: NewBound(6, 6, 8, 8), wantIntersect: []uint64{1, 2}},
+		{name: "touching", bound: NewBound(210, 210, 220, 220), wantIntersect: []uint64{3}},
+		{name: "empty space", bound: NewBound(150, 150, 160, 160), wantIntersect: []uint64{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ids(<-r.Intersects(tt.bound)); !reflect.DeepEqual(got, tt.wantIntersect) {
+				t.Errorf("RTree.Intersects() = %v, want %v", got, tt.wantIntersect)
+			}
+			if got := <-r.IsIntersect(tt.bound); got != (len(tt.wantIntersect) > 0) {
+				t.Errorf("RTree.IsIntersect() = %v, want %v", got, len(tt.wantIntersect) > 0)
+			}
+
+			// every entity of a leaf the bound hits is retrieved, including ones it misses
+			retrieved := ids(<-r.Retrieve(tt.bound))
+			for _, id := range tt.wantIntersect {
+				found := false
+				for _, got := range retrieved {
+					found = found || got == id
+				}
+				if !found {
+					t.Errorf("RTree.Retrieve() = %v, missing entity %v", retrieved, id)
+				}
+			}
+		})
+	}
+
+	if !<-r.IsEntity(&Entity{ID: 3, Bound: NewBound(200, 200, 210, 210)}) {
+		t.Errorf("RTree.IsEntity() = false, want true")
+	}
+	if <-r.IsEntity(&Entity{ID: 3, Bound: NewBound(0, 0, 10, 10)}) {
+		t.Errorf("RTree.IsEntity() with the wrong bound = true, want false")
+	}
+	if want := NewBound(0, 0, 210, 210); !r.Bounds().IsEqual(want) {
+		t.Errorf("RTree.Bounds() = %v, want %v", r.Bounds(), want)
+	}
+
+	count := 0
+	r.ForEach(func(*Entity) bool {
+		count++
+		return true
+	})
+	if count != 4 {
+		t.Errorf("RTree.ForEach() gave %v entities, want 4", count)
+	}
+
+	r.Clear()
+	if r.Len() != 0 || <-r.IsIntersect(NewBound(0, 0, 1000, 1000)) {
+		t.Errorf("RTree.Clear() left entities in the tree")
+	}
+}
+
+func Test_quadraticSplit(t *testing.T) {
+	// two clusters of bounds which should be split apart
+	bounds := []Bound{
+		NewBound(0, 0, 1, 1),
+		NewBound(100, 100, 101, 101),
+		NewBound(1, 1, 2, 2),
+		NewBound(99, 99, 100, 100),
+		NewBound(2, 0, 3, 1),
+	}
+
+	a, b := quadraticSplit(bounds, 2)
+	if len(a) < 2 || len(b) < 2 || len(a)+len(b) != len(bounds) {
+		t.Fatalf("quadgo.quadraticSplit() = %v and %v", a, b)
+	}
+
+	near := func(group []int) bool {
+		for _, i := range group {
+			if bounds[i].Min.X >= 50 {
+				return false
+			}
+		}
+		return true
+	}
+	if near(a) == near(b) {
+		t.Errorf("quadgo.quadraticSplit() = %v and %v, want the clusters apart", a, b)
+	}
+}
+
+// BenchmarkStatic compares QuadGo and a built RTree for static geometry of many large
+// overlapping bounds, which QuadGo has to store in many leaves.
+func BenchmarkStatic(b *testing.B) {
+	r := rand.New(rand.NewSource(5))
+	entities := make(Entities, 2000)
+	for i := range entities {
+		x, y := r.Float64()*900, r.Float64()*900
+		entities[i] = &Entity{ID: uint64(i), Bound: NewBound(x, y, x+20+r.Float64()*300, y+5+r.Float64()*20)}
+	}
+	bounds := randomBounds(100, 1000, 1000)
+
+	trees := []struct {
+		name  string
+		query func(Bound, func(*Entity) bool)
+	}{
+		{name: "QuadGo", query: Build(1300, 1300, entities, SetMaxEntities(16), SetMaxDepth(6)).QueryFunc},
+		{name: "RTree", query: BuildRTree(entities, SetMaxEntities(16)).QueryFunc},
+	}
+	for _, tree := range trees {
+		b.Run(tree.name, func(b *testing.B) {
+			found := 0
+			for i := 0; i < b.N; i++ {
+				tree.query(bounds[i%len(bounds)], func(*Entity) bool {
+					found++
+					return true
+				})
+			}
+		})
+	}
+}
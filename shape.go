@@ -0,0 +1,367 @@
+// Copyright 2019 Tskken. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package quadgo
+
+import "math"
+
+// Shape is the precise shape of an entity used to test for collisions after the tree has
+// found the entities whose bounds intersect.
+//
+// The shapes provided are Circle, OrientedBox, Polygon and Capsule. Every shape is convex,
+// which lets any two shapes be tested against each other exactly.
+type Shape interface {
+	// Bounds returns the smallest bound covering the shape.
+	Bounds() Bound
+
+	// hull returns the shape as a convex hull grown by a radius.
+	hull() hull
+
+	// translate returns the shape moved by the given offset.
+	translate(d Point) Shape
+}
+
+// Circle is a Shape of all points with in Radius of Center.
+type Circle struct {
+	Center Point
+	Radius float64
+}
+
+// Bounds returns the smallest bound covering the circle.
+func (c Circle) Bounds() Bound {
+	return NewBound(c.Center.X-c.Radius, c.Center.Y-c.Radius, c.Center.X+c.Radius, c.Center.Y+c.Radius)
+}
+
+func (c Circle) hull() hull {
+	return hull{points: []Point{c.Center}, radius: c.Radius}
+}
+
+func (c Circle) translate(d Point) Shape {
+	c.Center = add(c.Center, d)
+	return c
+}
+
+// OrientedBox is a Shape of a rectangle of the given Width and Height centered on Center and
+// rotated by Angle radians around its center.
+type OrientedBox struct {
+	Center        Point
+	Width, Height float64
+	Angle         float64
+}
+
+// Bounds returns the smallest bound covering the box.
+func (o OrientedBox) Bounds() Bound {
+	return o.hull().bounds()
+}
+
+func (o OrientedBox) hull() hull {
+	sin, cos := math.Sincos(o.Angle)
+	w, h := o.Width/2, o.Height/2
+
+	points := make([]Point, 0, 4)
+	for _, corner := range [4]Point{{-w, -h}, {w, -h}, {w, h}, {-w, h}} {
+		points = append(points, Point{
+			X: o.Center.X + corner.X*cos - corner.Y*sin,
+			Y: o.Center.Y + corner.X*sin + corner.Y*cos,
+		})
+	}
+	return hull{points: points}
+}
+
+func (o OrientedBox) translate(d Point) Shape {
+	o.Center = add(o.Center, d)
+	return o
+}
+
+// Polygon is a Shape of a convex polygon with the given points in order around its edge,
+// in either direction. The points have to make a convex polygon to be tested correctly.
+type Polygon struct {
+	Points []Point
+}
+
+// Bounds returns the smallest bound covering the polygon.
+func (p Polygon) Bounds() Bound {
+	return p.hull().bounds()
+}
+
+func (p Polygon) hull() hull {
+	return hull{points: p.Points}
+}
+
+func (p Polygon) translate(d Point) Shape {
+	points := make([]Point, len(p.Points))
+	for i := range p.Points {
+		points[i] = add(p.Points[i], d)
+	}
+	return Polygon{Points: points}
+}
+
+// Capsule is a Shape of all points with in Radius of the line from A to B.
+type Capsule struct {
+	A, B   Point
+	Radius float64
+}
+
+// Bounds returns the smallest bound covering the capsule.
+func (c Capsule) Bounds() Bound {
+	return c.hull().bounds()
+}
+
+func (c Capsule) hull() hull {
+	return hull{points: []Point{c.A, c.B}, radius: c.Radius}
+}
+
+func (c Capsule) translate(d Point) Shape {
+	c.A, c.B = add(c.A, d), add(c.B, d)
+	return c
+}
+
+// NewEntityWithShape creates a new entity with the given shape, using the bounds of the
+// shape as the bounds of the entity.
+//
+// The ID of the entity is set the same as NewEntity().
+func NewEntityWithShape(shape Shape) *Entity {
+	return &Entity{
+		ID:    newID(),
+		Bound: shape.Bounds(),
+		Shape: shape,
+	}
+}
+
+// IsIntersectShape takes a shape and returns if that shape intersects any entity within the tree.
+//
+// IsIntersectShape is the same as IsIntersect() but tests the precise shape against each
+// entity found, using the Shape of the entity if it has one.
+func (q *QuadGo) IsIntersectShape(shape Shape) <-chan bool {
+	out := make(chan bool)
+
+	go func() {
+		h := shape.hull()
+		hit := false
+		q.observedQuery(shape.Bounds(), &h, func(*Entity) bool {
+			hit = true
+			return false
+		})
+		out <- hit
+		close(out)
+	}()
+
+	return out
+}
+
+// IntersectsShape takes a shape and returns all entities that the given shape intersects with.
+//
+// IntersectsShape is the same as Intersects() but tests the precise shape against each
+// entity found, using the Shape of the entity if it has one.
+func (q *QuadGo) IntersectsShape(shape Shape) <-chan Entities {
+	out := make(chan Entities)
+
+	go func() {
+		h := shape.hull()
+		var entities Entities
+		q.observedQuery(shape.Bounds(), &h, func(e *Entity) bool {
+			entities = append(entities, e)
+			return true
+		})
+		out <- entities
+		close(out)
+	}()
+
+	return out
+}
+
+// Overlaps returns if the two shapes overlap, including when they only touch.
+func Overlaps(a, b Shape) bool {
+	return a.hull().overlaps(b.hull())
+}
+
+// IsIntersectShape returns if the entity intersects the given shape.
+//
+// The precise Shape of the entity is used if it has one, otherwise its Bound is used.
+func (e *Entity) IsIntersectShape(shape Shape) bool {
+	if !e.Bound.IsIntersect(shape.Bounds()) {
+		return false
+	}
+	return e.hull().overlaps(shape.hull())
+}
+
+// narrow returns if the entity, whose bound already intersects the given bound, intersects
+// the given hull or the bound itself if the hull is nil.
+//
+// Entities without a Shape are only tested against a hull as their bound is all there is
+// to test against a bound.
+func (e *Entity) narrow(bound Bound, h *hull) bool {
+	switch {
+	case h != nil:
+		return e.hull().overlaps(*h)
+	case e.Shape != nil:
+		return e.Shape.hull().overlaps(boxHull(bound))
+	default:
+		return true
+	}
+}
+
+// hull returns the precise shape of the entity, or its bound if it has no shape.
+func (e *Entity) hull() hull {
+	if e.Shape == nil {
+		return boxHull(e.Bound)
+	}
+	return e.Shape.hull()
+}
+
+// shapeEpsilon is how far apart shapes can be and still be counted as touching, to allow for
+// floating point error from rotating shapes.
+const shapeEpsilon = 1e-9
+
+// hull is a convex polygon grown by a radius, which every Shape can be made from.
+//
+// A circle is a single point grown by its radius and a capsule is a line grown by its radius.
+type hull struct {
+	points []Point
+	radius float64
+}
+
+// boxHull returns the hull of the given bound.
+func boxHull(b Bound) hull {
+	return hull{points: []Point{b.Min, {X: b.Max.X, Y: b.Min.Y}, b.Max, {X: b.Min.X, Y: b.Max.Y}}}
+}
+
+// bounds returns the smallest bound covering the hull.
+func (h hull) bounds() Bound {
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	for _, p := range h.points {
+		minX, minY = math.Min(minX, p.X), math.Min(minY, p.Y)
+		maxX, maxY = math.Max(maxX, p.X), math.Max(maxY, p.Y)
+	}
+	return NewBound(minX-h.radius, minY-h.radius, maxX+h.radius, maxY+h.radius)
+}
+
+// overlaps returns if the two hulls overlap, which is when the distance between their
+// polygons is no more then their radii together.
+func (h hull) overlaps(other hull) bool {
+	if len(h.points) == 0 || len(other.points) == 0 {
+		return false
+	}
+
+	// check for one polygon being inside the other, where their edges would not touch
+	if h.contains(other.points[0]) || other.contains(h.points[0]) {
+		return true
+	}
+
+	_, _, dist := h.closest(other)
+	return dist <= h.radius+other.radius+shapeEpsilon
+}
+
+// contains returns if the given point is with in the polygon of the hull.
+func (h hull) contains(p Point) bool {
+	if len(h.points) < 3 {
+		return false
+	}
+
+	// the point is inside if it is on the same side of every edge
+	sign := 0.0
+	for i, a := range h.points {
+		b := h.points[(i+1)%len(h.points)]
+		c := cross(sub(b, a), sub(p, a))
+		if c == 0 {
+			continue
+		}
+		if sign == 0 {
+			sign = c
+		} else if (c > 0) != (sign > 0) {
+			return false
+		}
+	}
+	return true
+}
+
+// closest returns the closest points between the edges of the two hulls polygons and the
+// distance between them.
+func (h hull) closest(other hull) (Point, Point, float64) {
+	var pa, pb Point
+	best := math.Inf(1)
+	for i := range h.points {
+		a1, a2 := h.edge(i)
+		for j := range other.points {
+			b1, b2 := other.edge(j)
+			p, q := closestSegments(a1, a2, b1, b2)
+			if d := length(sub(q, p)); d < best {
+				pa, pb, best = p, q, d
+			}
+		}
+	}
+	return pa, pb, best
+}
+
+// edge returns the i'th edge of the hulls polygon. A single point is an edge to itself and
+// two points are a single edge.
+func (h hull) edge(i int) (Point, Point) {
+	if len(h.points) == 2 && i == 1 {
+		return h.points[0], h.points[1]
+	}
+	return h.points[i], h.points[(i+1)%len(h.points)]
+}
+
+// closestSegments returns the closest points between the line from p1 to q1 and the line
+// from p2 to q2.
+func closestSegments(p1, q1, p2, q2 Point) (Point, Point) {
+	d1, d2, r := sub(q1, p1), sub(q2, p2), sub(p1, p2)
+	a, e, f := dot(d1, d1), dot(d2, d2), dot(d2, r)
+
+	var s, t float64
+	switch {
+	case a == 0 && e == 0:
+		// both segments are points
+	case a == 0:
+		t = clamp01(f / e)
+	default:
+		c := dot(d1, r)
+		if e == 0 {
+			s = clamp01(-c / a)
+			break
+		}
+
+		b := dot(d1, d2)
+		if denom := a*e - b*b; denom != 0 {
+			s = clamp01((b*f - c*e) / denom)
+		}
+		t = (b*s + f) / e
+		if t < 0 {
+			t, s = 0, clamp01(-c/a)
+		} else if t > 1 {
+			t, s = 1, clamp01((b-c)/a)
+		}
+	}
+
+	return add(p1, scale(d1, s)), add(p2, scale(d2, t))
+}
+
+func add(a, b Point) Point {
+	return Point{X: a.X + b.X, Y: a.Y + b.Y}
+}
+
+func sub(a, b Point) Point {
+	return Point{X: a.X - b.X, Y: a.Y - b.Y}
+}
+
+func scale(p Point, s float64) Point {
+	return Point{X: p.X * s, Y: p.Y * s}
+}
+
+func dot(a, b Point) float64 {
+	return a.X*b.X + a.Y*b.Y
+}
+
+func cross(a, b Point) float64 {
+	return a.X*b.Y - a.Y*b.X
+}
+
+func length(p Point) float64 {
+	return math.Hypot(p.X, p.Y)
+}
+
+func clamp01(v float64) float64 {
+	return math.Min(math.Max(v, 0), 1)
+}
@@ -0,0 +1,308 @@
+// Copyright 2019 Tskken. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package quadgo
+
+import (
+	"math"
+	"sort"
+	"testing"
+)
+
+func TestShape_Bounds(t *testing.T) {
+	tests := []struct {
+		name  string
+		shape Shape
+		want  Bound
+	}{
+		{
+			name:  "circle",
+			shape: Circle{Center: Point{10, 20}, Radius: 5},
+			want:  NewBound(5, 15, 15, 25),
+		},
+		{
+			name:  "axis aligned box",
+			shape: OrientedBox{Center: Point{10, 10}, Width: 8, Height: 4},
+			want:  NewBound(6, 8, 14, 12),
+		},
+		{
+			name:  "box rotated 90 degrees",
+			shape: OrientedBox{Center: Point{10, 10}, Width: 8, Height: 4, Angle: math.Pi / 2},
+			want:  NewBound(8, 6, 12, 14),
+		},
+		{
+			name:  "polygon",
+			shape: Polygon{Points: []Point{{0, 0}, {10, 5}, {3, 12}}},
+			want:  NewBound(0, 0, 10, 12),
+		},
+		{
+			name:  "capsule",
+			shape: Capsule{A: Point{0, 0}, B: Point{10, 0}, Radius: 2},
+			want:  NewBound(-2, -2, 12, 2),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.shape.Bounds()
+			if !boundNear(got, tt.want) {
+				t.Errorf("Shape.Bounds() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// boundNear returns if the two bounds are equal apart from floating point error.
+func boundNear(lhs, rhs Bound) bool {
+	near := func(a, b float64) bool {
+		return math.Abs(a-b) < 1e-9
+	}
+	return near(lhs.Min.X, rhs.Min.X) && near(lhs.Min.Y, rhs.Min.Y) &&
+		near(lhs.Max.X, rhs.Max.X) && near(lhs.Max.Y, rhs.Max.Y)
+}
+
+func TestOverlaps(t *testing.T) {
+	diamond := Polygon{Points: []Point{{10, 0}, {20, 10}, {10, 20}, {0, 10}}}
+
+	tests := []struct {
+		name string
+		a, b Shape
+		want bool
+	}{
+		{
+			name: "circles overlapping",
+			a:    Circle{Center: Point{0, 0}, Radius: 5},
+			b:    Circle{Center: Point{8, 0}, Radius: 5},
+			want: true,
+		},
+		{
+			name: "circles touching",
+			a:    Circle{Center: Point{0, 0}, Radius: 5},
+			b:    Circle{Center: Point{10, 0}, Radius: 5},
+			want: true,
+		},
+		{
+			name: "circles apart",
+			a:    Circle{Center: Point{0, 0}, Radius: 5},
+			b:    Circle{Center: Point{8, 8}, Radius: 5},
+			want: false,
+		},
+		{
+			name: "circle inside polygon",
+			a:    Circle{Center: Point{10, 10}, Radius: 1},
+			b:    diamond,
+			want: true,
+		},
+		{
+			name: "circle near polygon corner",
+			a:    Circle{Center: Point{2, 2}, Radius: 2},
+			b:    diamond,
+			want: false,
+		},
+		{
+			name: "circle on polygon edge",
+			a:    Circle{Center: Point{2, 2}, Radius: 4.5},
+			b:    diamond,
+			want: true,
+		},
+		{
+			name: "polygon inside polygon",
+			a:    Polygon{Points: []Point{{9, 9}, {11, 9}, {10, 11}}},
+			b:    diamond,
+			want: true,
+		},
+		{
+			name: "rotated boxes crossing",
+			a:    OrientedBox{Center: Point{0, 0}, Width: 20, Height: 2, Angle: math.Pi / 4},
+			b:    OrientedBox{Center: Point{0, 0}, Width: 20, Height: 2, Angle: -math.Pi / 4},
+			want: true,
+		},
+		{
+			name: "rotated box missing corner",
+			a:    OrientedBox{Center: Point{0, 0}, Width: 10, Height: 10, Angle: math.Pi / 4},
+			b:    Polygon{Points: []Point{{5, 5}, {8, 5}, {8, 8}, {5, 8}}},
+			want: false,
+		},
+		{
+			name: "capsules crossing",
+			a:    Capsule{A: Point{0, 0}, B: Point{10, 10}, Radius: 1},
+			b:    Capsule{A: Point{0, 10}, B: Point{10, 0}, Radius: 1},
+			want: true,
+		},
+		{
+			name: "capsules parallel",
+			a:    Capsule{A: Point{0, 0}, B: Point{10, 0}, Radius: 1},
+			b:    Capsule{A: Point{0, 3}, B: Point{10, 3}, Radius: 1},
+			want: false,
+		},
+		{
+			name: "capsule and circle",
+			a:    Capsule{A: Point{0, 0}, B: Point{10, 0}, Radius: 1},
+			b:    Circle{Center: Point{5, 2.5}, Radius: 1.5},
+			want: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Overlaps(tt.a, tt.b); got != tt.want {
+				t.Errorf("quadgo.Overlaps() = %v, want %v", got, tt.want)
+			}
+			if got := Overlaps(tt.b, tt.a); got != tt.want {
+				t.Errorf("quadgo.Overlaps() reversed = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEntity_IsIntersectShape(t *testing.T) {
+	tests := []struct {
+		name   string
+		entity *Entity
+		shape  Shape
+		want   bool
+	}{
+		{
+			name:   "bound entity",
+			entity: &Entity{ID: 1, Bound: NewBound(0, 0, 10, 10)},
+			shape:  Circle{Center: Point{12, 12}, Radius: 3},
+			want:   true,
+		},
+		{
+			name:   "bound entity missed by circle",
+			entity: &Entity{ID: 1, Bound: NewBound(0, 0, 10, 10)},
+			shape:  Circle{Center: Point{13, 13}, Radius: 3},
+			want:   false,
+		},
+		{
+			name:   "shaped entity",
+			entity: NewEntityWithShape(Circle{Center: Point{5, 5}, Radius: 5}),
+			shape:  Circle{Center: Point{12, 12}, Radius: 3},
+			want:   false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.entity.IsIntersectShape(tt.shape); got != tt.want {
+				t.Errorf("Entity.IsIntersectShape() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQuadGo_shapes(t *testing.T) {
+	q := New(100, 100, SetMaxEntities(2))
+
+	ball := NewEntityWithShape(Circle{Center: Point{20, 20}, Radius: 10})
+	ball.ID = 1
+	beam := NewEntityWithShape(OrientedBox{Center: Point{50, 50}, Width: 80, Height: 4, Angle: math.Pi / 4})
+	beam.ID = 2
+	box := &Entity{ID: 3, Bound: NewBound(70, 10, 90, 30)}
+	q.InsertEntities(ball, beam, box)
+
+	ids := func(entities Entities) []uint64 {
+		var ids []uint64
+		for _, e := range entities {
+			ids = append(ids, e.ID)
+		}
+		sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+		return ids
+	}
+
+	tests := []struct {
+		name   string
+		bound  *Bound
+		shape  Shape
+		want   []uint64
+		wantIs bool
+	}{
+		{
+			name:   "bound in corner of circle bound",
+			bound:  &Bound{Min: Point{10, 10}, Max: Point{12, 12}},
+			want:   nil,
+			wantIs: false,
+		},
+		{
+			name:   "bound over circle",
+			bound:  &Bound{Min: Point{12, 22}, Max: Point{18, 28}},
+			want:   []uint64{1},
+			wantIs: true,
+		},
+		{
+			name:   "bound beside beam",
+			bound:  &Bound{Min: Point{70, 40}, Max: Point{80, 50}},
+			want:   nil,
+			wantIs: false,
+		},
+		{
+			name:   "bound over beam and box",
+			bound:  &Bound{Min: Point{60, 20}, Max: Point{80, 60}},
+			want:   []uint64{2, 3},
+			wantIs: true,
+		},
+		{
+			name:   "circle missing box corner",
+			shape:  Circle{Center: Point{95, 35}, Radius: 6},
+			want:   nil,
+			wantIs: false,
+		},
+		{
+			name:   "circle over box edge",
+			shape:  Circle{Center: Point{75, 35}, Radius: 6},
+			want:   []uint64{3},
+			wantIs: true,
+		},
+		{
+			name:   "capsule along beam",
+			shape:  Capsule{A: Point{10, 10}, B: Point{90, 90}, Radius: 1},
+			want:   []uint64{1, 2},
+			wantIs: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []uint64
+			var gotIs bool
+			if tt.bound != nil {
+				b := NewBound(tt.bound.Min.X, tt.bound.Min.Y, tt.bound.Max.X, tt.bound.Max.Y)
+				got, gotIs = ids(<-q.Intersects(b)), <-q.IsIntersect(b)
+			} else {
+				got, gotIs = ids(<-q.IntersectsShape(tt.shape)), <-q.IsIntersectShape(tt.shape)
+			}
+
+			if len(got) != len(tt.want) {
+				t.Fatalf("QuadGo.Intersects() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("QuadGo.Intersects() = %v, want %v", got, tt.want)
+				}
+			}
+			if gotIs != tt.wantIs {
+				t.Errorf("QuadGo.IsIntersect() = %v, want %v", gotIs, tt.wantIs)
+			}
+		})
+	}
+}
+
+func Test_closestSegments(t *testing.T) {
+	tests := []struct {
+		name           string
+		p1, q1, p2, q2 Point
+		wantDist       float64
+	}{
+		{name: "points", p1: Point{0, 0}, q1: Point{0, 0}, p2: Point{3, 4}, q2: Point{3, 4}, wantDist: 5},
+		{name: "point and segment", p1: Point{5, 5}, q1: Point{5, 5}, p2: Point{0, 0}, q2: Point{10, 0}, wantDist: 5},
+		{name: "segment and point", p1: Point{0, 0}, q1: Point{10, 0}, p2: Point{12, 0}, q2: Point{12, 0}, wantDist: 2},
+		{name: "crossing", p1: Point{0, 0}, q1: Point{10, 10}, p2: Point{0, 10}, q2: Point{10, 0}, wantDist: 0},
+		{name: "parallel", p1: Point{0, 0}, q1: Point{10, 0}, p2: Point{5, 3}, q2: Point{15, 3}, wantDist: 3},
+		{name: "end to end", p1: Point{0, 0}, q1: Point{10, 0}, p2: Point{13, 4}, q2: Point{20, 4}, wantDist: 5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, q := closestSegments(tt.p1, tt.q1, tt.p2, tt.q2)
+			if got := length(sub(q, p)); math.Abs(got-tt.wantDist) > 1e-9 {
+				t.Errorf("closestSegments() distance = %v, want %v", got, tt.wantDist)
+			}
+		})
+	}
+}
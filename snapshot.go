@@ -0,0 +1,116 @@
+// Copyright 2019 Tskken. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package quadgo
+
+// Snapshot is a read only copy of a QuadGo tree at the time it was taken.
+//
+// A Snapshot can be read from while the tree it was taken from keeps being written to,
+// for example letting a render go routine read the last frames tree while the next frame
+// is being simulated. The entities in a snapshot are the same entities as in the tree, so
+// changes made to an entity itself are seen by both and are not safe to make while the
+// snapshot is being read.
+//
+// A Snapshot does not have the Observer or node pool of its tree, so reading from it never
+// calls the Observer from another go routine.
+type Snapshot struct {
+	tree *QuadGo
+}
+
+// Snapshot takes a read only Snapshot of the tree.
+//
+// Taking a snapshot does not copy the tree, the snapshot shares every node with it. Writes
+// to the tree after the snapshot is taken copy each shared node they change, along with
+// the nodes on the path to it, so a snapshot costs the same no matter the size of the tree
+// and each write only costs the nodes it touches. Take it from the go routine writing to
+// the tree, such as at the end of each frame.
+func (q *QuadGo) Snapshot() *Snapshot {
+	q.node.shared = true
+
+	return &Snapshot{
+		tree: &QuadGo{
+			node:        q.node,
+			maxEntities: q.maxEntities,
+			maxDepth:    q.maxDepth,
+			codec:       q.codec,
+			size:        q.size,
+		},
+	}
+}
+
+// Retrieve returns all entities from all nodes the given bounds intersects with.
+// See QuadGo.Retrieve().
+func (s *Snapshot) Retrieve(bound Bound) <-chan Entities {
+	return s.tree.Retrieve(bound)
+}
+
+// IsEntity checks if a given entity exists within the snapshot.
+// See QuadGo.IsEntity().
+func (s *Snapshot) IsEntity(entity *Entity) <-chan bool {
+	return s.tree.IsEntity(entity)
+}
+
+// IsIntersect take a bound and returns if that bound intersects any entity within the snapshot.
+// See QuadGo.IsIntersect().
+func (s *Snapshot) IsIntersect(bound Bound) <-chan bool {
+	return s.tree.IsIntersect(bound)
+}
+
+// Intersects takes a bound and returns all entities that the given bound intersects with.
+// See QuadGo.Intersects().
+func (s *Snapshot) Intersects(bound Bound) <-chan Entities {
+	return s.tree.Intersects(bound)
+}
+
+// IsIntersectShape takes a shape and returns if that shape intersects any entity within the snapshot.
+// See QuadGo.IsIntersectShape().
+func (s *Snapshot) IsIntersectShape(shape Shape) <-chan bool {
+	return s.tree.IsIntersectShape(shape)
+}
+
+// IntersectsShape takes a shape and returns all entities that the given shape intersects with.
+// See QuadGo.IntersectsShape().
+func (s *Snapshot) IntersectsShape(shape Shape) <-chan Entities {
+	return s.tree.IntersectsShape(shape)
+}
+
+// QueryFunc calls the given function for every entity that the given bound intersects with.
+// See QuadGo.QueryFunc().
+func (s *Snapshot) QueryFunc(bound Bound, fn func(*Entity) bool) {
+	s.tree.QueryFunc(bound, fn)
+}
+
+// All returns every entity with in the snapshot.
+// See QuadGo.All().
+func (s *Snapshot) All() <-chan Entities {
+	return s.tree.All()
+}
+
+// ForEach calls the given function for every entity with in the snapshot.
+// See QuadGo.ForEach().
+func (s *Snapshot) ForEach(fn func(*Entity) bool) {
+	s.tree.ForEach(fn)
+}
+
+// Walk calls the given function for every node in the snapshot.
+// See QuadGo.Walk().
+func (s *Snapshot) Walk(fn func(NodeInfo) bool) {
+	s.tree.Walk(fn)
+}
+
+// Stats walks the snapshot and returns statistics about its shape.
+// See QuadGo.Stats().
+func (s *Snapshot) Stats() Stats {
+	return s.tree.Stats()
+}
+
+// Len returns the number of entities in the snapshot.
+func (s *Snapshot) Len() int {
+	return s.tree.Len()
+}
+
+// Bounds returns the bounds of the tree the snapshot was taken from.
+func (s *Snapshot) Bounds() Bound {
+	return s.tree.Bounds()
+}
@@ -0,0 +1,215 @@
+// Copyright 2019 Tskken. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package quadgo
+
+import (
+	"testing"
+)
+
+func TestQuadGo_Snapshot(t *testing.T) {
+	type fields struct {
+		quadgo   *QuadGo
+		entities Entities
+	}
+	type args struct {
+		insert Entities
+		bound  Bound
+	}
+	tests := []struct {
+		name   string
+		fields fields
+		args   args
+		want   Entities
+	}{
+		{
+			name: "snapshot not changed by insert",
+			fields: fields{
+				quadgo: New(800, 600, SetMaxEntities(2)),
+				entities: Entities{
+					&Entity{ID: 1, Bound: NewBound(0, 0, 50, 50)},
+					&Entity{ID: 2, Bound: NewBound(500, 400, 700, 600)},
+				},
+			},
+			args: args{
+				insert: Entities{
+					&Entity{ID: 3, Bound: NewBound(10, 10, 20, 20)},
+					&Entity{ID: 4, Bound: NewBound(30, 30, 40, 40)},
+				},
+				bound: NewBound(0, 0, 100, 100),
+			},
+			want: Entities{
+				&Entity{ID: 1, Bound: NewBound(0, 0, 50, 50)},
+			},
+		},
+		{
+			name: "snapshot not changed by clear",
+			fields: fields{
+				quadgo:   New(800, 600, SetMaxEntities(2)),
+				entities: randomEntities(50, 800, 600),
+			},
+			args: args{
+				bound: NewBound(0, 0, 800, 600),
+			},
+			want: randomEntities(50, 800, 600),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.fields.quadgo.InsertEntities(tt.fields.entities...)
+			if err != nil {
+				t.Errorf("QuadGo.Snapshot() got error on insert %v", err)
+			}
+
+			snapshot := tt.fields.quadgo.Snapshot()
+
+			if len(tt.args.insert) > 0 {
+				err = tt.fields.quadgo.InsertEntities(tt.args.insert...)
+				if err != nil {
+					t.Errorf("QuadGo.Snapshot() got error on insert %v", err)
+				}
+			} else {
+				tt.fields.quadgo.Clear()
+			}
+
+			if got := snapshot.Len(); got != len(tt.fields.entities) {
+				t.Errorf("Snapshot.Len() = %v, want %v", got, len(tt.fields.entities))
+			}
+			if got := snapshot.Bounds(); !got.IsEqual(tt.fields.quadgo.Bounds()) {
+				t.Errorf("Snapshot.Bounds() = %v, want %v", got, tt.fields.quadgo.Bounds())
+			}
+
+			got := <-snapshot.Intersects(tt.args.bound)
+			if len(got) != len(tt.want) {
+				t.Errorf("Snapshot.Intersects() = %v, want %v", got, tt.want)
+			}
+			for _, e := range tt.want {
+				if !got.Contains(e) {
+					t.Errorf("Snapshot.Intersects() did not return %v", e)
+				}
+				if !<-snapshot.IsEntity(e) {
+					t.Errorf("Snapshot.IsEntity() did not find %v", e)
+				}
+			}
+			for _, e := range tt.args.insert {
+				if <-snapshot.IsEntity(e) {
+					t.Errorf("Snapshot.IsEntity() found %v inserted after snapshot", e)
+				}
+			}
+		})
+	}
+}
+
+func TestSnapshot_concurrentWrites(t *testing.T) {
+	q := New(800, 600, SetMaxEntities(2))
+	entities := randomEntities(100, 800, 600)
+	if err := q.InsertEntities(entities[:50]...); err != nil {
+		t.Errorf("QuadGo.Snapshot() got error on insert %v", err)
+	}
+
+	snapshot := q.Snapshot()
+
+	// read from the snapshot while the tree is being written to
+	done := make(chan int)
+	go func() {
+		count := 0
+		snapshot.ForEach(func(*Entity) bool {
+			count++
+			return true
+		})
+		done <- count
+	}()
+
+	for _, e := range entities[50:] {
+		if err := q.InsertEntities(e); err != nil {
+			t.Errorf("QuadGo.Snapshot() got error on insert %v", err)
+		}
+	}
+	for _, e := range entities[:50] {
+		if err := q.Remove(e); err != nil {
+			t.Errorf("QuadGo.Snapshot() got error on remove %v", err)
+		}
+	}
+
+	if got := <-done; got != 50 {
+		t.Errorf("Snapshot.ForEach() visited %v entities, want 50", got)
+	}
+}
+
+func TestQuadGo_Snapshot_observer(t *testing.T) {
+	r := &recorder{}
+	q := New(800, 600, SetMaxEntities(2), SetObserver(r), SetNodePool(16))
+	q.InsertEntities(randomEntities(20, 800, 600)...)
+	events := len(r.events)
+
+	// reads from the snapshot do not tell the observer of the tree
+	snapshot := q.Snapshot()
+	<-snapshot.Intersects(NewBound(0, 0, 400, 300))
+	snapshot.QueryFunc(NewBound(0, 0, 800, 600), func(*Entity) bool { return true })
+
+	if len(r.events) != events {
+		t.Errorf("Snapshot reads told the observer %v", r.events[events:])
+	}
+	if snapshot.tree.observer != nil || snapshot.tree.pool != nil {
+		t.Errorf("QuadGo.Snapshot() kept the observer or node pool of the tree")
+	}
+}
+
+func TestQuadGo_Snapshot_shared(t *testing.T) {
+	tests := []struct {
+		name string
+		ops  []Option
+	}{
+		{name: "no pool", ops: []Option{SetMaxEntities(2)}},
+		{name: "pool", ops: []Option{SetMaxEntities(2), SetNodePool(64)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := New(800, 600, tt.ops...)
+			entities := randomEntities(60, 800, 600)
+			q.InsertEntities(entities[:50]...)
+			before := q.Clone()
+
+			// taking a snapshot does not copy any nodes
+			snapshot := q.Snapshot()
+			if snapshot.tree.node != q.node {
+				t.Fatalf("QuadGo.Snapshot() copied the root node")
+			}
+
+			// a write only copies the nodes along its path
+			q.InsertEntities(entities[50])
+			shared := 0
+			for _, child := range q.children {
+				for _, c := range snapshot.tree.children {
+					if child == c {
+						shared++
+					}
+				}
+			}
+			if shared != 3 {
+				t.Errorf("QuadGo children shared with snapshot after insert = %v, want 3", shared)
+			}
+
+			// later writes, including ones that collapse and pool nodes, leave the snapshot as it was
+			q.InsertEntities(entities[51:]...)
+			for _, e := range entities[:40] {
+				if err := q.Remove(e); err != nil {
+					t.Fatalf("QuadGo.Remove() got error %v", err)
+				}
+			}
+			if err := q.Validate(); err != nil {
+				t.Errorf("QuadGo.Validate() after writes to a tree with a snapshot got error %v", err)
+			}
+			if !sameNode(snapshot.tree.node, before.node) {
+				t.Errorf("Snapshot changed by writes to its tree")
+			}
+
+			q.Clear()
+			q.InsertEntities(randomEntities(50, 800, 600)...)
+			if !sameNode(snapshot.tree.node, before.node) {
+				t.Errorf("Snapshot changed by clearing and reusing its tree")
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+// Copyright 2019 Tskken. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package quadgo
+
+import "fmt"
+
+// Stats holds statistics about the shape of a tree for tuning its Option's.
+// See QuadGo.Stats().
+type Stats struct {
+	// Nodes is the number of nodes in the tree, including the root.
+	Nodes int
+	// Leaves is the number of leaf nodes in the tree.
+	Leaves int
+	// EmptyLeaves is the number of leaf nodes holding no entities.
+	EmptyLeaves int
+
+	// MaxDepth is the depth of the deepest leaf node.
+	MaxDepth uint16
+	// AvgDepth is the average depth of all leaf nodes.
+	AvgDepth float64
+
+	// LeafEntities is a histogram of the number of entities in each leaf, where
+	// LeafEntities[n] is the number of leaf nodes holding n entities.
+	LeafEntities []int
+
+	// References is the number of entities held by all leaf nodes, counting an entity
+	// once for each leaf it is in.
+	References int
+	// Entities is the number of distinct entities in the tree.
+	Entities int
+	// Duplication is the average number of leaves each entity is in, found as References
+	// divided by Entities. It is 0 for an empty tree.
+	Duplication float64
+}
+
+// Stats walks the tree and returns statistics about its shape.
+//
+// Stats are useful for picking the SetMaxEntities() and SetMaxDepth() of a tree. A high
+// Duplication means entities are large compared to the leaves they are in, which is often
+// from too high a max depth, while many leaves over the max entities means the tree is
+// limited by its max depth.
+func (q *QuadGo) Stats() Stats {
+	var s Stats
+	depths := 0
+	q.walk(func(info NodeInfo) bool {
+		s.Nodes++
+		if !info.Leaf {
+			return true
+		}
+
+		s.Leaves++
+		depths += int(info.Depth)
+		if info.Depth > s.MaxDepth {
+			s.MaxDepth = info.Depth
+		}
+		if info.Entities == 0 {
+			s.EmptyLeaves++
+		}
+
+		for len(s.LeafEntities) <= info.Entities {
+			s.LeafEntities = append(s.LeafEntities, 0)
+		}
+		s.LeafEntities[info.Entities]++
+		s.References += info.Entities
+
+		return true
+	})
+
+	q.forEach(q.bound, func(*Entity) bool {
+		s.Entities++
+		return true
+	})
+
+	s.AvgDepth = float64(depths) / float64(s.Leaves)
+	if s.Entities > 0 {
+		s.Duplication = float64(s.References) / float64(s.Entities)
+	}
+
+	return s
+}
+
+// String returns the stats as a multi line report.
+func (s Stats) String() string {
+	return fmt.Sprintf("Nodes: %v, Leaves: %v, Empty Leaves: %v\n"+
+		"Max Depth: %v, Avg Depth: %.2f\n"+
+		"Entities: %v, References: %v, Duplication: %.2f\n"+
+		"Entities per Leaf: %v\n",
+		s.Nodes, s.Leaves, s.EmptyLeaves,
+		s.MaxDepth, s.AvgDepth,
+		s.Entities, s.References, s.Duplication,
+		s.LeafEntities)
+}
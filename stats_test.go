@@ -0,0 +1,115 @@
+// Copyright 2019 Tskken. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package quadgo
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestQuadGo_Stats(t *testing.T) {
+	tests := []struct {
+		name string
+		tree func() *QuadGo
+		want Stats
+	}{
+		{
+			name: "empty tree",
+			tree: func() *QuadGo {
+				return New(100, 100)
+			},
+			want: Stats{
+				Nodes:        1,
+				Leaves:       1,
+				EmptyLeaves:  1,
+				LeafEntities: []int{1},
+			},
+		},
+		{
+			name: "duplicated entity",
+			tree: func() *QuadGo {
+				return Build(100, 100, Entities{
+					{ID: 1, Bound: NewBound(0, 0, 10, 10)},
+					{ID: 2, Bound: NewBound(60, 0, 70, 10)},
+					{ID: 3, Bound: NewBound(40, 40, 60, 60)},
+				}, SetMaxEntities(2))
+			},
+			want: Stats{
+				Nodes:        5,
+				Leaves:       4,
+				MaxDepth:     1,
+				AvgDepth:     1,
+				LeafEntities: []int{0, 2, 2},
+				References:   6,
+				Entities:     3,
+				Duplication:  2,
+			},
+		},
+		{
+			name: "limited by max depth",
+			tree: func() *QuadGo {
+				q := New(100, 100, SetMaxEntities(2), SetMaxDepth(1))
+				for i := 0; i < 5; i++ {
+					q.InsertEntities(&Entity{ID: uint64(i + 1), Bound: NewBound(float64(i), 0, float64(i)+10, 10)})
+				}
+				return q
+			},
+			want: Stats{
+				Nodes:        5,
+				Leaves:       4,
+				EmptyLeaves:  3,
+				MaxDepth:     1,
+				AvgDepth:     1,
+				LeafEntities: []int{3, 0, 0, 0, 0, 1},
+				References:   5,
+				Entities:     5,
+				Duplication:  1,
+			},
+		},
+		{
+			name: "uneven depth",
+			tree: func() *QuadGo {
+				return Build(100, 100, Entities{
+					{ID: 1, Bound: NewBound(0, 0, 1, 1)},
+					{ID: 2, Bound: NewBound(30, 0, 31, 1)},
+					{ID: 3, Bound: NewBound(0, 30, 1, 31)},
+				}, SetMaxEntities(2))
+			},
+			want: Stats{
+				Nodes:        9,
+				Leaves:       7,
+				EmptyLeaves:  4,
+				MaxDepth:     2,
+				AvgDepth:     11.0 / 7.0,
+				LeafEntities: []int{4, 3},
+				References:   3,
+				Entities:     3,
+				Duplication:  1,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := tt.tree()
+			if got := q.Stats(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("QuadGo.Stats() = %v, want %v", got, tt.want)
+			}
+			if got := q.Snapshot().Stats(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Snapshot.Stats() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPersistent_Stats(t *testing.T) {
+	entities := randomEntities(100, 100, 100)
+
+	p := BuildPersistent(100, 100, entities, SetMaxEntities(4))
+	q := Build(100, 100, entities, SetMaxEntities(4))
+
+	if got, want := p.Stats(), q.Stats(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Persistent.Stats() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,116 @@
+// Copyright 2019 Tskken. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package quadgo
+
+import (
+	"bufio"
+	"fmt"
+	"image/color"
+	"io"
+	"math"
+)
+
+// colors used to draw a tree.
+var (
+	nodeShallowColor = color.RGBA{R: 0xf0, G: 0xf4, B: 0xf8, A: 0xff}
+	nodeDeepColor    = color.RGBA{R: 0x5b, G: 0x7a, B: 0x99, A: 0xff}
+	nodeStrokeColor  = color.RGBA{R: 0x33, G: 0x33, B: 0x33, A: 0xff}
+	entityColor      = color.RGBA{R: 0x1f, G: 0x77, B: 0xb4, A: 0xff}
+	queryColor       = color.RGBA{R: 0xd6, G: 0x27, B: 0x28, A: 0xff}
+	hitColor         = color.RGBA{R: 0xff, G: 0x7f, B: 0x0e, A: 0xff}
+)
+
+// depthColor returns the fill color of a node at the given depth, going from light for the
+// root to dark for nodes at the max depth.
+func depthColor(depth, maxDepth uint16) color.RGBA {
+	if maxDepth == 0 {
+		return nodeShallowColor
+	}
+
+	t := math.Min(float64(depth)/float64(maxDepth), 1)
+	lerp := func(a, b uint8) uint8 {
+		return uint8(math.Round(float64(a) + (float64(b)-float64(a))*t))
+	}
+	return color.RGBA{
+		R: lerp(nodeShallowColor.R, nodeDeepColor.R),
+		G: lerp(nodeShallowColor.G, nodeDeepColor.G),
+		B: lerp(nodeShallowColor.B, nodeDeepColor.B),
+		A: 0xff,
+	}
+}
+
+// hex returns the color as a hex string for SVG.
+func hex(c color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
+
+// WriteSVG draws the tree as an SVG image to the given writer.
+//
+// Every node of the tree is drawn as a rectangle shaded by its depth, with deeper nodes
+// being darker, and every entity is drawn as an outlined box over the nodes. Entities with
+// no width or height are drawn as dots. Each given query bound is drawn highlighted along
+// with the entities it intersects.
+//
+// The image uses the coordinates of the tree, with y going down, and each element has a
+// class of node, entity, hit or query along with a title for its ID or bounds.
+//
+//  q.WriteSVG(f, quadgo.NewBound(10, 10, 50, 50))
+func (q *QuadGo) WriteSVG(w io.Writer, queries ...Bound) error {
+	bw := bufio.NewWriter(w)
+	world := q.bound
+
+	// find all entities hit by the queries
+	hits := make(map[*Entity]bool)
+	for _, query := range queries {
+		q.query(query, world, nil, func(e *Entity) bool {
+			hits[e] = true
+			return true
+		}, nil)
+	}
+
+	fmt.Fprintf(bw, `<svg xmlns="http://www.w3.org/2000/svg" viewBox="%g %g %g %g">`+"\n",
+		world.Min.X, world.Min.Y, world.Max.X-world.Min.X, world.Max.Y-world.Min.Y)
+
+	// draw nodes from the root down so children are drawn over their parents
+	fmt.Fprintf(bw, `<g stroke="%v" stroke-width="1">`+"\n", hex(nodeStrokeColor))
+	q.walk(func(info NodeInfo) bool {
+		fmt.Fprintf(bw, `<rect class="node" x="%g" y="%g" width="%g" height="%g" fill="%v" vector-effect="non-scaling-stroke"><title>depth %v, %v entities</title></rect>`+"\n",
+			info.Bound.Min.X, info.Bound.Min.Y, info.Bound.Max.X-info.Bound.Min.X, info.Bound.Max.Y-info.Bound.Min.Y,
+			hex(depthColor(info.Depth, q.maxDepth)), info.Depth, info.Entities)
+		return true
+	})
+	fmt.Fprintln(bw, `</g>`)
+
+	// draw each entity once, filling the ones hit by a query
+	radius := math.Max(world.Max.X-world.Min.X, world.Max.Y-world.Min.Y) / 200
+	fmt.Fprintln(bw, `<g stroke-width="1">`)
+	q.forEach(world, func(e *Entity) bool {
+		class, c, fill := "entity", entityColor, "none"
+		if hits[e] {
+			class, c, fill = "hit", hitColor, hex(hitColor)
+		}
+
+		if e.Min.IsEqual(e.Max) {
+			fmt.Fprintf(bw, `<circle class="%v" cx="%g" cy="%g" r="%g" fill="%v"><title>%v</title></circle>`+"\n",
+				class, e.Min.X, e.Min.Y, radius, hex(c), e.ID)
+			return true
+		}
+
+		fmt.Fprintf(bw, `<rect class="%v" x="%g" y="%g" width="%g" height="%g" stroke="%v" fill="%v" fill-opacity="0.4" vector-effect="non-scaling-stroke"><title>%v</title></rect>`+"\n",
+			class, e.Min.X, e.Min.Y, e.Max.X-e.Min.X, e.Max.Y-e.Min.Y, hex(c), fill, e.ID)
+		return true
+	})
+	fmt.Fprintln(bw, `</g>`)
+
+	// draw the queries over everything else
+	for _, query := range queries {
+		fmt.Fprintf(bw, `<rect class="query" x="%g" y="%g" width="%g" height="%g" stroke="%v" stroke-width="2" stroke-dasharray="4" fill="none" vector-effect="non-scaling-stroke"><title>%g, %g to %g, %g</title></rect>`+"\n",
+			query.Min.X, query.Min.Y, query.Max.X-query.Min.X, query.Max.Y-query.Min.Y, hex(queryColor),
+			query.Min.X, query.Min.Y, query.Max.X, query.Max.Y)
+	}
+
+	fmt.Fprintln(bw, `</svg>`)
+	return bw.Flush()
+}
@@ -0,0 +1,145 @@
+// Copyright 2019 Tskken. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package quadgo
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"testing"
+)
+
+// svgElements decodes an SVG image and counts its elements by class.
+func svgElements(t *testing.T, data []byte) map[string]int {
+	counts := make(map[string]int)
+	d := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			return counts
+		}
+		if err != nil {
+			t.Fatalf("could not decode svg: %v", err)
+		}
+
+		if start, ok := tok.(xml.StartElement); ok {
+			for _, attr := range start.Attr {
+				if attr.Name.Local == "class" {
+					counts[attr.Value]++
+				}
+			}
+		}
+	}
+}
+
+// failWriter is an io.Writer that always fails.
+type failWriter struct{}
+
+func (failWriter) Write([]byte) (int, error) {
+	return 0, errors.New("write failed")
+}
+
+func TestQuadGo_WriteSVG(t *testing.T) {
+	type args struct {
+		queries []Bound
+	}
+	tests := []struct {
+		name     string
+		entities Entities
+		args     args
+		want     map[string]int
+	}{
+		{
+			name: "empty tree",
+			want: map[string]int{"node": 1},
+		},
+		{
+			name: "split tree",
+			entities: Entities{
+				{ID: 1, Bound: NewBound(0, 0, 10, 10)},
+				{ID: 2, Bound: NewBound(60, 0, 70, 10)},
+				{ID: 3, Bound: NewBound(0, 60, 10, 70)},
+				{ID: 4, Bound: NewBound(40, 40, 60, 60)},
+			},
+			want: map[string]int{"node": 5, "entity": 4},
+		},
+		{
+			name: "query with hits",
+			entities: Entities{
+				{ID: 1, Bound: NewBound(0, 0, 10, 10)},
+				{ID: 2, Bound: NewBound(60, 0, 70, 10)},
+				{ID: 3, Bound: NewBound(0, 60, 10, 70)},
+				{ID: 4, Bound: NewBound(40, 40, 60, 60)},
+			},
+			args: args{
+				queries: []Bound{NewBound(5, 5, 45, 45)},
+			},
+			want: map[string]int{"node": 5, "entity": 2, "hit": 2, "query": 1},
+		},
+		{
+			name: "point entities",
+			entities: Entities{
+				{ID: 1, Bound: NewBound(20, 20, 20, 20)},
+				{ID: 2, Bound: NewBound(80, 80, 80, 80)},
+			},
+			args: args{
+				queries: []Bound{NewBound(10, 10, 30, 30), NewBound(90, 90, 100, 100)},
+			},
+			want: map[string]int{"node": 1, "entity": 1, "hit": 1, "query": 2},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := Build(100, 100, tt.entities, SetMaxEntities(3))
+
+			var buf bytes.Buffer
+			if err := q.WriteSVG(&buf, tt.args.queries...); err != nil {
+				t.Fatalf("QuadGo.WriteSVG() got error %v", err)
+			}
+
+			got := svgElements(t, buf.Bytes())
+			for class, want := range tt.want {
+				if got[class] != want {
+					t.Errorf("QuadGo.WriteSVG() %v elements = %v, want %v", class, got[class], want)
+				}
+			}
+			for class := range got {
+				if _, ok := tt.want[class]; !ok {
+					t.Errorf("QuadGo.WriteSVG() has %v %v elements, want 0", got[class], class)
+				}
+			}
+		})
+	}
+}
+
+func TestQuadGo_WriteSVG_error(t *testing.T) {
+	q := Build(100, 100, randomEntities(50, 100, 100))
+	if err := q.WriteSVG(failWriter{}); err == nil {
+		t.Errorf("QuadGo.WriteSVG() wanted an error")
+	}
+}
+
+func Test_depthColor(t *testing.T) {
+	tests := []struct {
+		name     string
+		depth    uint16
+		maxDepth uint16
+		want     string
+	}{
+		{name: "root", depth: 0, maxDepth: 5, want: hex(nodeShallowColor)},
+		{name: "max depth", depth: 5, maxDepth: 5, want: hex(nodeDeepColor)},
+		{name: "past max depth", depth: 7, maxDepth: 5, want: hex(nodeDeepColor)},
+		{name: "no depth", depth: 0, maxDepth: 0, want: hex(nodeShallowColor)},
+		{name: "half way", depth: 1, maxDepth: 2, want: "#a6b7c9"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := hex(depthColor(tt.depth, tt.maxDepth)); got != tt.want {
+				t.Errorf("depthColor() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
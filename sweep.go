@@ -0,0 +1,135 @@
+// Copyright 2019 Tskken. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package quadgo
+
+import (
+	"errors"
+	"math"
+	"sort"
+)
+
+// SweepAndPrune is a SpatialIndex which keeps its entities in a list sorted by the min x of
+// their bounds.
+//
+// A query only tests the entities whose min x falls between the min x of the query less the
+// widest entity and the max x of the query. This makes SweepAndPrune fast for entities spread
+// out along x, such as a side scrolling level, but slow for entities stacked on top of each
+// other or with a few very wide entities. Like Grid it has no bounds.
+type SweepAndPrune struct {
+	entities Entities
+
+	// maxWidth is the width of the widest entity that has been inserted
+	maxWidth float64
+}
+
+// NewSweepAndPrune creates an empty SweepAndPrune.
+func NewSweepAndPrune() *SweepAndPrune {
+	return &SweepAndPrune{}
+}
+
+// InsertEntities inserts any number of entities in to the list in order of their min x. Any
+// entities equal to one already in the list are skipped.
+//
+// This will return an error if you do not give it any entities.
+func (s *SweepAndPrune) InsertEntities(entities ...*Entity) error {
+	if len(entities) == 0 {
+		return errors.New("no entities given to SweepAndPrune.InsertEntities()")
+	}
+
+	for _, e := range entities {
+		// look through the entities with the same min x for an equal entity
+		i, found := s.search(e.Min.X), false
+		for ; i < len(s.entities) && s.entities[i].Min.X == e.Min.X; i++ {
+			if s.entities[i].IsEqual(e) {
+				found = true
+				break
+			}
+		}
+		if found {
+			continue
+		}
+
+		s.entities = append(s.entities, nil)
+		copy(s.entities[i+1:], s.entities[i:])
+		s.entities[i] = e
+
+		s.maxWidth = math.Max(s.maxWidth, e.Max.X-e.Min.X)
+	}
+	return nil
+}
+
+// Remove removes the given entity from the list.
+//
+// The given entity has to have the same ID and Bounds as the one to remove. This will return
+// an error if the entity given was not found.
+func (s *SweepAndPrune) Remove(entity *Entity) error {
+	for i := s.search(entity.Min.X); i < len(s.entities) && s.entities[i].Min.X == entity.Min.X; i++ {
+		if s.entities[i].IsEqual(entity) {
+			copy(s.entities[i:], s.entities[i+1:])
+			s.entities[len(s.entities)-1] = nil
+			s.entities = s.entities[:len(s.entities)-1]
+			return nil
+		}
+	}
+
+	return errors.New("could not find entity in index to remove")
+}
+
+// QueryFunc calls the given function for every entity that the given bound intersects with.
+//
+// Returning false from the given function stops the query. See QuadGo.QueryFunc().
+func (s *SweepAndPrune) QueryFunc(bound Bound, fn func(*Entity) bool) {
+	for i := s.search(bound.Min.X - s.maxWidth); i < len(s.entities) && s.entities[i].Min.X <= bound.Max.X; i++ {
+		e := s.entities[i]
+		if e.IsIntersect(bound) && e.narrow(bound, nil) && !fn(e) {
+			return
+		}
+	}
+}
+
+// Nearest returns up to k entities in the list closest to the given point, closest first.
+//
+// Nearest searches out both ways along x from the point until no closer entity could be
+// found. See QuadGo.Nearest().
+func (s *SweepAndPrune) Nearest(point Point, k int) Entities {
+	if k <= 0 || len(s.entities) == 0 {
+		return nil
+	}
+
+	found := newNearest(k)
+	start := s.search(point.X)
+
+	// entities after the point along x are at least as far away as their min x
+	for i := start; i < len(s.entities); i++ {
+		e := s.entities[i]
+		if e.Min.X-point.X > found.worst() {
+			break
+		}
+		found.add(e, e.Bound.distance(point))
+	}
+
+	// entities before the point along x reach no further then their min x plus the widest entity
+	for i := start - 1; i >= 0; i-- {
+		e := s.entities[i]
+		if point.X-(e.Min.X+s.maxWidth) > found.worst() {
+			break
+		}
+		found.add(e, e.Bound.distance(point))
+	}
+
+	return found.entities
+}
+
+// Len returns the number of entities in the list.
+func (s *SweepAndPrune) Len() int {
+	return len(s.entities)
+}
+
+// search returns the index of the first entity with a min x of at least x.
+func (s *SweepAndPrune) search(x float64) int {
+	return sort.Search(len(s.entities), func(i int) bool {
+		return s.entities[i].Min.X >= x
+	})
+}
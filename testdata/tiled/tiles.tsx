@@ -0,0 +1,22 @@
+<?xml version="1.0" encoding="UTF-8"?>
+<tileset version="1.10" tiledversion="1.10.2" name="tiles" tilewidth="16" tileheight="16" tilecount="3" columns="3">
+ <image source="tiles.png" width="48" height="16"/>
+ <tile id="0">
+  <properties>
+   <property name="solid" type="bool" value="true"/>
+  </properties>
+  <objectgroup draworder="index" id="2">
+   <object id="1" name="block" x="0" y="0" width="16" height="16"/>
+  </objectgroup>
+ </tile>
+ <tile id="1">
+  <objectgroup draworder="index" id="2">
+   <object id="1" name="ledge" type="platform" x="0" y="8" width="16" height="8"/>
+  </objectgroup>
+ </tile>
+ <tile id="2">
+  <properties>
+   <property name="decor" value="grass"/>
+  </properties>
+ </tile>
+</tileset>
@@ -0,0 +1,952 @@
+// Copyright 2019 Tskken. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package quadgo
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// flags stored in the high bits of a Tiled global tile ID.
+const (
+	tiledFlipHorizontal uint32 = 0x80000000
+	tiledFlipVertical   uint32 = 0x40000000
+	tiledFlipDiagonal   uint32 = 0x20000000
+	tiledFlags          uint32 = 0xF0000000
+)
+
+// TiledObject is the Data of entities loaded from a Tiled map.
+type TiledObject struct {
+	// Layer is the name of the layer the collider is from.
+	Layer string
+	// ID is the ID of the object in the map, or 0 for colliders from tile layers.
+	ID int
+	// GID is the global tile ID for colliders from tile layers and tile objects, or 0.
+	GID uint32
+	// Name is the name of the object.
+	Name string
+	// Type is the type or class of the object.
+	Type string
+	// Properties are the custom properties of the object. Colliders from tile layers have
+	// the properties of their tile along with the properties of their collision object.
+	Properties map[string]string
+}
+
+// LoadTiled reads a Tiled map from the given TMX or JSON file and returns a new tree with
+// an entity for each collider in the map.
+//
+// Every object in the object layers of the map is a collider, using the bounds of its
+// rectangle, ellipse, point, polygon or polyline after rotation. Each tile in the tile layers
+// of the map that has collision objects set in its tileset adds a collider for each of its
+// collision objects. The Data of each entity is a *TiledObject and the ID of each entity is
+// its order in the map starting at 1.
+//
+// External tilesets are read from their TSX or JSON files relative to the map. Only
+// orthogonal maps that are not infinite are supported.
+//
+// The bounds of the tree covers the map and all of its colliders. LoadTiled takes the same
+// Option functions as New.
+func LoadTiled(path string, ops ...Option) (*QuadGo, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var m *tiledMap
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".tmx":
+		m, err = parseTMX(data, filepath.Dir(path))
+	case ".json", ".tmj":
+		m, err = parseTiledJSON(data, filepath.Dir(path))
+	default:
+		return nil, fmt.Errorf("unsupported Tiled map file %v", path)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if m.Orientation != "orthogonal" {
+		return nil, fmt.Errorf("unsupported Tiled map orientation %v", m.Orientation)
+	}
+	if m.Infinite {
+		return nil, errors.New("infinite Tiled maps are not supported")
+	}
+
+	// find all of the colliders of the map
+	world := NewBound(0, 0, float64(m.Width*m.TileWidth), float64(m.Height*m.TileHeight))
+	var entities Entities
+	add := func(bound Bound, object *TiledObject) {
+		entities = append(entities, &Entity{
+			ID:    uint64(len(entities) + 1),
+			Bound: bound,
+			Data:  object,
+		})
+		world = world.union(bound)
+	}
+	if err := m.colliders(m.Layers, 0, 0, add); err != nil {
+		return nil, err
+	}
+
+	q := NewWithBound(world, ops...)
+	if err := q.load(entities); err != nil {
+		return nil, err
+	}
+
+	return q, nil
+}
+
+// tiledMap is a Tiled map read from either of its file formats.
+type tiledMap struct {
+	Orientation           string
+	Width, Height         int
+	TileWidth, TileHeight int
+	Infinite              bool
+	Tilesets              []*tiledTileset
+	Layers                []*tiledLayer
+}
+
+// tiledTileset is a tileset of a Tiled map with the tiles that have collision objects or properties.
+type tiledTileset struct {
+	FirstGID              uint32
+	TileWidth, TileHeight int
+	Tiles                 map[uint32]*tiledTile
+}
+
+// tiledTile is a tile of a tileset.
+type tiledTile struct {
+	Properties map[string]string
+	Objects    []*tiledObject
+}
+
+// tiledLayer is a tile, object or group layer of a Tiled map.
+type tiledLayer struct {
+	Name             string
+	OffsetX, OffsetY float64
+	Width, Height    int
+
+	// Data holds the global tile IDs of a tile layer.
+	Data []uint32
+	// Objects holds the objects of an object layer.
+	Objects []*tiledObject
+	// Layers holds the layers of a group layer.
+	Layers []*tiledLayer
+}
+
+// tiledObject is an object of an object layer or a collision object of a tile.
+type tiledObject struct {
+	ID            int
+	Name, Type    string
+	X, Y          float64
+	Width, Height float64
+	Rotation      float64
+	GID           uint32
+	Properties    map[string]string
+
+	// ellipses use the bounds of their rectangle, while points and polygons use their points
+	Point      bool
+	HasPolygon bool
+	Polygon    []Point
+}
+
+// colliders adds the colliders of the given layers with the given offset.
+func (m *tiledMap) colliders(layers []*tiledLayer, offsetX, offsetY float64, add func(Bound, *TiledObject)) error {
+	for _, l := range layers {
+		x, y := offsetX+l.OffsetX, offsetY+l.OffsetY
+
+		// add colliders for each object in an object layer
+		for _, o := range l.Objects {
+			add(o.bound(x, y), &TiledObject{
+				Layer:      l.Name,
+				ID:         o.ID,
+				GID:        o.GID &^ tiledFlags,
+				Name:       o.Name,
+				Type:       o.Type,
+				Properties: o.Properties,
+			})
+		}
+
+		// add colliders for each tile with collision objects in a tile layer
+		if len(l.Data) > 0 && len(l.Data) != l.Width*l.Height {
+			return fmt.Errorf("Tiled layer %v has %v tiles, want %v", l.Name, len(l.Data), l.Width*l.Height)
+		}
+		for i, gid := range l.Data {
+			tileset, tile := m.tile(gid)
+			if tile == nil {
+				continue
+			}
+
+			// tiles are drawn with their bottom left corner at the bottom left of their cell
+			col, row := i%l.Width, i/l.Width
+			tileX := x + float64(col*m.TileWidth)
+			tileY := y + float64((row+1)*m.TileHeight-tileset.TileHeight)
+
+			for _, o := range tile.Objects {
+				b := tileset.flip(o.bound(0, 0), gid)
+
+				var properties map[string]string
+				if len(tile.Properties)+len(o.Properties) > 0 {
+					properties = make(map[string]string, len(tile.Properties)+len(o.Properties))
+					for k, v := range tile.Properties {
+						properties[k] = v
+					}
+					for k, v := range o.Properties {
+						properties[k] = v
+					}
+				}
+
+				add(NewBound(tileX+b.Min.X, tileY+b.Min.Y, tileX+b.Max.X, tileY+b.Max.Y), &TiledObject{
+					Layer:      l.Name,
+					GID:        gid &^ tiledFlags,
+					Name:       o.Name,
+					Type:       o.Type,
+					Properties: properties,
+				})
+			}
+		}
+
+		if err := m.colliders(l.Layers, x, y, add); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// tile returns the tileset and tile for the given global tile ID, or a nil tile if the
+// tile has no collision objects.
+func (m *tiledMap) tile(gid uint32) (*tiledTileset, *tiledTile) {
+	gid &^= tiledFlags
+	if gid == 0 {
+		return nil, nil
+	}
+
+	// find the tileset with the largest first global tile ID that holds the tile
+	var tileset *tiledTileset
+	for _, ts := range m.Tilesets {
+		if ts.FirstGID <= gid && (tileset == nil || ts.FirstGID > tileset.FirstGID) {
+			tileset = ts
+		}
+	}
+	if tileset == nil {
+		return nil, nil
+	}
+
+	tile := tileset.Tiles[gid-tileset.FirstGID]
+	if tile == nil || len(tile.Objects) == 0 {
+		return nil, nil
+	}
+	return tileset, tile
+}
+
+// flip returns the given bound with in a tile flipped by the flags of the given global tile ID.
+func (ts *tiledTileset) flip(b Bound, gid uint32) Bound {
+	w, h := float64(ts.TileWidth), float64(ts.TileHeight)
+
+	if gid&tiledFlipDiagonal != 0 {
+		b = NewBound(b.Min.Y, b.Min.X, b.Max.Y, b.Max.X)
+		w, h = h, w
+	}
+	if gid&tiledFlipHorizontal != 0 {
+		b = NewBound(w-b.Max.X, b.Min.Y, w-b.Min.X, b.Max.Y)
+	}
+	if gid&tiledFlipVertical != 0 {
+		b = NewBound(b.Min.X, h-b.Max.Y, b.Max.X, h-b.Min.Y)
+	}
+
+	return b
+}
+
+// bound returns the bounds of the object after rotation with the given offset.
+func (o *tiledObject) bound(offsetX, offsetY float64) Bound {
+	// find the corners of the object relative to its position
+	var points []Point
+	switch {
+	case o.Point:
+		points = []Point{{0, 0}}
+	case o.HasPolygon:
+		points = o.Polygon
+	case o.GID != 0:
+		// tile objects are positioned by their bottom left corner
+		points = []Point{{0, -o.Height}, {o.Width, -o.Height}, {o.Width, 0}, {0, 0}}
+	default:
+		points = []Point{{0, 0}, {o.Width, 0}, {o.Width, o.Height}, {0, o.Height}}
+	}
+
+	// rotate the corners clockwise around the objects position, rounding off the floating
+	// point error left from rotating by a multiple of 90 degrees
+	const precision = 1e9
+	sin, cos := math.Sincos(o.Rotation * math.Pi / 180)
+	x, y := offsetX+o.X, offsetY+o.Y
+
+	var bound Bound
+	for i, p := range points {
+		corner := Point{
+			X: x + math.Round((p.X*cos-p.Y*sin)*precision)/precision,
+			Y: y + math.Round((p.X*sin+p.Y*cos)*precision)/precision,
+		}
+
+		b := NewBound(corner.X, corner.Y, corner.X, corner.Y)
+		if i == 0 {
+			bound = b
+			continue
+		}
+		bound = bound.union(b)
+	}
+	return bound
+}
+
+// parseTiledGIDs decodes the global tile IDs of a tile layer in the given encoding and compression.
+func parseTiledGIDs(data, encoding, compression string) ([]uint32, error) {
+	switch encoding {
+	case "csv":
+		var gids []uint32
+		for _, s := range strings.Split(data, ",") {
+			s = strings.TrimSpace(s)
+			if s == "" {
+				continue
+			}
+
+			gid, err := strconv.ParseUint(s, 10, 32)
+			if err != nil {
+				return nil, err
+			}
+			gids = append(gids, uint32(gid))
+		}
+		return gids, nil
+	case "base64":
+		raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(data))
+		if err != nil {
+			return nil, err
+		}
+
+		var r io.Reader = bytes.NewReader(raw)
+		switch compression {
+		case "":
+		case "gzip":
+			if r, err = gzip.NewReader(r); err != nil {
+				return nil, err
+			}
+		case "zlib":
+			if r, err = zlib.NewReader(r); err != nil {
+				return nil, err
+			}
+		default:
+			return nil, fmt.Errorf("unsupported Tiled layer compression %v", compression)
+		}
+
+		if raw, err = ioutil.ReadAll(r); err != nil {
+			return nil, err
+		}
+		if len(raw)%4 != 0 {
+			return nil, errors.New("Tiled layer data is not a list of tile IDs")
+		}
+
+		gids := make([]uint32, len(raw)/4)
+		for i := range gids {
+			gids[i] = binary.LittleEndian.Uint32(raw[i*4:])
+		}
+		return gids, nil
+	default:
+		return nil, fmt.Errorf("unsupported Tiled layer encoding %v", encoding)
+	}
+}
+
+// parseTiledPoints decodes the points of a TMX polygon or polyline.
+func parseTiledPoints(s string) ([]Point, error) {
+	var points []Point
+	for _, pair := range strings.Fields(s) {
+		xy := strings.Split(pair, ",")
+		if len(xy) != 2 {
+			return nil, fmt.Errorf("invalid Tiled point %v", pair)
+		}
+
+		x, err := strconv.ParseFloat(xy[0], 64)
+		if err != nil {
+			return nil, err
+		}
+		y, err := strconv.ParseFloat(xy[1], 64)
+		if err != nil {
+			return nil, err
+		}
+		points = append(points, Point{X: x, Y: y})
+	}
+	return points, nil
+}
+
+// tmxMap is the XML form of a TMX map.
+type tmxMap struct {
+	Orientation string        `xml:"orientation,attr"`
+	Width       int           `xml:"width,attr"`
+	Height      int           `xml:"height,attr"`
+	TileWidth   int           `xml:"tilewidth,attr"`
+	TileHeight  int           `xml:"tileheight,attr"`
+	Infinite    int           `xml:"infinite,attr"`
+	Tilesets    []*tmxTileset `xml:"tileset"`
+	tmxGroup
+}
+
+// tmxGroup is the XML form of a TMX group layer, which the map also is.
+type tmxGroup struct {
+	Name       string         `xml:"name,attr"`
+	OffsetX    float64        `xml:"offsetx,attr"`
+	OffsetY    float64        `xml:"offsety,attr"`
+	Properties []*tmxProperty `xml:"properties>property"`
+
+	// Children holds the layers of the group in the order they are in the map, whatever
+	// their kind, as the IDs of entities follow the order of the layers.
+	Children []*tmxChild `xml:",any"`
+}
+
+// tmxChild is the XML form of one layer of a group, holding a tile layer, an object layer
+// or a group layer. Any other element is skipped and leaves it empty.
+type tmxChild struct {
+	Layer   *tmxLayer
+	Objects *tmxObjects
+	Group   *tmxGroup
+}
+
+// UnmarshalXML decodes the layer by the name of its element.
+func (c *tmxChild) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	switch start.Name.Local {
+	case "layer":
+		c.Layer = new(tmxLayer)
+		return d.DecodeElement(c.Layer, &start)
+	case "objectgroup":
+		c.Objects = new(tmxObjects)
+		return d.DecodeElement(c.Objects, &start)
+	case "group":
+		c.Group = new(tmxGroup)
+		return d.DecodeElement(c.Group, &start)
+	}
+	return d.Skip()
+}
+
+// tmxTileset is the XML form of a TSX tileset or a TMX tileset.
+type tmxTileset struct {
+	FirstGID   uint32     `xml:"firstgid,attr"`
+	Source     string     `xml:"source,attr"`
+	TileWidth  int        `xml:"tilewidth,attr"`
+	TileHeight int        `xml:"tileheight,attr"`
+	Tiles      []*tmxTile `xml:"tile"`
+}
+
+// tmxTile is the XML form of a tile of a tileset.
+type tmxTile struct {
+	ID         uint32         `xml:"id,attr"`
+	Properties []*tmxProperty `xml:"properties>property"`
+	Objects    *tmxObjects    `xml:"objectgroup"`
+}
+
+// tmxLayer is the XML form of a tile layer.
+type tmxLayer struct {
+	Name    string  `xml:"name,attr"`
+	Width   int     `xml:"width,attr"`
+	Height  int     `xml:"height,attr"`
+	OffsetX float64 `xml:"offsetx,attr"`
+	OffsetY float64 `xml:"offsety,attr"`
+	Data    struct {
+		Encoding    string `xml:"encoding,attr"`
+		Compression string `xml:"compression,attr"`
+		Tiles       []struct {
+			GID uint32 `xml:"gid,attr"`
+		} `xml:"tile"`
+		Value string `xml:",chardata"`
+	} `xml:"data"`
+}
+
+// tmxObjects is the XML form of an object layer or the collision objects of a tile.
+type tmxObjects struct {
+	Name    string       `xml:"name,attr"`
+	OffsetX float64      `xml:"offsetx,attr"`
+	OffsetY float64      `xml:"offsety,attr"`
+	Objects []*tmxObject `xml:"object"`
+}
+
+// tmxObject is the XML form of an object.
+type tmxObject struct {
+	ID         int            `xml:"id,attr"`
+	Name       string         `xml:"name,attr"`
+	Type       string         `xml:"type,attr"`
+	Class      string         `xml:"class,attr"`
+	X          float64        `xml:"x,attr"`
+	Y          float64        `xml:"y,attr"`
+	Width      float64        `xml:"width,attr"`
+	Height     float64        `xml:"height,attr"`
+	Rotation   float64        `xml:"rotation,attr"`
+	GID        uint32         `xml:"gid,attr"`
+	Properties []*tmxProperty `xml:"properties>property"`
+	Point      *struct{}      `xml:"point"`
+	Polygon    *tmxPoints     `xml:"polygon"`
+	Polyline   *tmxPoints     `xml:"polyline"`
+}
+
+// tmxPoints is the XML form of a polygon or polyline.
+type tmxPoints struct {
+	Points string `xml:"points,attr"`
+}
+
+// tmxProperty is the XML form of a custom property.
+type tmxProperty struct {
+	Name  string  `xml:"name,attr"`
+	Value *string `xml:"value,attr"`
+	Text  string  `xml:",chardata"`
+}
+
+// parseTMX reads a TMX map with its external tilesets relative to the given directory.
+func parseTMX(data []byte, dir string) (*tiledMap, error) {
+	var x tmxMap
+	if err := xml.Unmarshal(data, &x); err != nil {
+		return nil, err
+	}
+
+	m := &tiledMap{
+		Orientation: x.Orientation,
+		Width:       x.Width,
+		Height:      x.Height,
+		TileWidth:   x.TileWidth,
+		TileHeight:  x.TileHeight,
+		Infinite:    x.Infinite != 0,
+	}
+
+	for _, ts := range x.Tilesets {
+		tileset, err := ts.tileset(dir)
+		if err != nil {
+			return nil, err
+		}
+		m.Tilesets = append(m.Tilesets, tileset)
+	}
+
+	root, err := x.tmxGroup.layer()
+	if err != nil {
+		return nil, err
+	}
+	m.Layers = root.Layers
+
+	return m, nil
+}
+
+// tileset converts the TMX tileset, reading it from its source if it is external.
+func (ts *tmxTileset) tileset(dir string) (*tiledTileset, error) {
+	firstGID := ts.FirstGID
+	if ts.Source != "" {
+		data, err := ioutil.ReadFile(filepath.Join(dir, ts.Source))
+		if err != nil {
+			return nil, err
+		}
+
+		// external tilesets can be either TSX or JSON
+		if ext := strings.ToLower(filepath.Ext(ts.Source)); ext == ".json" || ext == ".tsj" {
+			tileset, err := parseTiledJSONTileset(data)
+			if err != nil {
+				return nil, err
+			}
+			tileset.FirstGID = firstGID
+			return tileset, nil
+		}
+
+		ts = new(tmxTileset)
+		if err := xml.Unmarshal(data, ts); err != nil {
+			return nil, err
+		}
+	}
+
+	tileset := &tiledTileset{
+		FirstGID:   firstGID,
+		TileWidth:  ts.TileWidth,
+		TileHeight: ts.TileHeight,
+		Tiles:      make(map[uint32]*tiledTile, len(ts.Tiles)),
+	}
+	for _, t := range ts.Tiles {
+		tile := &tiledTile{
+			Properties: tmxProperties(t.Properties),
+		}
+		if t.Objects != nil {
+			for _, o := range t.Objects.Objects {
+				object, err := o.object()
+				if err != nil {
+					return nil, err
+				}
+				tile.Objects = append(tile.Objects, object)
+			}
+		}
+		tileset.Tiles[t.ID] = tile
+	}
+
+	return tileset, nil
+}
+
+// layer converts the TMX group layer and all of its layers, in the order they are in the map.
+func (g *tmxGroup) layer() (*tiledLayer, error) {
+	l := &tiledLayer{
+		Name:    g.Name,
+		OffsetX: g.OffsetX,
+		OffsetY: g.OffsetY,
+	}
+
+	for _, c := range g.Children {
+		var layer *tiledLayer
+		var err error
+		switch {
+		case c.Layer != nil:
+			layer, err = c.Layer.layer()
+		case c.Objects != nil:
+			layer, err = c.Objects.layer()
+		case c.Group != nil:
+			layer, err = c.Group.layer()
+		default:
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		l.Layers = append(l.Layers, layer)
+	}
+
+	return l, nil
+}
+
+// layer converts the TMX tile layer.
+func (tl *tmxLayer) layer() (*tiledLayer, error) {
+	layer := &tiledLayer{
+		Name:    tl.Name,
+		OffsetX: tl.OffsetX,
+		OffsetY: tl.OffsetY,
+		Width:   tl.Width,
+		Height:  tl.Height,
+	}
+
+	// layers without an encoding list each tile as an element
+	if tl.Data.Encoding == "" {
+		for _, t := range tl.Data.Tiles {
+			layer.Data = append(layer.Data, t.GID)
+		}
+		return layer, nil
+	}
+
+	gids, err := parseTiledGIDs(tl.Data.Value, tl.Data.Encoding, tl.Data.Compression)
+	if err != nil {
+		return nil, err
+	}
+	layer.Data = gids
+
+	return layer, nil
+}
+
+// layer converts the TMX object layer.
+func (og *tmxObjects) layer() (*tiledLayer, error) {
+	layer := &tiledLayer{
+		Name:    og.Name,
+		OffsetX: og.OffsetX,
+		OffsetY: og.OffsetY,
+	}
+	for _, o := range og.Objects {
+		object, err := o.object()
+		if err != nil {
+			return nil, err
+		}
+		layer.Objects = append(layer.Objects, object)
+	}
+
+	return layer, nil
+}
+
+// object converts the TMX object.
+func (o *tmxObject) object() (*tiledObject, error) {
+	object := &tiledObject{
+		ID:         o.ID,
+		Name:       o.Name,
+		Type:       o.Type,
+		X:          o.X,
+		Y:          o.Y,
+		Width:      o.Width,
+		Height:     o.Height,
+		Rotation:   o.Rotation,
+		GID:        o.GID,
+		Point:      o.Point != nil,
+		Properties: tmxProperties(o.Properties),
+	}
+	if object.Type == "" {
+		object.Type = o.Class
+	}
+
+	points := o.Polygon
+	if points == nil {
+		points = o.Polyline
+	}
+	if points != nil {
+		polygon, err := parseTiledPoints(points.Points)
+		if err != nil {
+			return nil, err
+		}
+		object.Polygon = polygon
+		object.HasPolygon = true
+	}
+
+	return object, nil
+}
+
+// tmxProperties converts the TMX custom properties.
+func tmxProperties(properties []*tmxProperty) map[string]string {
+	if len(properties) == 0 {
+		return nil
+	}
+
+	m := make(map[string]string, len(properties))
+	for _, p := range properties {
+		// multi line string properties are held as text instead of a value
+		if p.Value != nil {
+			m[p.Name] = *p.Value
+		} else {
+			m[p.Name] = p.Text
+		}
+	}
+	return m
+}
+
+// jsonTiledMap is the JSON form of a Tiled map.
+type jsonTiledMap struct {
+	Orientation string              `json:"orientation"`
+	Width       int                 `json:"width"`
+	Height      int                 `json:"height"`
+	TileWidth   int                 `json:"tilewidth"`
+	TileHeight  int                 `json:"tileheight"`
+	Infinite    bool                `json:"infinite"`
+	Tilesets    []*jsonTiledTileset `json:"tilesets"`
+	Layers      []*jsonTiledLayer   `json:"layers"`
+}
+
+// jsonTiledTileset is the JSON form of a tileset.
+type jsonTiledTileset struct {
+	FirstGID   uint32 `json:"firstgid"`
+	Source     string `json:"source"`
+	TileWidth  int    `json:"tilewidth"`
+	TileHeight int    `json:"tileheight"`
+	Tiles      []struct {
+		ID          uint32                `json:"id"`
+		Properties  []*jsonTiledProperty  `json:"properties"`
+		ObjectGroup *jsonTiledObjectGroup `json:"objectgroup"`
+	} `json:"tiles"`
+}
+
+// jsonTiledObjectGroup is the JSON form of the collision objects of a tile.
+type jsonTiledObjectGroup struct {
+	Objects []*jsonTiledObject `json:"objects"`
+}
+
+// jsonTiledLayer is the JSON form of a tile, object or group layer.
+type jsonTiledLayer struct {
+	Type        string             `json:"type"`
+	Name        string             `json:"name"`
+	Width       int                `json:"width"`
+	Height      int                `json:"height"`
+	OffsetX     float64            `json:"offsetx"`
+	OffsetY     float64            `json:"offsety"`
+	Encoding    string             `json:"encoding"`
+	Compression string             `json:"compression"`
+	Data        json.RawMessage    `json:"data"`
+	Objects     []*jsonTiledObject `json:"objects"`
+	Layers      []*jsonTiledLayer  `json:"layers"`
+}
+
+// jsonTiledObject is the JSON form of an object.
+type jsonTiledObject struct {
+	ID         int                  `json:"id"`
+	Name       string               `json:"name"`
+	Type       string               `json:"type"`
+	Class      string               `json:"class"`
+	X          float64              `json:"x"`
+	Y          float64              `json:"y"`
+	Width      float64              `json:"width"`
+	Height     float64              `json:"height"`
+	Rotation   float64              `json:"rotation"`
+	GID        uint32               `json:"gid"`
+	Point      bool                 `json:"point"`
+	Polygon    []Point              `json:"polygon"`
+	Polyline   []Point              `json:"polyline"`
+	Properties []*jsonTiledProperty `json:"properties"`
+}
+
+// jsonTiledProperty is the JSON form of a custom property.
+type jsonTiledProperty struct {
+	Name  string      `json:"name"`
+	Value interface{} `json:"value"`
+}
+
+// parseTiledJSON reads a JSON map with its external tilesets relative to the given directory.
+func parseTiledJSON(data []byte, dir string) (*tiledMap, error) {
+	var j jsonTiledMap
+	if err := json.Unmarshal(data, &j); err != nil {
+		return nil, err
+	}
+
+	m := &tiledMap{
+		Orientation: j.Orientation,
+		Width:       j.Width,
+		Height:      j.Height,
+		TileWidth:   j.TileWidth,
+		TileHeight:  j.TileHeight,
+		Infinite:    j.Infinite,
+	}
+
+	for _, ts := range j.Tilesets {
+		var tileset *tiledTileset
+		var err error
+		if ts.Source != "" {
+			// external tilesets can be either TSX or JSON
+			tileset, err = (&tmxTileset{Source: ts.Source}).tileset(dir)
+		} else {
+			tileset, err = ts.tileset()
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		tileset.FirstGID = ts.FirstGID
+		m.Tilesets = append(m.Tilesets, tileset)
+	}
+
+	for _, l := range j.Layers {
+		layer, err := l.layer()
+		if err != nil {
+			return nil, err
+		}
+		m.Layers = append(m.Layers, layer)
+	}
+
+	return m, nil
+}
+
+// parseTiledJSONTileset reads an external JSON tileset.
+func parseTiledJSONTileset(data []byte) (*tiledTileset, error) {
+	var ts jsonTiledTileset
+	if err := json.Unmarshal(data, &ts); err != nil {
+		return nil, err
+	}
+	return ts.tileset()
+}
+
+// tileset converts the JSON tileset.
+func (ts *jsonTiledTileset) tileset() (*tiledTileset, error) {
+	tileset := &tiledTileset{
+		FirstGID:   ts.FirstGID,
+		TileWidth:  ts.TileWidth,
+		TileHeight: ts.TileHeight,
+		Tiles:      make(map[uint32]*tiledTile, len(ts.Tiles)),
+	}
+	for _, t := range ts.Tiles {
+		tile := &tiledTile{
+			Properties: jsonTiledProperties(t.Properties),
+		}
+		if t.ObjectGroup != nil {
+			for _, o := range t.ObjectGroup.Objects {
+				tile.Objects = append(tile.Objects, o.object())
+			}
+		}
+		tileset.Tiles[t.ID] = tile
+	}
+
+	return tileset, nil
+}
+
+// layer converts the JSON layer and all of its layers.
+func (l *jsonTiledLayer) layer() (*tiledLayer, error) {
+	layer := &tiledLayer{
+		Name:    l.Name,
+		OffsetX: l.OffsetX,
+		OffsetY: l.OffsetY,
+		Width:   l.Width,
+		Height:  l.Height,
+	}
+
+	switch l.Type {
+	case "tilelayer":
+		// tile data is either a list of IDs or an encoded string
+		if l.Encoding == "base64" {
+			var s string
+			if err := json.Unmarshal(l.Data, &s); err != nil {
+				return nil, err
+			}
+
+			gids, err := parseTiledGIDs(s, l.Encoding, l.Compression)
+			if err != nil {
+				return nil, err
+			}
+			layer.Data = gids
+		} else if len(l.Data) > 0 {
+			if err := json.Unmarshal(l.Data, &layer.Data); err != nil {
+				return nil, err
+			}
+		}
+	case "objectgroup":
+		for _, o := range l.Objects {
+			layer.Objects = append(layer.Objects, o.object())
+		}
+	case "group":
+		for _, child := range l.Layers {
+			c, err := child.layer()
+			if err != nil {
+				return nil, err
+			}
+			layer.Layers = append(layer.Layers, c)
+		}
+	}
+
+	return layer, nil
+}
+
+// object converts the JSON object.
+func (o *jsonTiledObject) object() *tiledObject {
+	object := &tiledObject{
+		ID:         o.ID,
+		Name:       o.Name,
+		Type:       o.Type,
+		X:          o.X,
+		Y:          o.Y,
+		Width:      o.Width,
+		Height:     o.Height,
+		Rotation:   o.Rotation,
+		GID:        o.GID,
+		Point:      o.Point,
+		Properties: jsonTiledProperties(o.Properties),
+	}
+	if object.Type == "" {
+		object.Type = o.Class
+	}
+
+	if o.Polygon != nil {
+		object.Polygon, object.HasPolygon = o.Polygon, true
+	} else if o.Polyline != nil {
+		object.Polygon, object.HasPolygon = o.Polyline, true
+	}
+
+	return object
+}
+
+// jsonTiledProperties converts the JSON custom properties, formatting each value as a string.
+func jsonTiledProperties(properties []*jsonTiledProperty) map[string]string {
+	if len(properties) == 0 {
+		return nil
+	}
+
+	m := make(map[string]string, len(properties))
+	for _, p := range properties {
+		m[p.Name] = fmt.Sprint(p.Value)
+	}
+	return m
+}
@@ -0,0 +1,223 @@
+// Copyright 2019 Tskken. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package quadgo
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestLoadTiled(t *testing.T) {
+	// every file holds the same map so they all have the same colliders
+	want := []struct {
+		bound  Bound
+		object TiledObject
+	}{
+		{NewBound(0, 0, 16, 16), TiledObject{Layer: "ground", GID: 1, Name: "block", Properties: map[string]string{"solid": "true"}}},
+		{NewBound(48, 8, 64, 16), TiledObject{Layer: "ground", GID: 2, Name: "ledge", Type: "platform"}},
+		{NewBound(36, 20, 44, 28), TiledObject{Layer: "ground", GID: 10, Name: "rock"}},
+		{NewBound(0, 32, 16, 40), TiledObject{Layer: "ground", GID: 2, Name: "ledge", Type: "platform"}},
+		{NewBound(16, 32, 32, 48), TiledObject{Layer: "ground", GID: 1, Name: "block", Properties: map[string]string{"solid": "true"}}},
+		{NewBound(12, 23, 20, 39), TiledObject{Layer: "triggers", ID: 1, Name: "door", Type: "trigger", Properties: map[string]string{"target": "level2"}}},
+		{NewBound(42, 11, 42, 11), TiledObject{Layer: "triggers", ID: 2, Name: "spawn"}},
+		{NewBound(34, 27, 50, 35), TiledObject{Layer: "triggers", ID: 3, Name: "ramp"}},
+		{NewBound(-2, 3, 2, 13), TiledObject{Layer: "triggers", ID: 4, Name: "beam"}},
+		{NewBound(61, 41, 65, 45), TiledObject{Layer: "extras", ID: 5, Name: "bush", Type: "scenery"}},
+	}
+
+	tests := []struct {
+		name string
+		file string
+	}{
+		{name: "tmx with csv data", file: "testdata/tiled/level.tmx"},
+		{name: "tmx with zlib data", file: "testdata/tiled/level_zlib.tmx"},
+		{name: "json", file: "testdata/tiled/level.json"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := LoadTiled(tt.file, SetMaxEntities(2))
+			if err != nil {
+				t.Fatalf("quadgo.LoadTiled() got error %v", err)
+			}
+
+			if got.Len() != len(want) {
+				t.Fatalf("quadgo.LoadTiled() Len() = %v, want %v", got.Len(), len(want))
+			}
+			if wantBound := NewBound(-2, 0, 65, 48); !got.Bounds().IsEqual(wantBound) {
+				t.Errorf("quadgo.LoadTiled() Bounds() = %v, want %v", got.Bounds(), wantBound)
+			}
+
+			entities := <-got.All()
+			sort.Slice(entities, func(i, j int) bool { return entities[i].ID < entities[j].ID })
+			for i, e := range entities {
+				if e.ID != uint64(i+1) {
+					t.Fatalf("quadgo.LoadTiled() entity ID = %v, want %v", e.ID, i+1)
+				}
+				if !e.Bound.IsEqual(want[i].bound) {
+					t.Errorf("quadgo.LoadTiled() entity %v bound = %v, want %v", e.ID, e.Bound, want[i].bound)
+				}
+				object, ok := e.Data.(*TiledObject)
+				if !ok {
+					t.Fatalf("quadgo.LoadTiled() entity data = %T, want *TiledObject", e.Data)
+				}
+				if !reflect.DeepEqual(*object, want[i].object) {
+					t.Errorf("quadgo.LoadTiled() entity %v data = %+v, want %+v", e.ID, *object, want[i].object)
+				}
+			}
+
+			// the colliders have to be found by queries on the tree
+			for _, w := range want {
+				if !<-got.IsIntersect(w.bound) {
+					t.Errorf("quadgo.LoadTiled() IsIntersect(%v) = false, want true", w.bound)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadTiled_order(t *testing.T) {
+	// the layers of this map are an object layer, a tile layer, a group and then another object
+	// layer, so the TMX layers of each kind are between layers of other kinds
+	want := []struct {
+		layer string
+		name  string
+	}{
+		{"triggers", "door"},
+		{"triggers", "spawn"},
+		{"ground", "block"},
+		{"ground", "ledge"},
+		{"ground", "block"},
+		{"extras", "bush"},
+		{"late", "sign"},
+	}
+
+	fromTMX, err := LoadTiled("testdata/tiled/level_order.tmx")
+	if err != nil {
+		t.Fatalf("quadgo.LoadTiled() got error %v", err)
+	}
+	fromJSON, err := LoadTiled("testdata/tiled/level_order.json")
+	if err != nil {
+		t.Fatalf("quadgo.LoadTiled() got error %v", err)
+	}
+
+	byID := func(q *QuadGo) Entities {
+		entities := <-q.All()
+		sort.Slice(entities, func(i, j int) bool { return entities[i].ID < entities[j].ID })
+		return entities
+	}
+	gotTMX, gotJSON := byID(fromTMX), byID(fromJSON)
+	if len(gotTMX) != len(want) || len(gotJSON) != len(want) {
+		t.Fatalf("quadgo.LoadTiled() has %v TMX and %v JSON entities, want %v", len(gotTMX), len(gotJSON), len(want))
+	}
+
+	// the entities of both files have the same IDs, in the order of the layers of the map
+	for i := range want {
+		object := gotTMX[i].Data.(*TiledObject)
+		if object.Layer != want[i].layer || object.Name != want[i].name {
+			t.Errorf("quadgo.LoadTiled() TMX entity %v = %v %v, want %v %v", gotTMX[i].ID, object.Layer, object.Name, want[i].layer, want[i].name)
+		}
+		if !gotTMX[i].IsEqual(gotJSON[i]) || !reflect.DeepEqual(gotTMX[i].Data, gotJSON[i].Data) {
+			t.Errorf("quadgo.LoadTiled() TMX entity %v = %v, want the JSON entity %v", gotTMX[i].ID, gotTMX[i], gotJSON[i])
+		}
+	}
+}
+
+func TestLoadTiled_errors(t *testing.T) {
+	dir, err := ioutil.TempDir("", "quadgo")
+	if err != nil {
+		t.Fatalf("could not create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	tests := []struct {
+		name string
+		file string
+		data string
+	}{
+		{
+			name: "unsupported file",
+			file: "level.txt",
+			data: "",
+		},
+		{
+			name: "missing file",
+			file: "missing.tmx",
+		},
+		{
+			name: "invalid xml",
+			file: "invalid.tmx",
+			data: `<map orientation="orthogonal"`,
+		},
+		{
+			name: "isometric map",
+			file: "isometric.tmx",
+			data: `<map orientation="isometric" width="1" height="1" tilewidth="16" tileheight="16"/>`,
+		},
+		{
+			name: "infinite map",
+			file: "infinite.json",
+			data: `{"orientation": "orthogonal", "infinite": true, "layers": []}`,
+		},
+		{
+			name: "missing tileset",
+			file: "tileset.json",
+			data: `{"orientation": "orthogonal", "tilesets": [{"firstgid": 1, "source": "missing.tsx"}]}`,
+		},
+		{
+			name: "wrong tile count",
+			file: "tiles.tmx",
+			data: `<map orientation="orthogonal" width="2" height="2" tilewidth="16" tileheight="16">` +
+				`<layer name="ground" width="2" height="2"><data encoding="csv">1,0,0</data></layer></map>`,
+		},
+		{
+			name: "unsupported compression",
+			file: "compression.json",
+			data: `{"orientation": "orthogonal", "layers": [{"type": "tilelayer", "width": 1, "height": 1, ` +
+				`"encoding": "base64", "compression": "zstd", "data": "AAAAAA=="}]}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, tt.file)
+			if tt.name != "missing file" {
+				if err := ioutil.WriteFile(path, []byte(tt.data), 0644); err != nil {
+					t.Fatalf("could not write %v: %v", path, err)
+				}
+			}
+
+			if _, err := LoadTiled(path); err == nil {
+				t.Errorf("quadgo.LoadTiled() wanted an error")
+			}
+		})
+	}
+}
+
+func TestTiledTileset_flip(t *testing.T) {
+	ts := &tiledTileset{TileWidth: 16, TileHeight: 32}
+	b := NewBound(0, 8, 4, 16)
+
+	tests := []struct {
+		name string
+		gid  uint32
+		want Bound
+	}{
+		{name: "no flip", gid: 1, want: NewBound(0, 8, 4, 16)},
+		{name: "horizontal", gid: 1 | tiledFlipHorizontal, want: NewBound(12, 8, 16, 16)},
+		{name: "vertical", gid: 1 | tiledFlipVertical, want: NewBound(0, 16, 4, 24)},
+		{name: "diagonal", gid: 1 | tiledFlipDiagonal, want: NewBound(8, 0, 16, 4)},
+		{name: "rotated 90 degrees", gid: 1 | tiledFlipDiagonal | tiledFlipHorizontal, want: NewBound(16, 0, 24, 4)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ts.flip(b, tt.gid); !got.IsEqual(tt.want) {
+				t.Errorf("tiledTileset.flip() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
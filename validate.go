@@ -0,0 +1,155 @@
+// Copyright 2019 Tskken. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package quadgo
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ValidationError is the error returned by Validate() listing every problem found in a tree.
+type ValidationError struct {
+	// Violations describes each problem found, in the order the tree was walked.
+	Violations []string
+}
+
+// Error returns all violations of the tree, one per line.
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("QuadGo tree has %v violations:\n%v", len(e.Violations), strings.Join(e.Violations, "\n"))
+}
+
+// Validate walks the tree checking that its structure is correct, returning a
+// *ValidationError listing every problem found or nil if there are none.
+//
+// Validate checks that:
+//  - every leaf node only holds entities it overlaps, and holds each of them once
+//  - every entity is held by every leaf node it overlaps
+//  - branch nodes hold no entities
+//  - the children of every branch node tile its bound
+//  - no node is deeper then the max depth or has the wrong depth for its parent
+//  - every node links to its parent, other then nodes shared with a Snapshot which link to
+//    the parent they had when the snapshot was taken
+//  - no leaf node holds more then the max entities above the max depth
+//  - no branch node should have been collapsed in to a leaf node
+//  - Len() is the number of distinct entities in the tree
+//
+// Validate is meant for tests and debugging. Building with the quadgodebug build tag calls
+// Validate after every change to a tree, panicking if it is not valid.
+func (q *QuadGo) Validate() error {
+	v := &validator{q: q}
+
+	if q.node.parent != nil {
+		v.add(q.node, "root node has a parent")
+	}
+	if q.depth != 0 {
+		v.add(q.node, "root node has depth %v", q.depth)
+	}
+	v.node(q.node)
+
+	// check every entity is in each leaf it overlaps and the count of entities
+	count := 0
+	q.forEach(q.bound, func(e *Entity) bool {
+		count++
+		v.covered(q.node, e)
+		return true
+	})
+	if count != q.size {
+		v.violations = append(v.violations, fmt.Sprintf("tree has %v entities but Len() is %v", count, q.size))
+	}
+
+	if len(v.violations) > 0 {
+		return &ValidationError{Violations: v.violations}
+	}
+	return nil
+}
+
+// validator collects the violations found in a tree.
+type validator struct {
+	q          *QuadGo
+	violations []string
+}
+
+// add adds a violation for the given node.
+func (v *validator) add(n *node, format string, args ...interface{}) {
+	v.violations = append(v.violations, fmt.Sprintf("node at depth %v with bound %v to %v: ", n.depth, n.bound.Min, n.bound.Max)+fmt.Sprintf(format, args...))
+}
+
+// node checks the given node and all of its children.
+func (v *validator) node(n *node) {
+	if n.depth > v.q.maxDepth {
+		v.add(n, "deeper then max depth %v", v.q.maxDepth)
+	}
+
+	// check leaf nodes
+	if len(n.children) == 0 {
+		for i, e := range n.entities {
+			if !n.bound.IsIntersect(e.Bound) {
+				v.add(n, "holds entity %v which it does not overlap", e.ID)
+			}
+			if n.entities[:i].Contains(e) {
+				v.add(n, "holds entity %v more then once", e.ID)
+			}
+		}
+		if uint64(len(n.entities)) > v.q.maxEntities && n.depth < v.q.maxDepth {
+			v.add(n, "holds %v entities, more then the max %v, but was not split", len(n.entities), v.q.maxEntities)
+		}
+		return
+	}
+
+	// check branch nodes
+	if len(n.entities) > 0 {
+		v.add(n, "is a branch node holding %v entities", len(n.entities))
+	}
+	if len(n.children) != 4 {
+		v.add(n, "has %v children, want 4", len(n.children))
+	} else {
+		for i, b := range n.bound.quadrants() {
+			if !n.children[i].bound.IsEqual(b) {
+				v.add(n, "child %v has bound %v to %v, want %v to %v", i, n.children[i].bound.Min, n.children[i].bound.Max, b.Min, b.Max)
+			}
+		}
+	}
+	if n.collapsible() {
+		v.add(n, "children hold no more then the max %v entities but were not collapsed", v.q.maxEntities)
+	}
+
+	for _, child := range n.children {
+		if !child.shared && child.parent != n {
+			v.add(child, "does not link to its parent")
+		}
+		if child.depth != n.depth+1 {
+			v.add(child, "has depth %v, want %v", child.depth, n.depth+1)
+		}
+		v.node(child)
+	}
+}
+
+// covered checks that every leaf node under the given node that the entity overlaps holds it.
+func (v *validator) covered(n *node, e *Entity) {
+	if len(n.children) == 0 {
+		if !n.entities.Contains(e) {
+			v.add(n, "overlaps entity %v but does not hold it", e.ID)
+		}
+		return
+	}
+
+	for _, child := range n.children {
+		if child.bound.IsIntersect(e.Bound) {
+			v.covered(child, e)
+		}
+	}
+}
+
+// check validates the tree after a change when built with the quadgodebug build tag,
+// panicking if it is not valid.
+func (q *QuadGo) check() {
+	if !debug {
+		return
+	}
+
+	if err := q.Validate(); err != nil {
+		panic(err)
+	}
+}
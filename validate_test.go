@@ -0,0 +1,232 @@
+// Copyright 2019 Tskken. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package quadgo
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestQuadGo_Validate(t *testing.T) {
+	entities := randomEntities(200, 100, 100)
+
+	tests := []struct {
+		name string
+		tree func() *QuadGo
+	}{
+		{
+			name: "empty tree",
+			tree: func() *QuadGo {
+				return New(100, 100)
+			},
+		},
+		{
+			name: "built tree",
+			tree: func() *QuadGo {
+				return Build(100, 100, entities, SetMaxEntities(4))
+			},
+		},
+		{
+			name: "inserted tree",
+			tree: func() *QuadGo {
+				q := New(100, 100, SetMaxEntities(4))
+				q.InsertEntities(entities...)
+				return q
+			},
+		},
+		{
+			name: "removed from tree",
+			tree: func() *QuadGo {
+				q := Build(100, 100, entities, SetMaxEntities(4))
+				for _, e := range entities[:190] {
+					if err := q.Remove(e); err != nil {
+						t.Fatalf("QuadGo.Remove() got error %v", err)
+					}
+				}
+				return q
+			},
+		},
+		{
+			name: "limited by max depth",
+			tree: func() *QuadGo {
+				return Build(100, 100, entities, SetMaxEntities(2), SetMaxDepth(2))
+			},
+		},
+		{
+			name: "cloned tree",
+			tree: func() *QuadGo {
+				return Build(100, 100, entities, SetMaxEntities(4)).DeepClone()
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.tree().Validate(); err != nil {
+				t.Errorf("QuadGo.Validate() got error %v", err)
+			}
+		})
+	}
+}
+
+func TestQuadGo_Validate_violations(t *testing.T) {
+	entity := func(id uint64, minX, minY, maxX, maxY float64) *Entity {
+		return &Entity{ID: id, Bound: NewBound(minX, minY, maxX, maxY)}
+	}
+
+	// split returns a tree split once with an entity in each quadrant
+	split := func() *QuadGo {
+		return Build(100, 100, Entities{
+			entity(1, 0, 0, 10, 10),
+			entity(2, 60, 0, 70, 10),
+			entity(3, 0, 60, 10, 70),
+			entity(4, 60, 60, 70, 70),
+		}, SetMaxEntities(3))
+	}
+
+	tests := []struct {
+		name string
+		tree func() *QuadGo
+		want string
+	}{
+		{
+			name: "entity in leaf it does not overlap",
+			tree: func() *QuadGo {
+				q := split()
+				q.children[1].entities = append(q.children[1].entities, q.children[0].entities[0])
+				return q
+			},
+			want: "holds entity 1 which it does not overlap",
+		},
+		{
+			name: "entity in leaf twice",
+			tree: func() *QuadGo {
+				q := split()
+				q.children[0].entities = append(q.children[0].entities, q.children[0].entities[0])
+				return q
+			},
+			want: "holds entity 1 more then once",
+		},
+		{
+			name: "entity missing from leaf it overlaps",
+			tree: func() *QuadGo {
+				q := split()
+				e := entity(5, 40, 40, 60, 60)
+				for _, child := range q.children[:3] {
+					child.entities = append(child.entities, e)
+				}
+				q.size++
+				return q
+			},
+			want: "overlaps entity 5 but does not hold it",
+		},
+		{
+			name: "branch holding entities",
+			tree: func() *QuadGo {
+				q := split()
+				q.entities = append(q.entities, entity(5, 0, 0, 1, 1))
+				return q
+			},
+			want: "is a branch node holding 1 entities",
+		},
+		{
+			name: "children not tiling parent",
+			tree: func() *QuadGo {
+				q := split()
+				q.children[0].bound = NewBound(0, 0, 40, 50)
+				return q
+			},
+			want: "child 0 has bound",
+		},
+		{
+			name: "missing children",
+			tree: func() *QuadGo {
+				q := split()
+				q.children = q.children[:3]
+				q.size--
+				return q
+			},
+			want: "has 3 children, want 4",
+		},
+		{
+			name: "deeper then max depth",
+			tree: func() *QuadGo {
+				q := New(100, 100, SetMaxDepth(0))
+				q.split(nil)
+				return q
+			},
+			want: "deeper then max depth 0",
+		},
+		{
+			name: "wrong depth",
+			tree: func() *QuadGo {
+				q := split()
+				q.children[2].depth = 3
+				return q
+			},
+			want: "has depth 3, want 1",
+		},
+		{
+			name: "broken parent link",
+			tree: func() *QuadGo {
+				q := split()
+				q.children[3].parent = q.children[0]
+				return q
+			},
+			want: "does not link to its parent",
+		},
+		{
+			name: "leaf not split",
+			tree: func() *QuadGo {
+				q := New(100, 100, SetMaxEntities(1))
+				q.entities = append(q.entities, entity(1, 0, 0, 10, 10), entity(2, 50, 50, 60, 60))
+				q.size = 2
+				return q
+			},
+			want: "holds 2 entities, more then the max 1, but was not split",
+		},
+		{
+			name: "leaves not collapsed",
+			tree: func() *QuadGo {
+				q := split()
+				q.children[3].entities = q.children[3].entities[:0]
+				q.size--
+				return q
+			},
+			want: "children hold no more then the max 3 entities but were not collapsed",
+		},
+		{
+			name: "wrong len",
+			tree: func() *QuadGo {
+				q := split()
+				q.size = 7
+				return q
+			},
+			want: "tree has 4 entities but Len() is 7",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.tree().Validate()
+			if err == nil {
+				t.Fatalf("QuadGo.Validate() wanted an error")
+			}
+
+			verr, ok := err.(*ValidationError)
+			if !ok {
+				t.Fatalf("QuadGo.Validate() error = %T, want *ValidationError", err)
+			}
+
+			found := false
+			for _, v := range verr.Violations {
+				if strings.Contains(v, tt.want) {
+					found = true
+				}
+			}
+			if !found {
+				t.Errorf("QuadGo.Validate() = %v, want violation %q", err, tt.want)
+			}
+		})
+	}
+}